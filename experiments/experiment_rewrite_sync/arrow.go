@@ -0,0 +1,19 @@
+package backfill
+
+import "errors"
+
+// ErrArrowUnsupported is returned by NewArrowSink. Writing the Arrow IPC
+// streaming format needs a columnar builder and a Flatbuffers encoder
+// (e.g. apache/arrow-go); this module vendors no dependencies, stdlib-only,
+// so that encoder isn't available here. Until this module is allowed a
+// vendored dependency, get zero-copy pyarrow reads by writing JSON-lines
+// via FileSink and converting with `pyarrow.json.read_json`, or by piping
+// through a small Python/DuckDB step that re-emits Arrow/Feather.
+var ErrArrowUnsupported = errors.New("backfill: arrow ipc output requires a flatbuffers-capable dependency not vendored in this module")
+
+// NewArrowSink always returns ErrArrowUnsupported. It exists so a
+// --format arrow flag has somewhere to fail clearly instead of silently
+// falling back to another format.
+func NewArrowSink(path string) (Sink, error) {
+	return nil, ErrArrowUnsupported
+}
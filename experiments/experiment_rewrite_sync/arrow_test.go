@@ -0,0 +1,16 @@
+package backfill
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewArrowSinkReportsUnsupported(t *testing.T) {
+	sink, err := NewArrowSink("out.arrow")
+	if sink != nil {
+		t.Errorf("got non-nil sink, want nil")
+	}
+	if !errors.Is(err, ErrArrowUnsupported) {
+		t.Errorf("NewArrowSink() error = %v, want ErrArrowUnsupported", err)
+	}
+}
@@ -0,0 +1,218 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// OverflowPolicy controls what AsyncSink does when its internal queue is
+// full and a new record needs to be queued.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock (the default) makes Write block until the queue has
+	// room, the same backpressure a synchronous Sink would apply.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest discards the oldest not-yet-written queued record
+	// to make room for the new one, counting it in Dropped instead of
+	// blocking Write. Use when staying current matters more than writing
+	// every record.
+	OverflowDropOldest
+	// OverflowSpillToDisk appends the record as a JSON line under the
+	// directory set by WithSpillDir instead of queuing it, trading a slower
+	// recovery path for never blocking the caller or dropping data
+	// outright. Without WithSpillDir the overflowing record itself is
+	// discarded and counted in Dropped; the queued records are kept.
+	OverflowSpillToDisk
+)
+
+type queuedWrite struct {
+	did string
+	rec Record
+}
+
+// AsyncSink wraps a Sink with a bounded queue serviced by its own pool of
+// goroutines, so a briefly slow downstream write (an S3 upload, a database
+// round trip) doesn't stall the caller's fetch workers. Because the actual
+// write happens off the caller's goroutine, Write can't return next's
+// error synchronously; the first one is latched and surfaced by Close.
+type AsyncSink struct {
+	next     Sink
+	queue    chan queuedWrite
+	policy   OverflowPolicy
+	spillDir string
+
+	wg sync.WaitGroup
+
+	mu       sync.Mutex
+	dropped  int
+	writeErr error
+}
+
+// AsyncSinkOption configures an AsyncSink constructed with NewAsyncSink.
+type AsyncSinkOption func(*AsyncSink)
+
+// WithOverflowPolicy sets what happens once the queue is full. Defaults to
+// OverflowBlock.
+func WithOverflowPolicy(p OverflowPolicy) AsyncSinkOption {
+	return func(s *AsyncSink) { s.policy = p }
+}
+
+// WithSpillDir sets the directory OverflowSpillToDisk appends overflowed
+// records to, as JSON lines in overflow.jsonl. Required for
+// OverflowSpillToDisk; ignored by the other policies.
+func WithSpillDir(dir string) AsyncSinkOption {
+	return func(s *AsyncSink) { s.spillDir = dir }
+}
+
+// NewAsyncSink creates an AsyncSink that writes to next from workers
+// goroutines (fewer than 1 is treated as 1), queuing up to queueCapacity
+// records in between (fewer than 1 is treated as 1).
+func NewAsyncSink(next Sink, workers, queueCapacity int, opts ...AsyncSinkOption) *AsyncSink {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueCapacity < 1 {
+		queueCapacity = 1
+	}
+
+	s := &AsyncSink{next: next, queue: make(chan queuedWrite, queueCapacity)}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go s.worker()
+	}
+	return s
+}
+
+func (s *AsyncSink) worker() {
+	defer s.wg.Done()
+	for item := range s.queue {
+		if err := s.next.Write(context.Background(), item.did, item.rec); err != nil {
+			s.mu.Lock()
+			if s.writeErr == nil {
+				s.writeErr = fmt.Errorf("async write for %s: %w", item.did, err)
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// Write queues rec for a worker to write to next. Under OverflowBlock (the
+// default) it blocks until the queue has room, or returns ctx.Err() if ctx
+// is canceled first; under OverflowDropOldest and OverflowSpillToDisk it
+// never blocks.
+func (s *AsyncSink) Write(ctx context.Context, did string, rec Record) error {
+	item := queuedWrite{did: did, rec: rec}
+
+	switch s.policy {
+	case OverflowDropOldest:
+		select {
+		case s.queue <- item:
+		default:
+			select {
+			case <-s.queue:
+				s.mu.Lock()
+				s.dropped++
+				s.mu.Unlock()
+			default:
+			}
+			select {
+			case s.queue <- item:
+			default:
+				s.mu.Lock()
+				s.dropped++
+				s.mu.Unlock()
+			}
+		}
+		return nil
+	case OverflowSpillToDisk:
+		select {
+		case s.queue <- item:
+			return nil
+		default:
+			return s.spill(did, rec)
+		}
+	default:
+		select {
+		case s.queue <- item:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// spilledRecord is the JSON shape of a line in overflow.jsonl, as written
+// when OverflowSpillToDisk's queue is full.
+type spilledRecord struct {
+	DID    string `json:"did"`
+	Record Record `json:"record"`
+}
+
+func (s *AsyncSink) spill(did string, rec Record) error {
+	if s.spillDir == "" {
+		s.mu.Lock()
+		s.dropped++
+		s.mu.Unlock()
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(s.spillDir, 0o755); err != nil {
+		return fmt.Errorf("spill overflow: %w", err)
+	}
+	f, err := os.OpenFile(filepath.Join(s.spillDir, "overflow.jsonl"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("spill overflow: %w", err)
+	}
+	defer f.Close()
+
+	line, err := json.Marshal(spilledRecord{DID: did, Record: rec})
+	if err != nil {
+		return fmt.Errorf("spill overflow: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("spill overflow: %w", err)
+	}
+	return nil
+}
+
+// Dropped returns the number of records discarded by OverflowDropOldest
+// (or by OverflowSpillToDisk when WithSpillDir was never set), across the
+// sink's whole lifetime.
+func (s *AsyncSink) Dropped() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+// Close stops the worker pool once every already-queued write has been
+// delivered to next, closes next if it implements io.Closer, and returns
+// the first error encountered by either. Close assumes no concurrent
+// Write calls, the same assumption every other Sink in this package makes.
+func (s *AsyncSink) Close() error {
+	close(s.queue)
+	s.wg.Wait()
+
+	var closeErr error
+	if inner, ok := s.next.(interface{ Close() error }); ok {
+		closeErr = inner.Close()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.writeErr != nil {
+		return s.writeErr
+	}
+	return closeErr
+}
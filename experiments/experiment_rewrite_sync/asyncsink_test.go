@@ -0,0 +1,180 @@
+package backfill
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncedRecordingSink is recordingSink made safe for concurrent Write
+// calls, since AsyncSink delivers to next from worker goroutines.
+type syncedRecordingSink struct {
+	mu     sync.Mutex
+	writes []Record
+	err    error
+}
+
+func (s *syncedRecordingSink) Write(ctx context.Context, did string, rec Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.writes = append(s.writes, rec)
+	return nil
+}
+
+func (s *syncedRecordingSink) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.writes)
+}
+
+func TestAsyncSinkDeliversEveryWriteToNext(t *testing.T) {
+	next := &syncedRecordingSink{}
+	sink := NewAsyncSink(next, 2, 4)
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		if err := sink.Write(ctx, "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1"}); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if got := next.len(); got != 10 {
+		t.Fatalf("next received %d writes, want 10", got)
+	}
+}
+
+func TestAsyncSinkCloseSurfacesNextWriteError(t *testing.T) {
+	wantErr := errors.New("boom")
+	next := &syncedRecordingSink{err: wantErr}
+	sink := NewAsyncSink(next, 1, 1)
+
+	if err := sink.Write(context.Background(), "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if err := sink.Close(); !errors.Is(err, wantErr) {
+		t.Fatalf("Close() error = %v, want it to wrap %v", err, wantErr)
+	}
+}
+
+func TestAsyncSinkOverflowBlockBlocksUntilRoom(t *testing.T) {
+	release := make(chan struct{})
+	blocking := sinkFunc(func(ctx context.Context, did string, rec Record) error {
+		<-release
+		return nil
+	})
+	sink := NewAsyncSink(blocking, 1, 1)
+	ctx := context.Background()
+
+	// Fill the one worker and the one queue slot.
+	if err := sink.Write(ctx, "did:plc:alice", Record{}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := sink.Write(ctx, "did:plc:alice", Record{}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- sink.Write(ctx, "did:plc:alice", Record{}) }()
+
+	select {
+	case <-done:
+		t.Fatal("Write() returned before the queue had room, want it to block")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Write() never unblocked after the queue drained")
+	}
+}
+
+func TestAsyncSinkOverflowBlockRespectsContextCancellation(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	blocking := sinkFunc(func(ctx context.Context, did string, rec Record) error {
+		<-release
+		return nil
+	})
+	sink := NewAsyncSink(blocking, 1, 1)
+
+	bg := context.Background()
+	if err := sink.Write(bg, "did:plc:alice", Record{}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := sink.Write(bg, "did:plc:alice", Record{}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(bg)
+	cancel()
+	if err := sink.Write(ctx, "did:plc:alice", Record{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Write() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestAsyncSinkOverflowDropOldestNeverBlocks(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	blocking := sinkFunc(func(ctx context.Context, did string, rec Record) error {
+		<-release
+		return nil
+	})
+	sink := NewAsyncSink(blocking, 1, 1, WithOverflowPolicy(OverflowDropOldest))
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		if err := sink.Write(ctx, "did:plc:alice", Record{}); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	if got := sink.Dropped(); got == 0 {
+		t.Error("Dropped() = 0, want at least one overflowed write to have been dropped")
+	}
+}
+
+func TestAsyncSinkOverflowSpillToDiskWritesOverflowFile(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	blocking := sinkFunc(func(ctx context.Context, did string, rec Record) error {
+		<-release
+		return nil
+	})
+	dir := t.TempDir()
+	sink := NewAsyncSink(blocking, 1, 1, WithOverflowPolicy(OverflowSpillToDisk), WithSpillDir(dir))
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		if err := sink.Write(ctx, "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1"}); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "overflow.jsonl")); err != nil {
+		t.Fatalf("overflow.jsonl missing: %v", err)
+	}
+}
+
+// sinkFunc adapts a function to the Sink interface, for tests that need a
+// next sink with custom blocking or failing behavior.
+type sinkFunc func(ctx context.Context, did string, rec Record) error
+
+func (f sinkFunc) Write(ctx context.Context, did string, rec Record) error {
+	return f(ctx, did, rec)
+}
@@ -0,0 +1,350 @@
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Run resolves every DID in cfg.DIDs (concurrently, bounded by
+// cfg.Concurrency), schedules the resolved DIDs so work spreads evenly
+// across PDS hosts, applies the configured allow/block filters, and
+// returns a Result describing what happened to each one. Fetching the
+// repo itself is not yet part of Run.
+//
+// Setting cfg.LoadResolutionMapPath instead skips resolution entirely,
+// loading the resolved set from a map written by an earlier run's
+// cfg.ResolutionMapPath — useful when only the fetch phase needs to
+// rerun and re-hitting the PLC directory would be wasted work.
+func Run(ctx context.Context, cfg Config) (*Result, error) {
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+		if cfg.DNSCacheTTL > 0 {
+			httpClient = &http.Client{Transport: NewDNSCachingTransport(nil, cfg.DNSCacheTTL)}
+		}
+	}
+	hooks := hooksOrNoop(cfg.Hooks)
+
+	tracker := newInFlightTracker()
+	if cfg.HeartbeatInterval > 0 {
+		stopHeartbeat := make(chan struct{})
+		defer close(stopHeartbeat)
+		go runHeartbeat(cfg.HeartbeatInterval, tracker, hooks, stopHeartbeat)
+	}
+
+	runID := cfg.RunID
+	if runID == "" {
+		runID = NewRunID()
+	}
+
+	var watchdog *Watchdog
+	if cfg.StuckDIDTimeout > 0 {
+		watchdog = &Watchdog{Timeout: cfg.StuckDIDTimeout}
+	}
+
+	var registry *RepoRegistry
+	if cfg.RegistryPath != "" {
+		reg, err := OpenRepoRegistry(cfg.RegistryPath)
+		if err != nil {
+			return nil, err
+		}
+		registry = reg
+	}
+
+	var limiter requestLimiter
+	switch {
+	case cfg.RedisRateLimit != nil:
+		limiter = newRedisRateLimiter(*cfg.RedisRateLimit)
+	case cfg.RateLimit > 0:
+		local := newTokenBucketLimiter(cfg.RateLimit)
+		if state, ok, err := loadRateLimiterState(cfg.RateLimitStatePath); err == nil && ok {
+			local.restore(state.Tokens)
+		}
+		defer func() {
+			_ = saveRateLimiterState(cfg.RateLimitStatePath, rateLimiterState{Tokens: local.snapshot()})
+		}()
+		limiter = local
+	}
+
+	// concurrencyLimiter bounds how many DIDs are resolved or processed at
+	// once overall, independent of hostLim's per-host bound below; it
+	// reuses hostLimiter against a single shared key since the two have
+	// identical "N at once, 0 means unlimited" semantics.
+	concurrencyLimiter := newHostLimiter(cfg.Concurrency)
+
+	result := &Result{RunID: runID}
+	var resolved []ResolvedDID
+	var resolveFailures []DIDResult
+	if cfg.LoadResolutionMapPath != "" {
+		loaded, err := LoadResolutionMap(cfg.LoadResolutionMapPath)
+		if err != nil {
+			return nil, err
+		}
+		resolved = loaded
+	} else {
+		var resolveMu sync.Mutex
+		var resolveWG sync.WaitGroup
+		for item := range workItemChannel(cfg) {
+			resolveWG.Add(1)
+			go func(item WorkItem) {
+				defer resolveWG.Done()
+				release := concurrencyLimiter.acquire("")
+				defer release()
+
+				id := tracker.start()
+				defer tracker.finish(id)
+
+				start := time.Now()
+				hooks.OnDIDStart(item.DID)
+				// resolveWithRetry calls ResolveDID, which coalesces duplicate
+				// DIDs across these goroutines via resolveGroup, so a DID
+				// repeated in cfg.DIDs only hits the PLC directory once.
+				r, attempts, err := resolveWithRetry(ctx, httpClient, cfg.PLCDirectoryURL, item.DID, defaultRetryPolicy, watchdog, limiter, hooks)
+				if err != nil {
+					hooks.OnError(item.DID, err)
+					didResult := DIDResult{DID: item.DID, SpanID: NewSpanID(), Err: err, ErrClass: classifyErr(err), Attempts: attempts, Duration: time.Since(start)}
+					hooks.OnDIDComplete(didResult)
+					recordToRegistry(registry, didResult)
+					resolveMu.Lock()
+					resolveFailures = append(resolveFailures, didResult)
+					if !cfg.SkipPerDIDResults {
+						result.PerDID = append(result.PerDID, didResult)
+					}
+					resolveMu.Unlock()
+					return
+				}
+				resolveMu.Lock()
+				resolved = append(resolved, r)
+				resolveMu.Unlock()
+			}(item)
+		}
+		resolveWG.Wait()
+
+		if cfg.ResolutionMapPath != "" {
+			if err := WriteResolutionMap(resolved, resolveFailures, cfg.ResolutionMapPath); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if cfg.WarmUpHosts {
+		result.ConnectionStats = WarmHosts(ctx, httpClient, resolved, cfg.WarmUpTopNHosts)
+	}
+
+	hostLim := newHostLimiter(cfg.MaxInFlightPerHost)
+
+	// recordsUsed and bytesUsed track progress against cfg.MaxRecords and
+	// cfg.MaxBytes; budgetExceeded is checked before each DID starts
+	// fetching so the run winds down once the budget is spent instead of
+	// processing every DID in cfg.DIDs.
+	var recordsUsed, bytesUsed int64
+	budgetExceeded := func() bool {
+		return (cfg.MaxRecords > 0 && atomic.LoadInt64(&recordsUsed) >= cfg.MaxRecords) ||
+			(cfg.MaxBytes > 0 && atomic.LoadInt64(&bytesUsed) >= cfg.MaxBytes)
+	}
+
+	var wg sync.WaitGroup
+	var perDID []DIDResult
+	if cfg.HealthAwareScheduling {
+		// Unlike the static scheduleByHost ordering below, scheduler.next()
+		// is consulted by whichever goroutine next acquires a concurrency
+		// slot, so the assignment reflects each host's health at the
+		// moment a worker actually becomes free to take more work, not
+		// just at the start of the run.
+		health := newHostHealthTracker()
+		scheduler := newHealthAwareScheduler(resolved, health)
+		var perDIDMu sync.Mutex
+		for i := 0; i < len(resolved); i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				releaseConcurrency := concurrencyLimiter.acquire("")
+				defer releaseConcurrency()
+
+				r, ok := scheduler.next()
+				if !ok {
+					return
+				}
+
+				id := tracker.start()
+				defer tracker.finish(id)
+
+				start := time.Now()
+				var didResult DIDResult
+				if budgetExceeded() {
+					didResult = budgetExceededResult(r, start)
+				} else {
+					release := hostLim.acquire(r.PDSHost)
+					defer release()
+
+					didResult = processScheduledDID(ctx, httpClient, cfg, r, start)
+					health.record(r.PDSHost, didResult.Err, didResult.Duration)
+				}
+				atomic.AddInt64(&recordsUsed, int64(didResult.Records))
+				atomic.AddInt64(&bytesUsed, didResult.Bytes)
+				hooks.OnDIDComplete(didResult)
+				if didResult.Err != nil {
+					hooks.OnError(r.DID, didResult.Err)
+				}
+				recordToRegistry(registry, didResult)
+				perDIDMu.Lock()
+				perDID = append(perDID, didResult)
+				perDIDMu.Unlock()
+			}()
+		}
+	} else {
+		scheduled := scheduleByHost(resolved)
+		perDID = make([]DIDResult, len(scheduled))
+		for i, r := range scheduled {
+			wg.Add(1)
+			go func(i int, r ResolvedDID) {
+				defer wg.Done()
+				releaseConcurrency := concurrencyLimiter.acquire("")
+				defer releaseConcurrency()
+
+				id := tracker.start()
+				defer tracker.finish(id)
+
+				start := time.Now()
+				var didResult DIDResult
+				if budgetExceeded() {
+					didResult = budgetExceededResult(r, start)
+				} else {
+					release := hostLim.acquire(r.PDSHost)
+					defer release()
+
+					didResult = processScheduledDID(ctx, httpClient, cfg, r, start)
+				}
+				atomic.AddInt64(&recordsUsed, int64(didResult.Records))
+				atomic.AddInt64(&bytesUsed, didResult.Bytes)
+				hooks.OnDIDComplete(didResult)
+				if didResult.Err != nil {
+					hooks.OnError(r.DID, didResult.Err)
+				}
+				recordToRegistry(registry, didResult)
+				perDID[i] = didResult
+			}(i, r)
+		}
+	}
+	wg.Wait()
+
+	if cfg.TimeoutRetry != nil {
+		perDID = retryTimedOutDIDs(ctx, httpClient, cfg, perDID, resolved, registry, tracker, hooks)
+	}
+
+	if !cfg.SkipPerDIDResults {
+		result.PerDID = append(result.PerDID, perDID...)
+	}
+	return result, nil
+}
+
+// recordToRegistry upserts d's outcome into registry, a no-op if registry
+// is nil (the registry is disabled). Errors from the upsert are
+// deliberately swallowed: a registry write failure shouldn't fail the
+// DID it's describing, which has already fully completed by this point.
+func recordToRegistry(registry *RepoRegistry, d DIDResult) {
+	if registry == nil {
+		return
+	}
+	status := "ok"
+	switch {
+	case d.Err != nil:
+		status = "error"
+	case d.Filtered:
+		status = "filtered"
+	}
+	_ = registry.Upsert(RegistryEntry{
+		DID:          d.DID,
+		LastSyncedAt: time.Now(),
+		RecordCount:  d.Records,
+		Status:       status,
+	})
+}
+
+// budgetExceededResult builds the DIDResult for a DID skipped because
+// cfg.MaxRecords or cfg.MaxBytes was already reached by the time its
+// worker was ready to fetch it.
+func budgetExceededResult(r ResolvedDID, start time.Time) DIDResult {
+	return DIDResult{
+		DID:      r.DID,
+		SpanID:   NewSpanID(),
+		PDSHost:  r.PDSHost,
+		Handle:   r.Handle,
+		Filtered: true,
+		Reason:   "max-records/max-bytes budget reached",
+		Attempts: r.Attempts,
+		Duration: time.Since(start),
+	}
+}
+
+// processScheduledDID applies the configured filters to a resolved DID
+// and builds its DIDResult, recovering a panic (e.g. from malformed repo
+// data a later fetch stage might trip over) into a failed DIDResult
+// instead of letting it crash the whole run's worker pool.
+func processScheduledDID(ctx context.Context, httpClient *http.Client, cfg Config, r ResolvedDID, start time.Time) DIDResult {
+	return recoverDIDResult(r, start, func() DIDResult {
+		decision := applyFilters(cfg, r)
+		didResult := DIDResult{
+			DID:      r.DID,
+			SpanID:   NewSpanID(),
+			PDSHost:  r.PDSHost,
+			Handle:   r.Handle,
+			Filtered: !decision.Allowed,
+			Reason:   decision.Reason,
+			Attempts: r.Attempts,
+			Duration: time.Since(start),
+		}
+
+		if cfg.VerifyHandles && decision.Allowed && r.Handle != "" {
+			verified, err := VerifyHandle(ctx, httpClient, r.DID, r.Handle)
+			didResult.HandleVerified = verified
+			didResult.HandleVerifyErr = err
+		}
+		return didResult
+	})
+}
+
+// recoverDIDResult runs work and returns its DIDResult, unless work
+// panics, in which case the panic is recovered and turned into a failed
+// DIDResult carrying ErrPanic instead of propagating and killing the
+// worker pool.
+func recoverDIDResult(r ResolvedDID, start time.Time, work func() DIDResult) (didResult DIDResult) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err := fmt.Errorf("%w: %v", ErrPanic, rec)
+			didResult = DIDResult{DID: r.DID, SpanID: NewSpanID(), PDSHost: r.PDSHost, Handle: r.Handle, Err: err, ErrClass: classifyErr(err), Duration: time.Since(start)}
+		}
+	}()
+	return work()
+}
+
+// resolveWithWatchdog calls ResolveDID under watchdog's timeout, retrying
+// once with a fresh context if the watchdog fires (and not at all if it
+// doesn't, or if watchdog is nil). A second watchdog timeout on the retry
+// is returned as a plain error rather than retried again, so a DID that's
+// always stuck can't loop forever.
+func resolveWithWatchdog(ctx context.Context, httpClient *http.Client, plcDirectoryURL, did string, watchdog *Watchdog, hooks Hooks) (ResolvedDID, error) {
+	const maxAttempts = 2
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		attemptCtx, cancel := context.WithCancel(ctx)
+		done, stuck := watchdog.Watch(func(elapsed time.Duration, stack []byte) {
+			hooks.OnDIDStuck(did, elapsed, stack)
+			cancel()
+		})
+
+		r, err := ResolveDID(attemptCtx, httpClient, plcDirectoryURL, did)
+		done()
+		cancel()
+
+		if err == nil || !stuck() {
+			return r, err
+		}
+		lastErr = err
+	}
+	return ResolvedDID{}, lastErr
+}
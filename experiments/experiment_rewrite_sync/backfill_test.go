@@ -0,0 +1,474 @@
+package backfill_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	backfill "github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync"
+	"github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync/backfilltest"
+)
+
+func TestRunResolvesAgainstMockPLC(t *testing.T) {
+	pds := backfilltest.NewPDS(nil)
+	defer pds.Close()
+
+	plc := backfilltest.NewPLCDirectory(map[string]backfilltest.PLCDoc{
+		"did:plc:alice": backfilltest.PDSDoc(pds.URL, "alice.bsky.social"),
+	})
+	defer plc.Close()
+
+	cfg := backfill.Config{
+		DIDs:            []backfill.WorkItem{{DID: "did:plc:alice"}},
+		PLCDirectoryURL: plc.URL,
+	}
+
+	result, err := backfill.Run(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(result.PerDID) != 1 {
+		t.Fatalf("got %d results, want 1", len(result.PerDID))
+	}
+
+	got := result.PerDID[0]
+	if got.Err != nil {
+		t.Fatalf("unexpected error resolving did:plc:alice: %v", got.Err)
+	}
+	if got.Handle != "alice.bsky.social" {
+		t.Errorf("Handle = %q, want %q", got.Handle, "alice.bsky.social")
+	}
+	if got.Filtered {
+		t.Errorf("Filtered = true, want false")
+	}
+}
+
+func TestRunGeneratesARunIDAndPerDIDSpanIDByDefault(t *testing.T) {
+	pds := backfilltest.NewPDS(nil)
+	defer pds.Close()
+
+	plc := backfilltest.NewPLCDirectory(map[string]backfilltest.PLCDoc{
+		"did:plc:alice": backfilltest.PDSDoc(pds.URL, "alice.bsky.social"),
+	})
+	defer plc.Close()
+
+	cfg := backfill.Config{
+		DIDs:            []backfill.WorkItem{{DID: "did:plc:alice"}},
+		PLCDirectoryURL: plc.URL,
+	}
+
+	result, err := backfill.Run(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.RunID == "" {
+		t.Error("RunID is empty, want one generated by default")
+	}
+	if len(result.PerDID) != 1 || result.PerDID[0].SpanID == "" {
+		t.Error("PerDID[0].SpanID is empty, want one generated per DID")
+	}
+}
+
+func TestRunUsesConfigRunIDWhenSet(t *testing.T) {
+	pds := backfilltest.NewPDS(nil)
+	defer pds.Close()
+
+	plc := backfilltest.NewPLCDirectory(map[string]backfilltest.PLCDoc{
+		"did:plc:alice": backfilltest.PDSDoc(pds.URL, "alice.bsky.social"),
+	})
+	defer plc.Close()
+
+	cfg := backfill.Config{
+		DIDs:            []backfill.WorkItem{{DID: "did:plc:alice"}},
+		PLCDirectoryURL: plc.URL,
+		RunID:           "fixed-run-id",
+	}
+
+	result, err := backfill.Run(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.RunID != "fixed-run-id" {
+		t.Errorf("RunID = %q, want %q", result.RunID, "fixed-run-id")
+	}
+}
+
+func TestRunSkipsPerDIDResultsWhenConfigured(t *testing.T) {
+	pds := backfilltest.NewPDS(nil)
+	defer pds.Close()
+
+	plc := backfilltest.NewPLCDirectory(map[string]backfilltest.PLCDoc{
+		"did:plc:alice": backfilltest.PDSDoc(pds.URL, "alice.bsky.social"),
+	})
+	defer plc.Close()
+
+	cfg := backfill.Config{
+		DIDs:              []backfill.WorkItem{{DID: "did:plc:alice"}},
+		PLCDirectoryURL:   plc.URL,
+		SkipPerDIDResults: true,
+	}
+
+	result, err := backfill.Run(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(result.PerDID) != 0 {
+		t.Fatalf("got %d results, want 0 with SkipPerDIDResults set", len(result.PerDID))
+	}
+}
+
+func TestRunWithConcurrencyLimitStillProcessesEveryDID(t *testing.T) {
+	var dids []backfill.WorkItem
+	plcDocs := map[string]backfilltest.PLCDoc{}
+	pds := backfilltest.NewPDS(nil)
+	defer pds.Close()
+
+	for i := 0; i < 10; i++ {
+		did := fmt.Sprintf("did:plc:user%d", i)
+		dids = append(dids, backfill.WorkItem{DID: did})
+		plcDocs[did] = backfilltest.PDSDoc(pds.URL, fmt.Sprintf("user%d.bsky.social", i))
+	}
+	plc := backfilltest.NewPLCDirectory(plcDocs)
+	defer plc.Close()
+
+	cfg := backfill.Config{
+		DIDs:            dids,
+		PLCDirectoryURL: plc.URL,
+		Concurrency:     3,
+	}
+
+	result, err := backfill.Run(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(result.PerDID) != len(dids) {
+		t.Fatalf("got %d results, want %d", len(result.PerDID), len(dids))
+	}
+	for _, d := range result.PerDID {
+		if d.Err != nil {
+			t.Errorf("DID %s: unexpected error %v", d.DID, d.Err)
+		}
+	}
+}
+
+func TestRunWithMaxRecordsAndMaxBytesSetStillProcessesEveryDIDWhenBudgetNotReached(t *testing.T) {
+	var dids []backfill.WorkItem
+	plcDocs := map[string]backfilltest.PLCDoc{}
+	pds := backfilltest.NewPDS(nil)
+	defer pds.Close()
+
+	for i := 0; i < 5; i++ {
+		did := fmt.Sprintf("did:plc:user%d", i)
+		dids = append(dids, backfill.WorkItem{DID: did})
+		plcDocs[did] = backfilltest.PDSDoc(pds.URL, fmt.Sprintf("user%d.bsky.social", i))
+	}
+	plc := backfilltest.NewPLCDirectory(plcDocs)
+	defer plc.Close()
+
+	cfg := backfill.Config{
+		DIDs:            dids,
+		PLCDirectoryURL: plc.URL,
+		MaxRecords:      1_000_000,
+		MaxBytes:        1_000_000_000,
+	}
+
+	result, err := backfill.Run(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(result.PerDID) != len(dids) {
+		t.Fatalf("got %d results, want %d", len(result.PerDID), len(dids))
+	}
+	for _, d := range result.PerDID {
+		if d.Filtered {
+			t.Errorf("DID %s: Filtered = true with a budget nowhere near reached, want false (Reason: %s)", d.DID, d.Reason)
+		}
+	}
+}
+
+func TestRunPersistsAndRestoresRateLimiterState(t *testing.T) {
+	pds := backfilltest.NewPDS(nil)
+	defer pds.Close()
+	plc := backfilltest.NewPLCDirectory(map[string]backfilltest.PLCDoc{
+		"did:plc:alice": backfilltest.PDSDoc(pds.URL, "alice.bsky.social"),
+	})
+	defer plc.Close()
+
+	statePath := filepath.Join(t.TempDir(), "rate-limit.json")
+	cfg := backfill.Config{
+		DIDs:               []backfill.WorkItem{{DID: "did:plc:alice"}},
+		PLCDirectoryURL:    plc.URL,
+		RateLimit:          5,
+		RateLimitStatePath: statePath,
+	}
+
+	if _, err := backfill.Run(context.Background(), cfg); err != nil {
+		t.Fatalf("first Run() error = %v", err)
+	}
+	if _, err := os.Stat(statePath); err != nil {
+		t.Fatalf("expected rate limiter state file to exist after Run(): %v", err)
+	}
+
+	if _, err := backfill.Run(context.Background(), cfg); err != nil {
+		t.Fatalf("second Run() error = %v", err)
+	}
+}
+
+type stuckRecordingHooks struct {
+	backfill.NoopHooks
+	mu    sync.Mutex
+	stuck []string
+}
+
+func (h *stuckRecordingHooks) OnDIDStuck(did string, elapsed time.Duration, stack []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stuck = append(h.stuck, did)
+}
+
+func TestRunWatchdogFiresAndRetriesOnAWedgedPLCResponse(t *testing.T) {
+	plc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer plc.Close()
+
+	hooks := &stuckRecordingHooks{}
+	cfg := backfill.Config{
+		DIDs:            []backfill.WorkItem{{DID: "did:plc:alice"}},
+		PLCDirectoryURL: plc.URL,
+		StuckDIDTimeout: 10 * time.Millisecond,
+		Hooks:           hooks,
+	}
+
+	result, err := backfill.Run(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(result.PerDID) != 1 || result.PerDID[0].Err == nil {
+		t.Fatalf("got %+v, want one errored DIDResult (the PLC never responded)", result.PerDID)
+	}
+
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+	if len(hooks.stuck) != 2 {
+		t.Errorf("OnDIDStuck fired %d times, want 2 (initial attempt + one retry)", len(hooks.stuck))
+	}
+}
+
+func TestRunCoalescesDuplicateDIDsInInput(t *testing.T) {
+	pds := backfilltest.NewPDS(nil)
+	defer pds.Close()
+
+	var plcRequests int32
+	plc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&plcRequests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(backfilltest.PDSDoc(pds.URL, "alice.bsky.social"))
+	}))
+	defer plc.Close()
+
+	cfg := backfill.Config{
+		DIDs: []backfill.WorkItem{
+			{DID: "did:plc:alice"},
+			{DID: "did:plc:alice"},
+			{DID: "did:plc:alice"},
+		},
+		PLCDirectoryURL: plc.URL,
+	}
+
+	result, err := backfill.Run(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(result.PerDID) != 3 {
+		t.Fatalf("got %d results, want 3 (one per input entry, even though they share a DID)", len(result.PerDID))
+	}
+	for _, d := range result.PerDID {
+		if d.Err != nil {
+			t.Errorf("unexpected error resolving did:plc:alice: %v", d.Err)
+		}
+	}
+	if got := atomic.LoadInt32(&plcRequests); got != 1 {
+		t.Errorf("PLC directory got %d requests for 3 duplicate DIDs in cfg.DIDs, want 1", got)
+	}
+}
+
+func TestRunRecordsEachDIDToTheRegistry(t *testing.T) {
+	pds := backfilltest.NewPDS(nil)
+	defer pds.Close()
+
+	plc := backfilltest.NewPLCDirectory(map[string]backfilltest.PLCDoc{
+		"did:plc:alice": backfilltest.PDSDoc(pds.URL, "alice.bsky.social"),
+	})
+	defer plc.Close()
+
+	registryPath := filepath.Join(t.TempDir(), "registry.json")
+	cfg := backfill.Config{
+		DIDs:            []backfill.WorkItem{{DID: "did:plc:alice"}},
+		PLCDirectoryURL: plc.URL,
+		RegistryPath:    registryPath,
+	}
+
+	if _, err := backfill.Run(context.Background(), cfg); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	reg, err := backfill.OpenRepoRegistry(registryPath)
+	if err != nil {
+		t.Fatalf("OpenRepoRegistry() error = %v", err)
+	}
+	entry, ok := reg.Get("did:plc:alice")
+	if !ok {
+		t.Fatal("registry has no entry for did:plc:alice after Run()")
+	}
+	if entry.Status != "ok" {
+		t.Errorf("entry.Status = %q, want %q", entry.Status, "ok")
+	}
+}
+
+func TestRunFlagsAnUnverifiableHandleWhenVerifyHandlesIsSet(t *testing.T) {
+	pds := backfilltest.NewPDS(nil)
+	defer pds.Close()
+
+	// alice.bsky.social.invalid has neither a DNS TXT record nor a well-known
+	// endpoint pointing back at did:plc:alice in this test, so
+	// verification should fail closed rather than being skipped.
+	plc := backfilltest.NewPLCDirectory(map[string]backfilltest.PLCDoc{
+		"did:plc:alice": backfilltest.PDSDoc(pds.URL, "alice.bsky.social.invalid"),
+	})
+	defer plc.Close()
+
+	cfg := backfill.Config{
+		DIDs:            []backfill.WorkItem{{DID: "did:plc:alice"}},
+		PLCDirectoryURL: plc.URL,
+		VerifyHandles:   true,
+	}
+
+	result, err := backfill.Run(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(result.PerDID) != 1 {
+		t.Fatalf("got %d results, want 1", len(result.PerDID))
+	}
+	if result.PerDID[0].HandleVerified {
+		t.Error("HandleVerified = true, want false for a handle with no matching DNS or well-known record")
+	}
+}
+
+func TestRunWithHealthAwareSchedulingProcessesEveryResolvedDID(t *testing.T) {
+	pdsA := backfilltest.NewPDS(nil)
+	defer pdsA.Close()
+	pdsB := backfilltest.NewPDS(nil)
+	defer pdsB.Close()
+
+	plc := backfilltest.NewPLCDirectory(map[string]backfilltest.PLCDoc{
+		"did:plc:alice": backfilltest.PDSDoc(pdsA.URL, "alice.bsky.social"),
+		"did:plc:bob":   backfilltest.PDSDoc(pdsA.URL, "bob.bsky.social"),
+		"did:plc:carol": backfilltest.PDSDoc(pdsB.URL, "carol.example.com"),
+	})
+	defer plc.Close()
+
+	cfg := backfill.Config{
+		DIDs: []backfill.WorkItem{
+			{DID: "did:plc:alice"},
+			{DID: "did:plc:bob"},
+			{DID: "did:plc:carol"},
+		},
+		PLCDirectoryURL:       plc.URL,
+		HealthAwareScheduling: true,
+	}
+
+	result, err := backfill.Run(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(result.PerDID) != 3 {
+		t.Fatalf("got %d results, want 3", len(result.PerDID))
+	}
+
+	seen := make(map[string]bool)
+	for _, r := range result.PerDID {
+		if r.Err != nil {
+			t.Errorf("unexpected error for %s: %v", r.DID, r.Err)
+		}
+		seen[r.DID] = true
+	}
+	for _, did := range []string{"did:plc:alice", "did:plc:bob", "did:plc:carol"} {
+		if !seen[did] {
+			t.Errorf("missing result for %s", did)
+		}
+	}
+}
+
+func TestRunWritesThenLoadsAResolutionMapSkippingPLC(t *testing.T) {
+	pds := backfilltest.NewPDS(nil)
+	defer pds.Close()
+
+	plc := backfilltest.NewPLCDirectory(map[string]backfilltest.PLCDoc{
+		"did:plc:alice": backfilltest.PDSDoc(pds.URL, "alice.bsky.social"),
+	})
+	defer plc.Close()
+
+	mapPath := filepath.Join(t.TempDir(), "resolution.json")
+	firstRun := backfill.Config{
+		DIDs:              []backfill.WorkItem{{DID: "did:plc:alice"}},
+		PLCDirectoryURL:   plc.URL,
+		ResolutionMapPath: mapPath,
+	}
+	if _, err := backfill.Run(context.Background(), firstRun); err != nil {
+		t.Fatalf("first Run() error = %v", err)
+	}
+
+	// Close the PLC directory so a second Run can only succeed by
+	// skipping it entirely and loading the persisted resolution map.
+	plc.Close()
+
+	secondRun := backfill.Config{
+		PLCDirectoryURL:       plc.URL,
+		LoadResolutionMapPath: mapPath,
+	}
+	result, err := backfill.Run(context.Background(), secondRun)
+	if err != nil {
+		t.Fatalf("second Run() error = %v", err)
+	}
+	if len(result.PerDID) != 1 {
+		t.Fatalf("got %d results, want 1", len(result.PerDID))
+	}
+	if got := result.PerDID[0]; got.DID != "did:plc:alice" || got.Handle != "alice.bsky.social" {
+		t.Errorf("PerDID[0] = %+v, want did:plc:alice/alice.bsky.social", got)
+	}
+}
+
+func TestRunWarmsUpHostsWhenConfigured(t *testing.T) {
+	pds := backfilltest.NewPDS(nil)
+	defer pds.Close()
+
+	plc := backfilltest.NewPLCDirectory(map[string]backfilltest.PLCDoc{
+		"did:plc:alice": backfilltest.PDSDoc(pds.URL, "alice.bsky.social"),
+	})
+	defer plc.Close()
+
+	cfg := backfill.Config{
+		DIDs:            []backfill.WorkItem{{DID: "did:plc:alice"}},
+		PLCDirectoryURL: plc.URL,
+		WarmUpHosts:     true,
+	}
+
+	result, err := backfill.Run(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.ConnectionStats.Handshakes != 1 {
+		t.Errorf("ConnectionStats.Handshakes = %d, want 1", result.ConnectionStats.Handshakes)
+	}
+}
@@ -0,0 +1,49 @@
+package backfilltest
+
+import "testing"
+
+func TestBuildFixtureCARProducesOneBlockPerRecord(t *testing.T) {
+	records := GenerateFixtureRecords(2, 1, 1)
+	if len(records) != 4 {
+		t.Fatalf("got %d records, want 4", len(records))
+	}
+
+	car, blocks := BuildFixtureCAR(records)
+	if len(blocks) != 4 {
+		t.Fatalf("got %d blocks, want 4", len(blocks))
+	}
+	if len(car) == 0 {
+		t.Fatal("BuildFixtureCAR returned an empty CAR")
+	}
+
+	// Each record's CID should be content-addressed: identical data
+	// yields identical CIDs, different data yields different CIDs.
+	seen := map[string]bool{}
+	for _, b := range blocks {
+		key := string(b.CID)
+		if seen[key] {
+			t.Errorf("duplicate CID for distinct fixture records")
+		}
+		seen[key] = true
+	}
+}
+
+func TestGenerateFixtureRecordsCollectionCounts(t *testing.T) {
+	records := GenerateFixtureRecords(3, 2, 1)
+
+	var posts, likes, follows int
+	for _, r := range records {
+		switch r.Collection {
+		case "app.bsky.feed.post":
+			posts++
+		case "app.bsky.feed.like":
+			likes++
+		case "app.bsky.graph.follow":
+			follows++
+		}
+	}
+
+	if posts != 3 || likes != 2 || follows != 1 {
+		t.Errorf("got posts=%d likes=%d follows=%d, want 3/2/1", posts, likes, follows)
+	}
+}
@@ -0,0 +1,47 @@
+package backfilltest
+
+// A tiny, write-only subset of CBOR (RFC 8949) — just enough to encode the
+// CAR header {"version":1,"roots":[<cid>]}. Not a general-purpose encoder.
+
+func cborAppendUint(buf []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(buf, major<<5|byte(n))
+	case n < 1<<8:
+		return append(buf, major<<5|24, byte(n))
+	case n < 1<<16:
+		return append(buf, major<<5|25, byte(n>>8), byte(n))
+	case n < 1<<32:
+		return append(buf, major<<5|26, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	default:
+		return append(buf, major<<5|27,
+			byte(n>>56), byte(n>>48), byte(n>>40), byte(n>>32),
+			byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+}
+
+func cborAppendTextString(buf []byte, s string) []byte {
+	buf = cborAppendUint(buf, 3, uint64(len(s)))
+	return append(buf, s...)
+}
+
+func cborAppendByteString(buf []byte, b []byte) []byte {
+	buf = cborAppendUint(buf, 2, uint64(len(b)))
+	return append(buf, b...)
+}
+
+func cborAppendMapHeader(buf []byte, n int) []byte {
+	return cborAppendUint(buf, 5, uint64(n))
+}
+
+func cborAppendArrayHeader(buf []byte, n int) []byte {
+	return cborAppendUint(buf, 4, uint64(n))
+}
+
+// cborAppendCIDLink encodes cid as a CBOR tag-42 byte string, the
+// convention used for IPLD links (including in CAR headers): a leading
+// 0x00 "identity multibase" byte precedes the raw CID bytes.
+func cborAppendCIDLink(buf []byte, cid []byte) []byte {
+	buf = append(buf, 0xd8, 42) // tag(42), encoded as 1-byte-following tag
+	return cborAppendByteString(buf, append([]byte{0x00}, cid...))
+}
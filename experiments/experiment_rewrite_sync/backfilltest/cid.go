@@ -0,0 +1,36 @@
+package backfilltest
+
+import "crypto/sha256"
+
+const (
+	codecDagCBOR  = 0x71
+	codecRaw      = 0x55
+	multihashSHA2 = 0x12
+	sha256Length  = 0x20
+)
+
+// newCIDv1 builds a binary CIDv1: varint(version) + varint(codec) +
+// multihash(varint(code) + varint(length) + digest). It's the raw-bytes
+// form CAR files store, not the multibase string form used in URLs.
+func newCIDv1(codec uint64, data []byte) []byte {
+	digest := sha256.Sum256(data)
+
+	var cid []byte
+	cid = appendUvarint(cid, 1) // CID version
+	cid = appendUvarint(cid, codec)
+	cid = appendUvarint(cid, multihashSHA2)
+	cid = appendUvarint(cid, sha256Length)
+	cid = append(cid, digest[:]...)
+	return cid
+}
+
+// appendUvarint appends x as an unsigned LEB128 varint, the integer
+// encoding CIDs and CAR block lengths use (distinct from CBOR's own
+// integer encoding).
+func appendUvarint(buf []byte, x uint64) []byte {
+	for x >= 0x80 {
+		buf = append(buf, byte(x)|0x80)
+		x >>= 7
+	}
+	return append(buf, byte(x))
+}
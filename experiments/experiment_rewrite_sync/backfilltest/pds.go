@@ -0,0 +1,28 @@
+package backfilltest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+)
+
+// NewPDS starts a fake PDS serving com.atproto.sync.getRepo from cars,
+// keyed by DID. Unknown DIDs get a 404.
+func NewPDS(cars map[string][]byte) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/xrpc/com.atproto.sync.getRepo":
+			did := r.URL.Query().Get("did")
+			car, ok := cars[did]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(car)))
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(car)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
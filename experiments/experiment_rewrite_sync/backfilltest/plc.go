@@ -0,0 +1,52 @@
+// Package backfilltest provides httptest-based fakes of the PLC directory
+// and a PDS, so tests of the backfill package (and of its downstream
+// consumers) can exercise a full run without touching the network.
+package backfilltest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+)
+
+// PLCDoc is the subset of a did:plc document the fake directory serves.
+type PLCDoc struct {
+	AlsoKnownAs []string     `json:"alsoKnownAs"`
+	Service     []PLCService `json:"service"`
+}
+
+// PLCService is a single service entry in a PLCDoc.
+type PLCService struct {
+	ID              string `json:"id"`
+	Type            string `json:"type"`
+	ServiceEndpoint string `json:"serviceEndpoint"`
+}
+
+// NewPLCDirectory starts a fake PLC directory serving docs. Unknown DIDs
+// get a 404, matching the real plc.directory.
+func NewPLCDirectory(docs map[string]PLCDoc) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		did := r.URL.Path[1:] // strip leading "/"
+		doc, ok := docs[did]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(doc)
+	}))
+}
+
+// PDSDoc builds a minimal PLCDoc advertising pdsEndpoint as the DID's
+// AtprotoPersonalDataServer, with an optional handle.
+func PDSDoc(pdsEndpoint, handle string) PLCDoc {
+	doc := PLCDoc{
+		Service: []PLCService{
+			{ID: "#atproto_pds", Type: "AtprotoPersonalDataServer", ServiceEndpoint: pdsEndpoint},
+		},
+	}
+	if handle != "" {
+		doc.AlsoKnownAs = []string{"at://" + handle}
+	}
+	return doc
+}
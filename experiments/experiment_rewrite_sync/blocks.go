@@ -0,0 +1,83 @@
+package backfill
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+// GetBlocks downloads only the given CIDs from did's repo on pdsEndpoint via
+// com.atproto.sync.getBlocks, writing the resulting CAR file to destPath. Use
+// this instead of FetchRepo when the set of changed CIDs is already known
+// (e.g. from firehose ops), to avoid pulling down the whole repo for a tiny
+// delta.
+//
+// If blockstore is non-nil, a successful fetch is cached there and a later
+// call asking for the exact same (pdsEndpoint, did, cids) skips the network
+// round trip entirely and copies the cached bytes to destPath instead. The
+// response is cached as one opaque blob, not split into its blocks, so the
+// cache key covers the whole request rather than each CID individually:
+// asking for a subset or superset of a previously
+// fetched CID set is always a cache miss, even though the PDS response
+// would overlap.
+func GetBlocks(ctx context.Context, httpClient *http.Client, pdsEndpoint, did string, cids []string, destPath string, blockstore *Blockstore) (int64, error) {
+	if len(cids) == 0 {
+		return 0, fmt.Errorf("getBlocks %s: no CIDs given", did)
+	}
+
+	if blockstore != nil {
+		key := getBlocksKey(pdsEndpoint, did, cids)
+		if data, ok, err := blockstore.Get(key); err != nil {
+			return 0, fmt.Errorf("getBlocks %s: %w", did, err)
+		} else if ok {
+			if err := os.WriteFile(destPath, data, 0o644); err != nil {
+				return 0, fmt.Errorf("getBlocks %s: write cached blocks to %s: %w", did, destPath, err)
+			}
+			return int64(len(data)), nil
+		}
+	}
+
+	q := url.Values{}
+	q.Set("did", did)
+	for _, cid := range cids {
+		q.Add("cids", cid)
+	}
+
+	reqURL := fmt.Sprintf("%s/xrpc/com.atproto.sync.getBlocks?%s", pdsEndpoint, q.Encode())
+	written, err := fetchToFile(ctx, httpClient, reqURL, destPath)
+	if err != nil || blockstore == nil {
+		return written, err
+	}
+
+	data, readErr := os.ReadFile(destPath)
+	if readErr != nil {
+		// The fetch itself succeeded; failing to populate the blockstore
+		// from the file we just wrote shouldn't fail the whole call.
+		return written, nil
+	}
+	_ = blockstore.Put(getBlocksKey(pdsEndpoint, did, cids), data)
+	return written, nil
+}
+
+// getBlocksKey derives a Blockstore key for a GetBlocks request, covering
+// the whole (pdsEndpoint, did, cids) request rather than each CID
+// individually. cids is sorted before hashing so the same set in a
+// different order hits the same cache entry.
+func getBlocksKey(pdsEndpoint, did string, cids []string) string {
+	sorted := append([]string(nil), cids...)
+	sort.Strings(sorted)
+
+	h := sha256.New()
+	h.Write([]byte(pdsEndpoint))
+	h.Write([]byte("|"))
+	h.Write([]byte(did))
+	h.Write([]byte("|"))
+	h.Write([]byte(strings.Join(sorted, ",")))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,87 @@
+package backfill
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetBlocksRequestsOnlyGivenCIDs(t *testing.T) {
+	var gotCIDs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotCIDs = r.URL.Query()["cids"]
+		w.Write([]byte("fake-car-bytes"))
+	}))
+	defer srv.Close()
+
+	destPath := filepath.Join(t.TempDir(), "blocks.car")
+	n, err := GetBlocks(context.Background(), srv.Client(), srv.URL, "did:plc:alice", []string{"cid1", "cid2"}, destPath, nil)
+	if err != nil {
+		t.Fatalf("GetBlocks() error = %v", err)
+	}
+	if n != int64(len("fake-car-bytes")) {
+		t.Errorf("wrote %d bytes, want %d", n, len("fake-car-bytes"))
+	}
+	if len(gotCIDs) != 2 || gotCIDs[0] != "cid1" || gotCIDs[1] != "cid2" {
+		t.Errorf("got cids=%v", gotCIDs)
+	}
+
+	body, err := os.ReadFile(destPath)
+	if err != nil {
+		t.Fatalf("read destPath: %v", err)
+	}
+	if string(body) != "fake-car-bytes" {
+		t.Errorf("destPath content = %q", body)
+	}
+}
+
+func TestGetBlocksRejectsEmptyCIDs(t *testing.T) {
+	if _, err := GetBlocks(context.Background(), nil, "https://pds.example", "did:plc:alice", nil, t.TempDir()+"/blocks.car", nil); err == nil {
+		t.Fatal("GetBlocks() error = nil, want error for empty cids")
+	}
+}
+
+func TestGetBlocksReusesCachedResultFromBlockstore(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte("fake-car-bytes"))
+	}))
+	defer srv.Close()
+
+	bs, err := NewBlockstore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewBlockstore() error = %v", err)
+	}
+
+	destPath1 := filepath.Join(t.TempDir(), "blocks.car")
+	if _, err := GetBlocks(context.Background(), srv.Client(), srv.URL, "did:plc:alice", []string{"cid1", "cid2"}, destPath1, bs); err != nil {
+		t.Fatalf("first GetBlocks() error = %v", err)
+	}
+	if requests != 1 {
+		t.Fatalf("got %d requests after first GetBlocks(), want 1", requests)
+	}
+
+	destPath2 := filepath.Join(t.TempDir(), "blocks.car")
+	n, err := GetBlocks(context.Background(), srv.Client(), srv.URL, "did:plc:alice", []string{"cid2", "cid1"}, destPath2, bs)
+	if err != nil {
+		t.Fatalf("second GetBlocks() error = %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("got %d requests after second GetBlocks() with the same CIDs in a different order, want still 1 (served from the blockstore)", requests)
+	}
+	if n != int64(len("fake-car-bytes")) {
+		t.Errorf("wrote %d bytes, want %d", n, len("fake-car-bytes"))
+	}
+
+	body, err := os.ReadFile(destPath2)
+	if err != nil {
+		t.Fatalf("read destPath2: %v", err)
+	}
+	if string(body) != "fake-car-bytes" {
+		t.Errorf("destPath2 content = %q", body)
+	}
+}
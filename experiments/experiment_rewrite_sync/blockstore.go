@@ -0,0 +1,79 @@
+package backfill
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Blockstore is a minimal on-disk content-addressed byte store: Put(key,
+// data) writes data under a path derived from key, and Get/Has look it
+// up by the same key later, possibly from a different run or process.
+// Keys are expected to already identify their content (a CID, or a hash
+// of exactly the bytes being requested), so the same key always means
+// the same bytes and entries never need invalidating.
+//
+// There's no vendored embedded database (badger, flatfs) in this
+// module, so this is a minimal flatfs-style layout instead: entries are
+// sharded two levels deep by the key's first four characters, the same
+// trick git's object store uses so one directory doesn't end up holding
+// millions of files.
+type Blockstore struct {
+	dir string
+}
+
+// NewBlockstore returns a Blockstore rooted at dir, creating it (and any
+// missing parents) if it doesn't already exist.
+func NewBlockstore(dir string) (*Blockstore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("blockstore: %w", err)
+	}
+	return &Blockstore{dir: dir}, nil
+}
+
+// Has reports whether key is already stored.
+func (b *Blockstore) Has(key string) bool {
+	_, err := os.Stat(b.path(key))
+	return err == nil
+}
+
+// Get returns the bytes stored under key. ok is false (with a nil error)
+// if key has never been Put.
+func (b *Blockstore) Get(key string) (data []byte, ok bool, err error) {
+	data, err = os.ReadFile(b.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("blockstore: get %s: %w", key, err)
+	}
+	return data, true, nil
+}
+
+// Put stores data under key, replacing any existing entry. The write is
+// atomic (via a temp file and rename) so a crash mid-write can't leave a
+// truncated entry behind for a later Get to read.
+func (b *Blockstore) Put(key string, data []byte) error {
+	path := b.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("blockstore: put %s: %w", key, err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("blockstore: put %s: %w", key, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("blockstore: put %s: %w", key, err)
+	}
+	return nil
+}
+
+// path returns the sharded on-disk path for key.
+func (b *Blockstore) path(key string) string {
+	if len(key) < 4 {
+		return filepath.Join(b.dir, key)
+	}
+	return filepath.Join(b.dir, key[:2], key[2:4], key)
+}
@@ -0,0 +1,92 @@
+package backfill
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestBlockstorePutThenGetRoundTrips(t *testing.T) {
+	bs, err := NewBlockstore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewBlockstore() error = %v", err)
+	}
+
+	if bs.Has("missing") {
+		t.Error("Has(\"missing\") = true before any Put")
+	}
+	if _, ok, err := bs.Get("missing"); err != nil || ok {
+		t.Errorf("Get(\"missing\") = (_, %v, %v), want (_, false, nil)", ok, err)
+	}
+
+	if err := bs.Put("abcdef", []byte("hello")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if !bs.Has("abcdef") {
+		t.Error("Has() = false after Put")
+	}
+
+	data, ok, err := bs.Get("abcdef")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if !ok {
+		t.Fatal("Get() ok = false after Put")
+	}
+	if string(data) != "hello" {
+		t.Errorf("Get() = %q, want %q", data, "hello")
+	}
+}
+
+func TestBlockstorePutOverwritesExistingEntry(t *testing.T) {
+	bs, err := NewBlockstore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewBlockstore() error = %v", err)
+	}
+
+	if err := bs.Put("key", []byte("v1")); err != nil {
+		t.Fatalf("first Put() error = %v", err)
+	}
+	if err := bs.Put("key", []byte("v2")); err != nil {
+		t.Fatalf("second Put() error = %v", err)
+	}
+
+	data, ok, err := bs.Get("key")
+	if err != nil || !ok {
+		t.Fatalf("Get() = (%q, %v, %v)", data, ok, err)
+	}
+	if string(data) != "v2" {
+		t.Errorf("Get() = %q, want %q (the overwritten value)", data, "v2")
+	}
+}
+
+func TestBlockstoreShardsEntriesByKeyPrefix(t *testing.T) {
+	dir := t.TempDir()
+	bs, err := NewBlockstore(dir)
+	if err != nil {
+		t.Fatalf("NewBlockstore() error = %v", err)
+	}
+
+	if err := bs.Put("abcdef0123", []byte("data")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	want := filepath.Join(dir, "ab", "cd", "abcdef0123")
+	if got := bs.path("abcdef0123"); got != want {
+		t.Errorf("path() = %q, want %q", got, want)
+	}
+}
+
+func TestBlockstoreHandlesShortKeys(t *testing.T) {
+	bs, err := NewBlockstore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewBlockstore() error = %v", err)
+	}
+
+	if err := bs.Put("ab", []byte("short")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	data, ok, err := bs.Get("ab")
+	if err != nil || !ok || string(data) != "short" {
+		t.Errorf("Get() = (%q, %v, %v), want (%q, true, nil)", data, ok, err, "short")
+	}
+}
@@ -0,0 +1,292 @@
+package backfill
+
+import (
+	"fmt"
+	"math"
+)
+
+// A tiny, read-only subset of CBOR (RFC 8949): enough to decode the CAR
+// header {"version":1,"roots":[<cid>,...]} and, via DecodeDAGCBORValue,
+// general DAG-CBOR record values. Not a general-purpose CBOR decoder —
+// in particular it rejects indefinite-length items and tags other than
+// 42, both of which DAG-CBOR itself forbids.
+
+// cborReader decodes CBOR items from buf in sequence.
+type cborReader struct {
+	buf []byte
+	pos int
+}
+
+func (r *cborReader) read(n int) ([]byte, error) {
+	if r.pos+n > len(r.buf) {
+		return nil, fmt.Errorf("cbor: unexpected end of input")
+	}
+	b := r.buf[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+// readHead decodes a CBOR item's initial byte (and any following
+// argument bytes), returning its major type and argument value.
+func (r *cborReader) readHead() (major byte, value uint64, err error) {
+	b, err := r.read(1)
+	if err != nil {
+		return 0, 0, err
+	}
+	major = b[0] >> 5
+	info := b[0] & 0x1f
+
+	var argLen int
+	switch {
+	case info < 24:
+		return major, uint64(info), nil
+	case info == 24:
+		argLen = 1
+	case info == 25:
+		argLen = 2
+	case info == 26:
+		argLen = 4
+	case info == 27:
+		argLen = 8
+	default:
+		return 0, 0, fmt.Errorf("cbor: unsupported additional info %d", info)
+	}
+
+	arg, err := r.read(argLen)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, b := range arg {
+		value = value<<8 | uint64(b)
+	}
+	return major, value, nil
+}
+
+// readTextString decodes a CBOR major-type-3 text string.
+func (r *cborReader) readTextString() (string, error) {
+	major, n, err := r.readHead()
+	if err != nil {
+		return "", err
+	}
+	if major != 3 {
+		return "", fmt.Errorf("cbor: expected text string, got major type %d", major)
+	}
+	b, err := r.read(int(n))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// readMapHeader decodes a CBOR major-type-5 map header, returning its
+// entry count.
+func (r *cborReader) readMapHeader() (int, error) {
+	major, n, err := r.readHead()
+	if err != nil {
+		return 0, err
+	}
+	if major != 5 {
+		return 0, fmt.Errorf("cbor: expected map, got major type %d", major)
+	}
+	return int(n), nil
+}
+
+// readArrayHeader decodes a CBOR major-type-4 array header, returning its
+// entry count.
+func (r *cborReader) readArrayHeader() (int, error) {
+	major, n, err := r.readHead()
+	if err != nil {
+		return 0, err
+	}
+	if major != 4 {
+		return 0, fmt.Errorf("cbor: expected array, got major type %d", major)
+	}
+	return int(n), nil
+}
+
+// readCIDLink decodes a CBOR tag-42 IPLD link (the convention CAR headers
+// use for root CIDs), returning the CID's raw bytes with the leading
+// 0x00 identity-multibase byte stripped.
+func (r *cborReader) readCIDLink() ([]byte, error) {
+	major, tag, err := r.readHead()
+	if err != nil {
+		return nil, err
+	}
+	if major != 6 || tag != 42 {
+		return nil, fmt.Errorf("cbor: expected tag 42 (IPLD link), got major type %d tag %d", major, tag)
+	}
+	return r.readLinkBody()
+}
+
+// readLinkBody decodes the byte-string body of a tag-42 IPLD link after
+// its tag head has already been consumed: a byte string whose first byte
+// is the 0x00 identity-multibase marker, followed by the raw CID bytes.
+func (r *cborReader) readLinkBody() ([]byte, error) {
+	bsMajor, n, err := r.readHead()
+	if err != nil {
+		return nil, err
+	}
+	if bsMajor != 2 {
+		return nil, fmt.Errorf("cbor: expected byte string for link, got major type %d", bsMajor)
+	}
+	b, err := r.read(int(n))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 || b[0] != 0x00 {
+		return nil, fmt.Errorf("cbor: link missing identity multibase prefix")
+	}
+	return b[1:], nil
+}
+
+// CIDLink is a decoded IPLD link (a CBOR tag-42 byte string) found inside
+// a DAG-CBOR record value, e.g. a post's reply references. It holds the
+// same raw CID bytes as CARBlock.CID.
+type CIDLink []byte
+
+// DecodeDAGCBORValue decodes a single DAG-CBOR-encoded value — the format
+// a CAR block's Data holds for atproto records — into a Go value:
+// map[string]any for maps, []any for arrays, string, []byte, int64,
+// uint64, float64, bool, nil, or CIDLink for a link to another block.
+// Byte strings borrow from data rather than copying it (text strings are
+// copied into Go strings), so a []byte in the result stays valid only as
+// long as data itself does (e.g. only until a CARFile reused via NextBlock
+// advances past this block).
+func DecodeDAGCBORValue(data []byte) (any, error) {
+	r := &cborReader{buf: data}
+	v, err := r.readValue()
+	if err != nil {
+		return nil, err
+	}
+	if r.pos != len(r.buf) {
+		return nil, fmt.Errorf("cbor: %d trailing byte(s) after value", len(r.buf)-r.pos)
+	}
+	return v, nil
+}
+
+// readValue decodes one CBOR value of any major type DAG-CBOR allows,
+// recursing into readValue again for array elements and map values.
+func (r *cborReader) readValue() (any, error) {
+	start := r.pos
+	major, arg, err := r.readHead()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case 0: // unsigned integer
+		return arg, nil
+	case 1: // negative integer
+		return -1 - int64(arg), nil
+	case 2: // byte string
+		return r.read(int(arg))
+	case 3: // text string
+		b, err := r.read(int(arg))
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	case 4: // array
+		items := make([]any, arg)
+		for i := range items {
+			v, err := r.readValue()
+			if err != nil {
+				return nil, fmt.Errorf("cbor: array element %d: %w", i, err)
+			}
+			items[i] = v
+		}
+		return items, nil
+	case 5: // map
+		m := make(map[string]any, arg)
+		for i := uint64(0); i < arg; i++ {
+			key, err := r.readTextString()
+			if err != nil {
+				return nil, fmt.Errorf("cbor: map key: %w", err)
+			}
+			v, err := r.readValue()
+			if err != nil {
+				return nil, fmt.Errorf("cbor: map value for key %q: %w", key, err)
+			}
+			m[key] = v
+		}
+		return m, nil
+	case 6: // tag
+		if arg != 42 {
+			return nil, fmt.Errorf("cbor: unsupported tag %d", arg)
+		}
+		b, err := r.readLinkBody()
+		if err != nil {
+			return nil, err
+		}
+		return CIDLink(b), nil
+	case 7: // simple values and floats
+		// arg holds a float's bits, not its additional info, so the
+		// float64 case is told apart by the initial byte instead.
+		if info := r.buf[start] & 0x1f; info == 27 {
+			return math.Float64frombits(arg), nil
+		} else if info >= 24 {
+			return nil, fmt.Errorf("cbor: unsupported simple value or float (additional info %d)", info)
+		}
+		switch arg {
+		case 20:
+			return false, nil
+		case 21:
+			return true, nil
+		case 22:
+			return nil, nil
+		default:
+			return nil, fmt.Errorf("cbor: unsupported simple value %d", arg)
+		}
+	default:
+		return nil, fmt.Errorf("cbor: unsupported major type %d", major)
+	}
+}
+
+// decodeCARHeader decodes a CARv1 header's {"version":N,"roots":[...]}
+// map and returns its root CIDs.
+func decodeCARHeader(header []byte) ([][]byte, error) {
+	r := &cborReader{buf: header}
+	n, err := r.readMapHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	var roots [][]byte
+	sawVersion := false
+	for i := 0; i < n; i++ {
+		key, err := r.readTextString()
+		if err != nil {
+			return nil, err
+		}
+		switch key {
+		case "version":
+			_, version, err := r.readHead()
+			if err != nil {
+				return nil, err
+			}
+			if version != 1 {
+				return nil, fmt.Errorf("cbor: unsupported CAR version %d", version)
+			}
+			sawVersion = true
+		case "roots":
+			count, err := r.readArrayHeader()
+			if err != nil {
+				return nil, err
+			}
+			roots = make([][]byte, count)
+			for j := 0; j < count; j++ {
+				cid, err := r.readCIDLink()
+				if err != nil {
+					return nil, err
+				}
+				roots[j] = cid
+			}
+		default:
+			return nil, fmt.Errorf("cbor: unexpected CAR header key %q", key)
+		}
+	}
+	if !sawVersion {
+		return nil, fmt.Errorf("cbor: CAR header missing version")
+	}
+	return roots, nil
+}
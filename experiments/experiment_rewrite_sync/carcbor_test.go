@@ -0,0 +1,114 @@
+package backfill
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeDAGCBORValueScalars(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want any
+	}{
+		{"small uint", []byte{0x05}, uint64(5)},
+		{"uint8", []byte{0x18, 0xff}, uint64(255)},
+		{"small negative int", []byte{0x29}, int64(-10)},
+		{"text string", []byte{0x63, 'c', 'a', 't'}, "cat"},
+		{"byte string", []byte{0x42, 0xde, 0xad}, []byte{0xde, 0xad}},
+		{"false", []byte{0xf4}, false},
+		{"true", []byte{0xf5}, true},
+		{"null", []byte{0xf6}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeDAGCBORValue(tt.data)
+			if err != nil {
+				t.Fatalf("DecodeDAGCBORValue() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeDAGCBORValue() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeDAGCBORValueArray(t *testing.T) {
+	// [1, "two", true]
+	data := []byte{
+		0x83,
+		0x01,
+		0x63, 't', 'w', 'o',
+		0xf5,
+	}
+	got, err := DecodeDAGCBORValue(data)
+	if err != nil {
+		t.Fatalf("DecodeDAGCBORValue() error = %v", err)
+	}
+	want := []any{uint64(1), "two", true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeDAGCBORValue() = %#v, want %#v", got, want)
+	}
+}
+
+func TestDecodeDAGCBORValueMap(t *testing.T) {
+	// {"text": "hello"}
+	data := []byte{
+		0xa1,
+		0x64, 't', 'e', 'x', 't',
+		0x65, 'h', 'e', 'l', 'l', 'o',
+	}
+	got, err := DecodeDAGCBORValue(data)
+	if err != nil {
+		t.Fatalf("DecodeDAGCBORValue() error = %v", err)
+	}
+	want := map[string]any{"text": "hello"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeDAGCBORValue() = %#v, want %#v", got, want)
+	}
+}
+
+func TestDecodeDAGCBORValueLink(t *testing.T) {
+	cid := []byte{0x01, 0x55, 0x12, 0x02, 0xab, 0xcd}
+	data := append([]byte{0xd8, 42}, cborByteString(append([]byte{0x00}, cid...))...)
+
+	got, err := DecodeDAGCBORValue(data)
+	if err != nil {
+		t.Fatalf("DecodeDAGCBORValue() error = %v", err)
+	}
+	link, ok := got.(CIDLink)
+	if !ok {
+		t.Fatalf("DecodeDAGCBORValue() = %#v (%T), want CIDLink", got, got)
+	}
+	if !reflect.DeepEqual([]byte(link), cid) {
+		t.Errorf("link = %x, want %x", []byte(link), cid)
+	}
+}
+
+func TestDecodeDAGCBORValueRejectsTrailingBytes(t *testing.T) {
+	data := []byte{0x01, 0x02} // uint(1), then a stray extra byte
+	if _, err := DecodeDAGCBORValue(data); err == nil {
+		t.Fatal("DecodeDAGCBORValue() error = nil, want an error for trailing bytes")
+	}
+}
+
+func TestDecodeDAGCBORValueRejectsUnsupportedTag(t *testing.T) {
+	data := []byte{0xc0, 0x01} // tag(0), not the tag-42 link DAG-CBOR allows
+	if _, err := DecodeDAGCBORValue(data); err == nil {
+		t.Fatal("DecodeDAGCBORValue() error = nil, want an error for an unsupported tag")
+	}
+}
+
+// cborByteString encodes b as a CBOR major-type-2 byte string, for
+// assembling test fixtures by hand.
+func cborByteString(b []byte) []byte {
+	n := len(b)
+	switch {
+	case n < 24:
+		return append([]byte{0x40 | byte(n)}, b...)
+	case n < 1<<8:
+		return append([]byte{0x58, byte(n)}, b...)
+	default:
+		panic("cborByteString: fixture too large for this helper")
+	}
+}
@@ -0,0 +1,274 @@
+package backfill
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// varintHeadPool holds scratch buffers for decoding the uvarint length
+// prefix at the start of each CAR header/block, so iterating a file with
+// millions of blocks (e.g. re-extracting an archived repo) doesn't
+// allocate one of these per block just to find out how long it is.
+var varintHeadPool = sync.Pool{
+	New: func() any { return make([]byte, 10) },
+}
+
+// CARBlock is one block read out of a CAR file: its raw CID bytes and the
+// data they hash, exactly as CARFile.Next yields them.
+type CARBlock struct {
+	CID  []byte
+	Data []byte
+}
+
+// CARFile is a CARv1 file (see
+// https://ipld.io/specs/transport/car/carv1/) opened for block-by-block
+// reading, e.g. to re-extract records from an archived repo snapshot
+// without re-fetching it from a PDS. On platforms that support it the
+// file is memory-mapped (see mmap_unix.go), so Next never needs to hold
+// more than the block it's currently decoding in the Go heap — the
+// kernel pages the rest in, and can evict it again under memory
+// pressure, which is what lets a 64GB archive be re-processed on a
+// machine with far less RAM. Call Close when done to release the
+// mapping or file handle.
+type CARFile struct {
+	r      io.ReaderAt
+	closer io.Closer
+	size   int64
+	roots  [][]byte
+
+	off int64
+
+	// scratch backs the most recent NextBlock result; reused (and grown
+	// as needed) across calls so decoding many blocks in a row doesn't
+	// allocate a fresh buffer for every one of them.
+	scratch []byte
+}
+
+// OpenCARFile opens the CARv1 file at path and decodes its header,
+// returning a CARFile ready to iterate over its blocks with Next.
+func OpenCARFile(path string) (*CARFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	size := info.Size()
+
+	// openCARReaderAt takes ownership of f: on success it's either
+	// mmap'd and closed immediately (the mapping keeps the file's
+	// contents available) or kept open as the ReaderAt itself.
+	r, closer, err := openCARReaderAt(f, size)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	header, headerEnd, err := readVarintFramedSection(r, 0, size)
+	if err != nil {
+		closer.Close()
+		return nil, fmt.Errorf("open CAR %s: read header: %w", path, err)
+	}
+	roots, err := decodeCARHeader(header)
+	if err != nil {
+		closer.Close()
+		return nil, fmt.Errorf("open CAR %s: decode header: %w", path, err)
+	}
+
+	return &CARFile{r: r, closer: closer, size: size, roots: roots, off: headerEnd}, nil
+}
+
+// Roots returns the CAR file's root CIDs, as raw binary CIDs (not the
+// multibase string form used in URLs).
+func (c *CARFile) Roots() [][]byte { return c.roots }
+
+// Next returns the next block in the file, or io.EOF once every block has
+// been read.
+func (c *CARFile) Next() (CARBlock, error) {
+	if c.off >= c.size {
+		return CARBlock{}, io.EOF
+	}
+
+	start := c.off
+	entry, entryEnd, err := readVarintFramedSection(c.r, start, c.size)
+	if err != nil {
+		return CARBlock{}, fmt.Errorf("read CAR block at offset %d: %w", start, err)
+	}
+	c.off = entryEnd
+
+	cidLen, err := cidByteLength(entry)
+	if err != nil {
+		return CARBlock{}, fmt.Errorf("parse CID in block at offset %d: %w", start, err)
+	}
+	return CARBlock{CID: entry[:cidLen], Data: entry[cidLen:]}, nil
+}
+
+// NextBlock decodes the next block into dst, reusing CARFile's internal
+// scratch buffer instead of allocating a fresh one for every block — the
+// low-allocation counterpart to Next for hot loops that decode a great
+// many blocks (e.g. re-extracting a large archived repo), where per-block
+// allocations are what push GC into dominating CPU time. dst.CID and
+// dst.Data alias that scratch buffer and are only valid until the next
+// call to NextBlock or Next; copy them (e.g. via append([]byte(nil),
+// ...)) if the caller needs to retain them past that point. Returns
+// io.EOF once every block has been read.
+func (c *CARFile) NextBlock(dst *CARBlock) error {
+	if c.off >= c.size {
+		return io.EOF
+	}
+
+	start := c.off
+	entry, entryEnd, err := readVarintFramedSectionInto(c.r, start, c.size, c.scratch)
+	if err != nil {
+		return fmt.Errorf("read CAR block at offset %d: %w", start, err)
+	}
+	c.scratch = entry
+	c.off = entryEnd
+
+	cidLen, err := cidByteLength(entry)
+	if err != nil {
+		return fmt.Errorf("parse CID in block at offset %d: %w", start, err)
+	}
+	dst.CID = entry[:cidLen]
+	dst.Data = entry[cidLen:]
+	return nil
+}
+
+// Close releases the CAR file's underlying mapping or file handle.
+func (c *CARFile) Close() error {
+	return c.closer.Close()
+}
+
+// Offset returns the byte offset Next/NextBlock will resume reading from,
+// so a caller that abandons iteration partway through (e.g. after a
+// framing error) can locate exactly how much of the file was consumed.
+func (c *CARFile) Offset() int64 { return c.off }
+
+// ReadRemaining reads every byte from the current offset to the end of
+// the file without attempting to parse it as CAR framing, for salvaging
+// the undecodable tail of a corrupt or truncated file (see
+// DecodeCARFileQuarantine).
+func (c *CARFile) ReadRemaining() ([]byte, error) {
+	n := c.size - c.off
+	if n <= 0 {
+		return nil, nil
+	}
+	buf := make([]byte, n)
+	if _, err := c.r.ReadAt(buf, c.off); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// readVarintFramedSection reads one uvarint-length-prefixed section of r
+// starting at off, the framing CAR files use for both their header and
+// each block: an unsigned LEB128 varint byte count followed by that many
+// bytes. It returns the section's data and the offset immediately
+// following it. The returned data is always freshly allocated; see
+// readVarintFramedSectionInto for a version that reuses a buffer.
+func readVarintFramedSection(r io.ReaderAt, off, limit int64) (data []byte, next int64, err error) {
+	return readVarintFramedSectionInto(r, off, limit, nil)
+}
+
+// readVarintFramedSectionInto behaves like readVarintFramedSection but
+// reuses dst's backing array when it's already large enough instead of
+// allocating a new one, so repeatedly reading framed sections of similar
+// size (e.g. one CARBlock per block in a CAR file) can settle into doing
+// no further allocations at all. dst's previous contents are never read,
+// only potentially grown and overwritten.
+func readVarintFramedSectionInto(r io.ReaderAt, off, limit int64, dst []byte) (data []byte, next int64, err error) {
+	if off >= limit {
+		return nil, 0, io.EOF
+	}
+
+	headBuf := varintHeadPool.Get().([]byte)
+	defer varintHeadPool.Put(headBuf)
+
+	want := int(min(int64(10), limit-off))
+	n, err := r.ReadAt(headBuf[:want], off)
+	if err != nil && err != io.EOF {
+		return nil, 0, err
+	}
+
+	length, consumed, err := readUvarint(headBuf[:n], 0)
+	if err != nil {
+		return nil, 0, fmt.Errorf("read length prefix at offset %d: %w", off, err)
+	}
+
+	start := off + int64(consumed)
+	end := start + int64(length)
+	if end > limit {
+		return nil, 0, fmt.Errorf("section length %d at offset %d exceeds file size", length, off)
+	}
+
+	if cap(dst) < int(length) {
+		dst = make([]byte, length)
+	} else {
+		dst = dst[:length]
+	}
+	if length > 0 {
+		if _, err := r.ReadAt(dst, start); err != nil {
+			return nil, 0, fmt.Errorf("read %d bytes at offset %d: %w", length, start, err)
+		}
+	}
+	return dst, end, nil
+}
+
+// readUvarint decodes an unsigned LEB128 varint (the encoding CAR uses to
+// frame its header and each block, and that CIDs use internally) from buf
+// starting at pos, returning its value and the position immediately
+// following it.
+func readUvarint(buf []byte, pos int) (value uint64, next int, err error) {
+	var shift uint
+	for {
+		if pos >= len(buf) {
+			return 0, 0, io.ErrUnexpectedEOF
+		}
+		b := buf[pos]
+		pos++
+		if shift >= 64 {
+			return 0, 0, fmt.Errorf("varint too long")
+		}
+		value |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return value, pos, nil
+		}
+		shift += 7
+	}
+}
+
+// cidByteLength returns the length, in bytes, of the binary CIDv1 prefix
+// at the start of entry — varint(version) + varint(codec) + multihash
+// (varint(code) + varint(length) + digest) — so callers can split a CAR
+// block entry into its CID and the data it hashes.
+func cidByteLength(entry []byte) (int, error) {
+	pos := 0
+	for i := 0; i < 2; i++ { // CID version, then codec
+		_, next, err := readUvarint(entry, pos)
+		if err != nil {
+			return 0, fmt.Errorf("cid: %w", err)
+		}
+		pos = next
+	}
+
+	_, next, err := readUvarint(entry, pos) // multihash code
+	if err != nil {
+		return 0, fmt.Errorf("cid: %w", err)
+	}
+	pos = next
+
+	digestLen, next, err := readUvarint(entry, pos)
+	if err != nil {
+		return 0, fmt.Errorf("cid: %w", err)
+	}
+	pos = next + int(digestLen)
+	if pos > len(entry) {
+		return 0, fmt.Errorf("cid: digest length %d exceeds block entry of %d bytes", digestLen, len(entry))
+	}
+	return pos, nil
+}
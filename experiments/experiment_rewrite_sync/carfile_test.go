@@ -0,0 +1,175 @@
+package backfill
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync/backfilltest"
+)
+
+func writeCARFixture(t *testing.T, records []backfilltest.FixtureRecord) (string, []backfilltest.Block) {
+	t.Helper()
+	data, blocks := backfilltest.BuildFixtureCAR(records)
+	path := filepath.Join(t.TempDir(), "fixture.car")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("write fixture CAR: %v", err)
+	}
+	return path, blocks
+}
+
+func TestOpenCARFileReadsEveryBlockInOrder(t *testing.T) {
+	records := backfilltest.GenerateFixtureRecords(2, 1, 1)
+	path, blocks := writeCARFixture(t, records)
+
+	car, err := OpenCARFile(path)
+	if err != nil {
+		t.Fatalf("OpenCARFile() error = %v", err)
+	}
+	defer car.Close()
+
+	if len(car.Roots()) != 1 {
+		t.Fatalf("Roots() = %d entries, want 1", len(car.Roots()))
+	}
+	if !bytes.Equal(car.Roots()[0], blocks[0].CID) {
+		t.Errorf("Roots()[0] = %x, want %x", car.Roots()[0], blocks[0].CID)
+	}
+
+	var got []CARBlock
+	for {
+		b, err := car.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		got = append(got, b)
+	}
+
+	if len(got) != len(blocks) {
+		t.Fatalf("got %d blocks, want %d", len(got), len(blocks))
+	}
+	for i, b := range got {
+		if !bytes.Equal(b.CID, blocks[i].CID) {
+			t.Errorf("block %d CID = %x, want %x", i, b.CID, blocks[i].CID)
+		}
+		if !bytes.Equal(b.Data, blocks[i].Data) {
+			t.Errorf("block %d Data = %q, want %q", i, b.Data, blocks[i].Data)
+		}
+	}
+}
+
+func TestCARFileNextBlockMatchesNext(t *testing.T) {
+	records := backfilltest.GenerateFixtureRecords(2, 1, 1)
+	path, blocks := writeCARFixture(t, records)
+
+	car, err := OpenCARFile(path)
+	if err != nil {
+		t.Fatalf("OpenCARFile() error = %v", err)
+	}
+	defer car.Close()
+
+	var got []CARBlock
+	var dst CARBlock
+	for {
+		err := car.NextBlock(&dst)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextBlock() error = %v", err)
+		}
+		// dst aliases CARFile's scratch buffer and is only valid until the
+		// next call, so copy out before looping.
+		got = append(got, CARBlock{
+			CID:  append([]byte(nil), dst.CID...),
+			Data: append([]byte(nil), dst.Data...),
+		})
+	}
+
+	if len(got) != len(blocks) {
+		t.Fatalf("got %d blocks, want %d", len(got), len(blocks))
+	}
+	for i, b := range got {
+		if !bytes.Equal(b.CID, blocks[i].CID) {
+			t.Errorf("block %d CID = %x, want %x", i, b.CID, blocks[i].CID)
+		}
+		if !bytes.Equal(b.Data, blocks[i].Data) {
+			t.Errorf("block %d Data = %q, want %q", i, b.Data, blocks[i].Data)
+		}
+	}
+}
+
+func TestCARFileNextBlockReusesItsScratchBuffer(t *testing.T) {
+	records := backfilltest.GenerateFixtureRecords(2, 0, 0)
+	path, _ := writeCARFixture(t, records)
+
+	car, err := OpenCARFile(path)
+	if err != nil {
+		t.Fatalf("OpenCARFile() error = %v", err)
+	}
+	defer car.Close()
+
+	var first CARBlock
+	if err := car.NextBlock(&first); err != nil {
+		t.Fatalf("NextBlock() error = %v", err)
+	}
+	firstData := append([]byte(nil), first.Data...)
+
+	var second CARBlock
+	if err := car.NextBlock(&second); err != nil {
+		t.Fatalf("NextBlock() error = %v", err)
+	}
+
+	if bytes.Equal(first.Data, firstData) && bytes.Equal(first.Data, second.Data) {
+		// Both blocks happened to contain identical bytes; not useful
+		// evidence either way, but not a failure.
+		t.Skip("fixture blocks are identical, can't observe buffer reuse")
+	}
+	if !bytes.Equal(first.Data, second.Data) {
+		t.Errorf("first.Data after a second NextBlock() call = %q, want it to alias second.Data = %q (scratch buffer reused)", first.Data, second.Data)
+	}
+}
+
+func TestOpenCARFileWithNoBlocksHitsImmediateEOF(t *testing.T) {
+	path, _ := writeCARFixture(t, nil)
+
+	car, err := OpenCARFile(path)
+	if err != nil {
+		t.Fatalf("OpenCARFile() error = %v", err)
+	}
+	defer car.Close()
+
+	if _, err := car.Next(); err != io.EOF {
+		t.Fatalf("Next() error = %v, want io.EOF", err)
+	}
+}
+
+func TestOpenCARFileRejectsATruncatedFile(t *testing.T) {
+	records := backfilltest.GenerateFixtureRecords(1, 0, 0)
+	data, _ := backfilltest.BuildFixtureCAR(records)
+	path := filepath.Join(t.TempDir(), "truncated.car")
+	if err := os.WriteFile(path, data[:len(data)-1], 0o644); err != nil {
+		t.Fatalf("write truncated CAR: %v", err)
+	}
+
+	car, err := OpenCARFile(path)
+	if err != nil {
+		// Truncation landing in the header is also an acceptable failure
+		// mode; OpenCARFile itself returning an error satisfies this test.
+		return
+	}
+	defer car.Close()
+
+	for {
+		if _, err := car.Next(); err != nil {
+			if err == io.EOF {
+				t.Fatalf("Next() reached EOF cleanly on a truncated file, want an error")
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package backfill
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// DecodedBlock is one CAR block with its DAG-CBOR value already decoded
+// (or the error encountered decoding it), as produced by DecodeCARFile.
+type DecodedBlock struct {
+	CID   []byte
+	Value any
+	Err   error
+}
+
+// DecodeCARFile opens the CARv1 file at path and decodes every block's
+// DAG-CBOR value, splitting the CPU-bound decode work across workers
+// goroutines. workers <= 0 defaults to runtime.GOMAXPROCS(0) — unlike
+// network fetch concurrency, which has to stay polite toward a PDS,
+// decoding an already-downloaded file has no reason not to use every
+// available core, so it's sized independently rather than sharing
+// Config.Concurrency.
+//
+// Reading the CAR file itself stays single-threaded (it's a sequential
+// framing with nothing to parallelize), but that's cheap relative to
+// decoding; a block that fails to decode is reported on its own
+// DecodedBlock.Err rather than failing the whole call.
+func DecodeCARFile(path string, workers int) ([]DecodedBlock, error) {
+	car, err := OpenCARFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer car.Close()
+
+	results, readErr := decodeCARBlocks(car, workers)
+	if readErr != nil {
+		return nil, fmt.Errorf("decode CAR %s: %w", path, readErr)
+	}
+	return results, nil
+}
+
+// QuarantineRecord describes one malformed-CAR quarantine incident,
+// written as JSON next to the raw bytes DecodeCARFileQuarantine couldn't
+// parse.
+type QuarantineRecord struct {
+	SourcePath    string    `json:"source_path"`
+	Offset        int64     `json:"offset"`
+	Error         string    `json:"error"`
+	DecodedBlocks int       `json:"decoded_blocks"`
+	QuarantinedAt time.Time `json:"quarantined_at"`
+}
+
+// DecodeCARFileQuarantine behaves like DecodeCARFile, except that if the
+// CAR's block framing itself becomes unreadable partway through (a
+// truncated download, a corrupted file), it salvages every block
+// successfully decoded before the break instead of discarding them. The
+// unparseable remainder of the file is written to quarantineDir as
+// "<base>.quarantine", alongside a QuarantineRecord describing what went
+// wrong as "<base>.quarantine.json" (both named after path's base name,
+// so quarantining the same DID's repo twice overwrites the earlier
+// incident rather than accumulating one file per attempt) — so one
+// malformed repo doesn't cost an otherwise-healthy DID its already-valid
+// records.
+func DecodeCARFileQuarantine(path string, workers int, quarantineDir string) ([]DecodedBlock, error) {
+	car, err := OpenCARFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer car.Close()
+
+	results, readErr := decodeCARBlocks(car, workers)
+	if readErr == nil {
+		return results, nil
+	}
+
+	if err := quarantineCARRemainder(car, path, quarantineDir, readErr, len(results)); err != nil {
+		return results, fmt.Errorf("decode CAR %s: %w (quarantine also failed: %v)", path, readErr, err)
+	}
+	return results, nil
+}
+
+// decodeCARBlocks drives car's blocks through a pool of workers decoding
+// each one's DAG-CBOR value, splitting the CPU-bound decode work across
+// workers goroutines. workers <= 0 defaults to runtime.GOMAXPROCS(0) —
+// unlike network fetch concurrency, which has to stay polite toward a
+// PDS, decoding an already-downloaded file has no reason not to use every
+// available core, so it's sized independently rather than sharing
+// Config.Concurrency.
+//
+// Reading the CAR file itself stays single-threaded (it's a sequential
+// framing with nothing to parallelize), but that's cheap relative to
+// decoding; a block that fails to decode is reported on its own
+// DecodedBlock.Err rather than failing the whole call. readErr is only
+// set when the framing itself becomes unreadable (not a bad block), in
+// which case results still holds every block decoded before the break.
+func decodeCARBlocks(car *CARFile, workers int) (results []DecodedBlock, readErr error) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	blocks := make(chan CARBlock, workers)
+	var resultsMu sync.Mutex
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for block := range blocks {
+				value, err := DecodeDAGCBORValue(block.Data)
+				resultsMu.Lock()
+				results = append(results, DecodedBlock{CID: block.CID, Value: value, Err: err})
+				resultsMu.Unlock()
+			}
+		}()
+	}
+
+	for {
+		block, err := car.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			readErr = err
+			break
+		}
+		// Next allocates a fresh CARBlock per call, so handing its CID and
+		// Data across goroutines here is safe without copying.
+		blocks <- block
+	}
+	close(blocks)
+	wg.Wait()
+
+	return results, readErr
+}
+
+// quarantineCARRemainder writes car's unparsed tail (from its current
+// Offset to EOF) and a QuarantineRecord describing readErr to
+// quarantineDir, backing DecodeCARFileQuarantine.
+func quarantineCARRemainder(car *CARFile, path, quarantineDir string, readErr error, decodedBlocks int) error {
+	if err := os.MkdirAll(quarantineDir, 0o755); err != nil {
+		return fmt.Errorf("create quarantine dir: %w", err)
+	}
+
+	remainder, err := car.ReadRemaining()
+	if err != nil {
+		return fmt.Errorf("read undecodable remainder: %w", err)
+	}
+
+	base := filepath.Base(path)
+	if err := os.WriteFile(filepath.Join(quarantineDir, base+".quarantine"), remainder, 0o644); err != nil {
+		return fmt.Errorf("write quarantined bytes: %w", err)
+	}
+
+	record := QuarantineRecord{
+		SourcePath:    path,
+		Offset:        car.Offset(),
+		Error:         readErr.Error(),
+		DecodedBlocks: decodedBlocks,
+		QuarantinedAt: time.Now(),
+	}
+	recordJSON, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode quarantine record: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(quarantineDir, base+".quarantine.json"), recordJSON, 0o644); err != nil {
+		return fmt.Errorf("write quarantine record: %w", err)
+	}
+	return nil
+}
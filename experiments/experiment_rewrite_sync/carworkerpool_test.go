@@ -0,0 +1,164 @@
+package backfill
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync/backfilltest"
+)
+
+func TestDecodeCARFileDecodesEveryBlockConcurrently(t *testing.T) {
+	records := backfilltest.GenerateFixtureRecords(5, 3, 2)
+	data, blocks := backfilltest.BuildFixtureCAR(records)
+	path := filepath.Join(t.TempDir(), "fixture.car")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("write fixture CAR: %v", err)
+	}
+
+	for _, workers := range []int{0, 1, 4} {
+		decoded, err := DecodeCARFile(path, workers)
+		if err != nil {
+			t.Fatalf("DecodeCARFile(workers=%d) error = %v", workers, err)
+		}
+		if len(decoded) != len(blocks) {
+			t.Fatalf("DecodeCARFile(workers=%d) returned %d blocks, want %d", workers, len(decoded), len(blocks))
+		}
+	}
+}
+
+func TestDecodeCARFileReportsPerBlockDecodeErrorsWithoutFailingTheCall(t *testing.T) {
+	// backfilltest's fixture blocks hold plain placeholder text, not real
+	// DAG-CBOR, so every block is expected to fail to decode as a CBOR
+	// value — but that's a per-block error, not a failure of the call.
+	records := backfilltest.GenerateFixtureRecords(2, 0, 0)
+	data, _ := backfilltest.BuildFixtureCAR(records)
+	path := filepath.Join(t.TempDir(), "fixture.car")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("write fixture CAR: %v", err)
+	}
+
+	decoded, err := DecodeCARFile(path, 2)
+	if err != nil {
+		t.Fatalf("DecodeCARFile() error = %v", err)
+	}
+	for _, b := range decoded {
+		if b.Err == nil {
+			t.Errorf("block %x decoded without error as %#v, want a CBOR decode error for placeholder bytes", b.CID, b.Value)
+		}
+	}
+}
+
+func TestDecodeCARFileDecodesValidDAGCBORBlocks(t *testing.T) {
+	cborValue := []byte{0x65, 'w', 'o', 'r', 'l', 'd'} // CBOR text string "world"
+	cid := cidV1ForTest(0x71, cborValue)               // 0x71 = dag-cbor codec
+
+	carData := backfilltest.BuildCAR([]backfilltest.Block{{CID: cid, Data: cborValue}})
+	path := filepath.Join(t.TempDir(), "valid.car")
+	if err := os.WriteFile(path, carData, 0o644); err != nil {
+		t.Fatalf("write CAR: %v", err)
+	}
+
+	decoded, err := DecodeCARFile(path, 1)
+	if err != nil {
+		t.Fatalf("DecodeCARFile() error = %v", err)
+	}
+	if len(decoded) != 1 {
+		t.Fatalf("got %d block(s), want 1", len(decoded))
+	}
+	if decoded[0].Err != nil {
+		t.Fatalf("decode error = %v", decoded[0].Err)
+	}
+	if decoded[0].Value != "world" {
+		t.Errorf("Value = %#v, want %q", decoded[0].Value, "world")
+	}
+}
+
+func TestDecodeCARFileQuarantineSalvagesBlocksDecodedBeforeTruncation(t *testing.T) {
+	records := backfilltest.GenerateFixtureRecords(5, 0, 0)
+	data, blocks := backfilltest.BuildFixtureCAR(records)
+	truncated := data[:len(data)-3] // cut off partway through the final block's framing
+
+	path := filepath.Join(t.TempDir(), "truncated.car")
+	if err := os.WriteFile(path, truncated, 0o644); err != nil {
+		t.Fatalf("write truncated CAR: %v", err)
+	}
+	quarantineDir := filepath.Join(t.TempDir(), "quarantine")
+
+	decoded, err := DecodeCARFileQuarantine(path, 2, quarantineDir)
+	if err != nil {
+		t.Fatalf("DecodeCARFileQuarantine() error = %v", err)
+	}
+	if len(decoded) != len(blocks)-1 {
+		t.Fatalf("decoded %d block(s), want %d (every block but the truncated last one)", len(decoded), len(blocks)-1)
+	}
+
+	quarantined, err := os.ReadFile(filepath.Join(quarantineDir, "truncated.car.quarantine"))
+	if err != nil {
+		t.Fatalf("read quarantined remainder: %v", err)
+	}
+	if len(quarantined) == 0 {
+		t.Error("quarantined remainder is empty, want the truncated tail of the file")
+	}
+
+	recordJSON, err := os.ReadFile(filepath.Join(quarantineDir, "truncated.car.quarantine.json"))
+	if err != nil {
+		t.Fatalf("read quarantine record: %v", err)
+	}
+	var record QuarantineRecord
+	if err := json.Unmarshal(recordJSON, &record); err != nil {
+		t.Fatalf("unmarshal quarantine record: %v", err)
+	}
+	if record.DecodedBlocks != len(blocks)-1 {
+		t.Errorf("record.DecodedBlocks = %d, want %d", record.DecodedBlocks, len(blocks)-1)
+	}
+	if record.Error == "" {
+		t.Error("record.Error is empty, want the framing read error")
+	}
+	if record.SourcePath != path {
+		t.Errorf("record.SourcePath = %q, want %q", record.SourcePath, path)
+	}
+}
+
+func TestDecodeCARFileQuarantineReturnsNilErrorAndSkipsQuarantineWhenFileIsHealthy(t *testing.T) {
+	records := backfilltest.GenerateFixtureRecords(2, 0, 0)
+	data, blocks := backfilltest.BuildFixtureCAR(records)
+	path := filepath.Join(t.TempDir(), "healthy.car")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("write CAR: %v", err)
+	}
+	quarantineDir := filepath.Join(t.TempDir(), "quarantine")
+
+	decoded, err := DecodeCARFileQuarantine(path, 2, quarantineDir)
+	if err != nil {
+		t.Fatalf("DecodeCARFileQuarantine() error = %v", err)
+	}
+	if len(decoded) != len(blocks) {
+		t.Fatalf("decoded %d block(s), want %d", len(decoded), len(blocks))
+	}
+	if _, err := os.Stat(quarantineDir); !os.IsNotExist(err) {
+		t.Errorf("quarantine dir was created for a healthy file: %v", err)
+	}
+}
+
+// cidV1ForTest builds a minimal binary CIDv1 (version + codec + sha256
+// multihash of data) for assembling CAR fixtures by hand.
+func cidV1ForTest(codec uint64, data []byte) []byte {
+	digest := sha256.Sum256(data)
+	var cid []byte
+	cid = appendUvarintForTest(cid, 1)
+	cid = appendUvarintForTest(cid, codec)
+	cid = appendUvarintForTest(cid, 0x12) // sha2-256 multihash code
+	cid = appendUvarintForTest(cid, 0x20) // digest length
+	return append(cid, digest[:]...)
+}
+
+func appendUvarintForTest(buf []byte, x uint64) []byte {
+	for x >= 0x80 {
+		buf = append(buf, byte(x)|0x80)
+		x >>= 7
+	}
+	return append(buf, byte(x))
+}
@@ -0,0 +1,124 @@
+package backfill
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// cassetteEntry is the on-disk representation of one recorded HTTP
+// exchange.
+type cassetteEntry struct {
+	StatusCode int                 `json:"status_code"`
+	Header     map[string][]string `json:"header"`
+	Body       string              `json:"body"`
+}
+
+// cassetteTransport wraps an http.RoundTripper to record real responses to
+// dir, or replay previously recorded ones from dir, keyed by a hash of the
+// request method, URL, and body. This makes a run reproducible offline:
+// record once against the real network, then replay forever in CI or for
+// benchmarking.
+type cassetteTransport struct {
+	dir    string
+	replay bool
+	next   http.RoundTripper
+}
+
+// NewCassetteTransport wraps next (http.DefaultTransport if nil) to
+// record HTTP exchanges under dir when replay is false, or to replay them
+// from dir without touching the network when replay is true.
+func NewCassetteTransport(dir string, replay bool, next http.RoundTripper) (http.RoundTripper, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("cassette: %w", err)
+	}
+	return &cassetteTransport{dir: dir, replay: replay, next: next}, nil
+}
+
+func (c *cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return nil, fmt.Errorf("cassette: read request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	path := c.entryPath(req, body)
+
+	if c.replay {
+		return c.loadEntry(path)
+	}
+
+	resp, err := c.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.saveEntry(path, resp); err != nil {
+		return nil, err
+	}
+	// saveEntry drained resp.Body; hand back a fresh reader over what we
+	// captured so the caller still sees the response.
+	return c.loadEntry(path)
+}
+
+func (c *cassetteTransport) entryPath(req *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(req.Method))
+	h.Write([]byte(req.URL.String()))
+	h.Write(body)
+	return filepath.Join(c.dir, hex.EncodeToString(h.Sum(nil))+".json")
+}
+
+func (c *cassetteTransport) loadEntry(path string) (*http.Response, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cassette: no recorded response for request: %w", err)
+	}
+	defer f.Close()
+
+	var entry cassetteEntry
+	if err := json.NewDecoder(f).Decode(&entry); err != nil {
+		return nil, fmt.Errorf("cassette: decode %s: %w", path, err)
+	}
+
+	return &http.Response{
+		StatusCode: entry.StatusCode,
+		Status:     http.StatusText(entry.StatusCode),
+		Header:     entry.Header,
+		Body:       io.NopCloser(bytes.NewReader([]byte(entry.Body))),
+	}, nil
+}
+
+func (c *cassetteTransport) saveEntry(path string, resp *http.Response) error {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("cassette: read response body: %w", err)
+	}
+
+	entry := cassetteEntry{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       string(body),
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("cassette: create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	return json.NewEncoder(f).Encode(entry)
+}
@@ -0,0 +1,58 @@
+package backfill
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCassetteRecordThenReplay(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("hello from the real server"))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+
+	recordTransport, err := NewCassetteTransport(dir, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recordClient := &http.Client{Transport: recordTransport}
+
+	resp, err := recordClient.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "hello from the real server" {
+		t.Fatalf("recorded body = %q", body)
+	}
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1", calls)
+	}
+
+	replayTransport, err := NewCassetteTransport(dir, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayClient := &http.Client{Transport: replayTransport}
+
+	resp2, err := replayClient.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body2, _ := io.ReadAll(resp2.Body)
+	resp2.Body.Close()
+	if string(body2) != "hello from the real server" {
+		t.Fatalf("replayed body = %q", body2)
+	}
+	if calls != 1 {
+		t.Errorf("replay hit the real server; calls = %d, want 1", calls)
+	}
+}
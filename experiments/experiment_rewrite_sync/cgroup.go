@@ -0,0 +1,31 @@
+package backfill
+
+import (
+	"math"
+	"runtime"
+)
+
+// DetectCPULimit returns how many CPUs this process may actually use,
+// preferring a cgroup CPU quota (as set by a Kubernetes/Docker resource
+// limit) over runtime.NumCPU(), which reports the host's total CPU count
+// even when the container is limited to a fraction of it. A fractional
+// quota (e.g. "500m" -> 0.5 CPUs) rounds up, since Go can't usefully
+// schedule across less than one OS thread. The result is always at
+// least 1.
+func DetectCPULimit() int {
+	if limit, ok := cgroupCPULimit(); ok && limit > 0 {
+		return int(math.Ceil(limit))
+	}
+	return runtime.NumCPU()
+}
+
+// SetGOMAXPROCSFromCgroup sets runtime.GOMAXPROCS to DetectCPULimit and
+// returns the value it set. Call it once at process startup, before
+// sizing any worker pools, so defaults derived from GOMAXPROCS (or from
+// DetectCPULimit directly) reflect the container's real CPU budget
+// instead of the host's.
+func SetGOMAXPROCSFromCgroup() int {
+	n := DetectCPULimit()
+	runtime.GOMAXPROCS(n)
+	return n
+}
@@ -0,0 +1,61 @@
+//go:build linux
+
+package backfill
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// cgroupCPULimit reads the CPU quota cgroups enforces on this process,
+// preferring the cgroup v2 unified hierarchy and falling back to cgroup
+// v1. It reports ok=false if no quota is set (cpu.max == "max", or
+// neither hierarchy is readable, e.g. not actually running under
+// cgroups), in which case callers should fall back to runtime.NumCPU().
+func cgroupCPULimit() (limit float64, ok bool) {
+	if limit, ok := cgroupV2CPULimit(); ok {
+		return limit, true
+	}
+	return cgroupV1CPULimit()
+}
+
+func cgroupV2CPULimit() (float64, bool) {
+	data, err := os.ReadFile("/sys/fs/cgroup/cpu.max")
+	if err != nil {
+		return 0, false
+	}
+	fields := strings.Fields(strings.TrimSpace(string(data)))
+	if len(fields) != 2 || fields[0] == "max" {
+		return 0, false
+	}
+	quota, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, false
+	}
+	period, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil || period <= 0 {
+		return 0, false
+	}
+	return quota / period, true
+}
+
+func cgroupV1CPULimit() (float64, bool) {
+	quota, err := readCgroupInt("/sys/fs/cgroup/cpu/cpu.cfs_quota_us")
+	if err != nil || quota <= 0 {
+		return 0, false
+	}
+	period, err := readCgroupInt("/sys/fs/cgroup/cpu/cpu.cfs_period_us")
+	if err != nil || period <= 0 {
+		return 0, false
+	}
+	return float64(quota) / float64(period), true
+}
+
+func readCgroupInt(path string) (int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
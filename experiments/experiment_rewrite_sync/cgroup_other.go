@@ -0,0 +1,9 @@
+//go:build !linux
+
+package backfill
+
+// cgroupCPULimit is only implemented on Linux, where cgroups live;
+// elsewhere DetectCPULimit falls back to runtime.NumCPU().
+func cgroupCPULimit() (limit float64, ok bool) {
+	return 0, false
+}
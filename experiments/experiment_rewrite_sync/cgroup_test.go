@@ -0,0 +1,21 @@
+package backfill
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestDetectCPULimitIsAtLeastOne(t *testing.T) {
+	if got := DetectCPULimit(); got < 1 {
+		t.Errorf("DetectCPULimit() = %d, want >= 1", got)
+	}
+}
+
+func TestDetectCPULimitFallsBackToNumCPUWithoutACgroupQuota(t *testing.T) {
+	if _, ok := cgroupCPULimit(); ok {
+		t.Skip("running under an actual CPU-limited cgroup; fallback path not exercised")
+	}
+	if got, want := DetectCPULimit(), runtime.NumCPU(); got != want {
+		t.Errorf("DetectCPULimit() = %d, want runtime.NumCPU() = %d", got, want)
+	}
+}
@@ -0,0 +1,96 @@
+package backfill
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+)
+
+// ChaosConfig controls how often chaosTransport injects each kind of
+// failure. Each rate is a probability in [0, 1]; rates are evaluated
+// independently and in the order below, so at most one failure mode fires
+// per request.
+type ChaosConfig struct {
+	// TimeoutRate causes the request's context to appear to have expired.
+	TimeoutRate float64
+
+	// RateLimitRate causes a synthetic 429 Too Many Requests response.
+	RateLimitRate float64
+
+	// TruncateRate cuts the real response body short, simulating a
+	// connection dropped mid-transfer.
+	TruncateRate float64
+
+	// MalformedBlockRate corrupts a single byte in the middle of the
+	// response body, simulating a malformed CAR block.
+	MalformedBlockRate float64
+}
+
+// chaosTransport wraps an http.RoundTripper to probabilistically inject
+// failures, so retry, circuit-breaker, and quarantine behavior can be
+// exercised under adverse conditions without a real flaky network.
+type chaosTransport struct {
+	next http.RoundTripper
+	cfg  ChaosConfig
+	rng  *rand.Rand
+}
+
+// NewChaosTransport wraps next (http.DefaultTransport if nil) with fault
+// injection per cfg. seed makes injected failures reproducible across
+// runs.
+func NewChaosTransport(next http.RoundTripper, cfg ChaosConfig, seed int64) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &chaosTransport{next: next, cfg: cfg, rng: rand.New(rand.NewSource(seed))}
+}
+
+func (c *chaosTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if c.roll(c.cfg.TimeoutRate) {
+		return nil, context.DeadlineExceeded
+	}
+	if c.roll(c.cfg.RateLimitRate) {
+		return &http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Status:     http.StatusText(http.StatusTooManyRequests),
+			Header:     http.Header{},
+			Body:       io.NopCloser(bytes.NewReader(nil)),
+		}, nil
+	}
+
+	resp, err := c.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	if c.roll(c.cfg.TruncateRate) {
+		body, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if readErr != nil {
+			return resp, readErr
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body[:len(body)/2]))
+		return resp, nil
+	}
+
+	if c.roll(c.cfg.MalformedBlockRate) {
+		body, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if readErr != nil {
+			return resp, readErr
+		}
+		if len(body) > 0 {
+			body[len(body)/2] ^= 0xff
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		return resp, nil
+	}
+
+	return resp, nil
+}
+
+func (c *chaosTransport) roll(rate float64) bool {
+	return rate > 0 && c.rng.Float64() < rate
+}
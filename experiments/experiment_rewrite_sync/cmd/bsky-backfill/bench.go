@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	backfill "github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync"
+	"github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync/backfilltest"
+)
+
+// runBench runs the same DID sample across a grid of
+// concurrency/max-inflight-per-host settings and prints a comparison
+// table, replacing the ad-hoc shell scripts used for the Python-vs-Go
+// experiment.
+func runBench(args []string) error {
+	fs := flag.NewFlagSet("bsky-backfill bench", flag.ContinueOnError)
+	didFile := fs.String("dids", "", "path to a file of newline-separated DIDs to benchmark against")
+	concurrencyGrid := fs.String("concurrency-grid", "1,4,16", "comma-separated list of Concurrency values to sweep")
+	maxInflightGrid := fs.String("max-inflight-per-host-grid", "0", "comma-separated list of MaxInFlightPerHost values to sweep")
+	useMock := fs.Bool("mock", true, "benchmark against an in-process mock PLC/PDS instead of the real network")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var dids []backfill.WorkItem
+	if *didFile != "" {
+		var err error
+		dids, err = readWorkItems(*didFile)
+		if err != nil {
+			return fmt.Errorf("read --dids file: %w", err)
+		}
+	}
+	if len(dids) == 0 {
+		dids = sampleDIDs(50)
+	}
+
+	concurrencies, err := parseIntList(*concurrencyGrid)
+	if err != nil {
+		return fmt.Errorf("--concurrency-grid: %w", err)
+	}
+	maxInflights, err := parseIntList(*maxInflightGrid)
+	if err != nil {
+		return fmt.Errorf("--max-inflight-per-host-grid: %w", err)
+	}
+
+	var plcURL string
+	var cleanup func()
+	if *useMock {
+		plcURL, cleanup = startMockPLCFor(dids)
+		defer cleanup()
+	}
+
+	fmt.Println("concurrency\tmax_inflight_per_host\tdids\tduration")
+	for _, concurrency := range concurrencies {
+		for _, maxInflight := range maxInflights {
+			cfg := backfill.Config{
+				DIDs:               dids,
+				Concurrency:        concurrency,
+				MaxInFlightPerHost: maxInflight,
+				PLCDirectoryURL:    plcURL,
+			}
+
+			start := time.Now()
+			result, err := backfill.Run(context.Background(), cfg)
+			elapsed := time.Since(start)
+			if err != nil {
+				return fmt.Errorf("bench run (concurrency=%d, max_inflight=%d): %w", concurrency, maxInflight, err)
+			}
+
+			fmt.Printf("%d\t%d\t%d\t%s\n", concurrency, maxInflight, len(result.PerDID), elapsed)
+		}
+	}
+
+	return nil
+}
+
+func parseIntList(s string) ([]int, error) {
+	var out []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", part, err)
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+// sampleDIDs generates n placeholder DIDs for benchmarking when the caller
+// doesn't supply a --dids file.
+func sampleDIDs(n int) []backfill.WorkItem {
+	items := make([]backfill.WorkItem, n)
+	for i := range items {
+		items[i] = backfill.WorkItem{DID: fmt.Sprintf("did:plc:bench%04d", i)}
+	}
+	return items
+}
+
+// startMockPLCFor spins up an in-process PLC directory and PDS that
+// resolve every DID in dids, so bench can measure the tool's own overhead
+// without real network variance.
+func startMockPLCFor(dids []backfill.WorkItem) (plcURL string, cleanup func()) {
+	pds := backfilltest.NewPDS(nil)
+
+	docs := make(map[string]backfilltest.PLCDoc, len(dids))
+	for _, item := range dids {
+		docs[item.DID] = backfilltest.PDSDoc(pds.URL, "")
+	}
+	plc := backfilltest.NewPLCDirectory(docs)
+
+	return plc.URL, func() {
+		plc.Close()
+		pds.Close()
+	}
+}
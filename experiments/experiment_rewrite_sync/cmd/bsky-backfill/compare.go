@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	backfill "github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync"
+)
+
+// compareReport summarizes one side-by-side run of the Go backfill against
+// the Python reference implementation over the same DID list.
+type compareReport struct {
+	GoDuration     time.Duration
+	PythonDuration time.Duration
+
+	GoRecords     int
+	PythonRecords int
+
+	// GoHeapSysKB is the Go runtime's reported heap+system memory, not RSS;
+	// PythonMaxRSSKB comes from the OS-reported peak RSS of the child
+	// process, so the two aren't directly comparable, just both useful.
+	GoHeapSysKB    int64
+	PythonMaxRSSKB int64
+
+	OnlyInGo     []string
+	OnlyInPython []string
+}
+
+// runCompare runs the Go backfill and the Python reference implementation
+// over the same DID list and prints a unified report, replacing the
+// practice of stitching together two JSON output files by hand.
+func runCompare(args []string) error {
+	fs := flag.NewFlagSet("bsky-backfill compare", flag.ContinueOnError)
+	didFile := fs.String("dids", "", "path to a file of newline-separated DIDs to compare against (required)")
+	pythonCmd := fs.String("python-cmd", "", `command that invokes the Python reference implementation, e.g. "python -m services.sync.backfill" (required)`)
+	pythonOutput := fs.String("python-output", "", "path the Python command writes its JSON-lines output (one {\"uri\":...} object per line) to (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *didFile == "" || *pythonCmd == "" || *pythonOutput == "" {
+		return fmt.Errorf("compare: --dids, --python-cmd, and --python-output are all required")
+	}
+
+	dids, err := readWorkItems(*didFile)
+	if err != nil {
+		return fmt.Errorf("read --dids file: %w", err)
+	}
+
+	goURIs, goDuration, goRSS, err := runGoSide(dids)
+	if err != nil {
+		return fmt.Errorf("go backfill: %w", err)
+	}
+
+	pythonURIs, pythonDuration, pythonRSS, err := runPythonSide(*pythonCmd, *didFile, *pythonOutput)
+	if err != nil {
+		return fmt.Errorf("python reference: %w", err)
+	}
+
+	report := buildCompareReport(goURIs, pythonURIs, goDuration, pythonDuration, goRSS, pythonRSS)
+	printCompareReport(report)
+	return nil
+}
+
+// uriSink collects the URIs of every record the Go backfill writes, so
+// compare can diff them against the Python side without an intermediate
+// output file.
+type uriSink struct {
+	mu   sync.Mutex
+	uris []string
+}
+
+func (s *uriSink) Write(ctx context.Context, did string, rec backfill.Record) error {
+	s.mu.Lock()
+	s.uris = append(s.uris, rec.URI)
+	s.mu.Unlock()
+	return nil
+}
+
+func runGoSide(dids []backfill.WorkItem) (uris []string, elapsed time.Duration, maxRSSKB int64, err error) {
+	sink := &uriSink{}
+	cfg := backfill.Config{
+		DIDs: dids,
+		Sink: sink,
+	}
+
+	start := time.Now()
+	_, err = backfill.Run(context.Background(), cfg)
+	elapsed = time.Since(start)
+	if err != nil {
+		return nil, elapsed, 0, err
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return sink.uris, elapsed, int64(mem.Sys / 1024), nil
+}
+
+func runPythonSide(pythonCmd, didFile, outputPath string) (uris []string, elapsed time.Duration, maxRSSKB int64, err error) {
+	fields := strings.Fields(pythonCmd)
+	if len(fields) == 0 {
+		return nil, 0, 0, fmt.Errorf("--python-cmd is empty")
+	}
+	argv := append(append([]string{}, fields[1:]...), didFile)
+
+	cmd := exec.Command(fields[0], argv...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+
+	start := time.Now()
+	err = cmd.Run()
+	elapsed = time.Since(start)
+	if err != nil {
+		return nil, elapsed, 0, fmt.Errorf("run %q: %w", pythonCmd, err)
+	}
+
+	uris, err = readURIsFromJSONLines(outputPath)
+	if err != nil {
+		return nil, elapsed, 0, fmt.Errorf("read --python-output: %w", err)
+	}
+
+	return uris, elapsed, processMaxRSSKB(cmd.ProcessState), nil
+}
+
+func readURIsFromJSONLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var uris []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var rec struct {
+			URI string `json:"uri"`
+		}
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			return nil, fmt.Errorf("parse line %q: %w", line, err)
+		}
+		uris = append(uris, rec.URI)
+	}
+	return uris, scanner.Err()
+}
+
+func buildCompareReport(goURIs, pythonURIs []string, goDuration, pythonDuration time.Duration, goRSS, pythonRSS int64) compareReport {
+	inPython := make(map[string]bool, len(pythonURIs))
+	for _, u := range pythonURIs {
+		inPython[u] = true
+	}
+	inGo := make(map[string]bool, len(goURIs))
+	for _, u := range goURIs {
+		inGo[u] = true
+	}
+
+	var onlyInGo, onlyInPython []string
+	for _, u := range goURIs {
+		if !inPython[u] {
+			onlyInGo = append(onlyInGo, u)
+		}
+	}
+	for _, u := range pythonURIs {
+		if !inGo[u] {
+			onlyInPython = append(onlyInPython, u)
+		}
+	}
+	sort.Strings(onlyInGo)
+	sort.Strings(onlyInPython)
+
+	return compareReport{
+		GoDuration:     goDuration,
+		PythonDuration: pythonDuration,
+		GoRecords:      len(goURIs),
+		PythonRecords:  len(pythonURIs),
+		GoHeapSysKB:    goRSS,
+		PythonMaxRSSKB: pythonRSS,
+		OnlyInGo:       onlyInGo,
+		OnlyInPython:   onlyInPython,
+	}
+}
+
+func printCompareReport(r compareReport) {
+	fmt.Println("metric\tgo\tpython")
+	fmt.Printf("duration\t%s\t%s\n", r.GoDuration, r.PythonDuration)
+	fmt.Printf("records\t%d\t%d\n", r.GoRecords, r.PythonRecords)
+	fmt.Printf("mem_kb\t%d\t%d\n", r.GoHeapSysKB, r.PythonMaxRSSKB)
+
+	fmt.Printf("\nonly in go (%d):\n", len(r.OnlyInGo))
+	for _, u := range r.OnlyInGo {
+		fmt.Println("  ", u)
+	}
+	fmt.Printf("\nonly in python (%d):\n", len(r.OnlyInPython))
+	for _, u := range r.OnlyInPython {
+		fmt.Println("  ", u)
+	}
+}
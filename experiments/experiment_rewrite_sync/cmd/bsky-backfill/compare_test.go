@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildCompareReportDiffsURIs(t *testing.T) {
+	goURIs := []string{"at://did:plc:a/app.bsky.feed.post/1", "at://did:plc:a/app.bsky.feed.post/2"}
+	pythonURIs := []string{"at://did:plc:a/app.bsky.feed.post/2", "at://did:plc:a/app.bsky.feed.post/3"}
+
+	report := buildCompareReport(goURIs, pythonURIs, time.Second, 2*time.Second, 10, 20)
+
+	if report.GoRecords != 2 || report.PythonRecords != 2 {
+		t.Fatalf("unexpected record counts: %+v", report)
+	}
+	if len(report.OnlyInGo) != 1 || report.OnlyInGo[0] != "at://did:plc:a/app.bsky.feed.post/1" {
+		t.Fatalf("unexpected OnlyInGo: %v", report.OnlyInGo)
+	}
+	if len(report.OnlyInPython) != 1 || report.OnlyInPython[0] != "at://did:plc:a/app.bsky.feed.post/3" {
+		t.Fatalf("unexpected OnlyInPython: %v", report.OnlyInPython)
+	}
+	if report.GoDuration != time.Second || report.PythonDuration != 2*time.Second {
+		t.Fatalf("unexpected durations: %+v", report)
+	}
+	if report.GoHeapSysKB != 10 || report.PythonMaxRSSKB != 20 {
+		t.Fatalf("unexpected memory fields: %+v", report)
+	}
+}
+
+func TestBuildCompareReportIdenticalOutputsHaveNoDiff(t *testing.T) {
+	uris := []string{"at://did:plc:a/app.bsky.feed.post/1"}
+	report := buildCompareReport(uris, uris, 0, 0, 0, 0)
+
+	if len(report.OnlyInGo) != 0 || len(report.OnlyInPython) != 0 {
+		t.Fatalf("expected no diff, got: %+v", report)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	backfill "github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync"
+)
+
+// runConvert transforms an existing output between formats, so a format
+// decision made at collection time isn't permanent. Input can be JSON-lines
+// (a single file or a directory of FileSink shards) or a CAR file; output
+// can be JSON-lines, Parquet, or Arrow IPC, though the latter two currently
+// fail with a clear error (see backfill.ErrParquetUnsupported,
+// backfill.ErrArrowUnsupported) since this module vendors no columnar
+// encoder dependency.
+func runConvert(args []string) error {
+	fs := flag.NewFlagSet("bsky-backfill convert", flag.ContinueOnError)
+	input := fs.String("input", "", "path to convert from: a JSON-lines file, a directory of JSON-lines shards, or a CAR file (required)")
+	inputFormat := fs.String("input-format", "jsonl", `format of --input: "jsonl" or "car"`)
+	output := fs.String("output", "", "path to convert to: a directory for --output-format jsonl, or a file for parquet/arrow (required)")
+	outputFormat := fs.String("output-format", "jsonl", `format to write: "jsonl", "parquet", or "arrow"`)
+	decodeWorkers := fs.Int("decode-workers", 0, "for --input-format car, number of goroutines decoding blocks concurrently (0 = use every detected CPU)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *input == "" || *output == "" {
+		return fmt.Errorf("convert: --input and --output are both required")
+	}
+
+	var records []backfill.Record
+	var err error
+	switch *inputFormat {
+	case "jsonl":
+		records, err = readJSONLInput(*input)
+	case "car":
+		records, err = recordsFromCARFile(*input, *decodeWorkers)
+	default:
+		return fmt.Errorf(`convert: --input-format %q: must be "jsonl" or "car"`, *inputFormat)
+	}
+	if err != nil {
+		return fmt.Errorf("convert: read --input: %w", err)
+	}
+
+	switch *outputFormat {
+	case "jsonl":
+		if err := writeJSONLOutput(*output, records); err != nil {
+			return fmt.Errorf("convert: write --output: %w", err)
+		}
+	case "parquet":
+		if _, err := backfill.NewParquetSink(*output); err != nil {
+			return fmt.Errorf("convert: write --output: %w", err)
+		}
+	case "arrow":
+		if _, err := backfill.NewArrowSink(*output); err != nil {
+			return fmt.Errorf("convert: write --output: %w", err)
+		}
+	default:
+		return fmt.Errorf(`convert: --output-format %q: must be "jsonl", "parquet", or "arrow"`, *outputFormat)
+	}
+
+	fmt.Printf("converted %d record(s) from %s (%s) to %s (%s)\n", len(records), *input, *inputFormat, *output, *outputFormat)
+	return nil
+}
+
+// readJSONLInput reads records from input, which is either a single
+// JSON-lines file or a directory of FileSink shards.
+func readJSONLInput(input string) ([]backfill.Record, error) {
+	info, err := os.Stat(input)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return readAllShards(input)
+	}
+	return readShardFile(input)
+}
+
+// writeJSONLOutput writes records as FileSink shards (one per DID, the
+// default partitioning) under the directory output.
+func writeJSONLOutput(output string, records []backfill.Record) error {
+	sink, err := backfill.NewFileSink(output)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	for _, rec := range records {
+		did, _, _, err := backfill.ParseATURI(rec.URI)
+		if err != nil {
+			did = "unknown"
+		}
+		if err := sink.Write(ctx, did, rec); err != nil {
+			sink.Close()
+			return err
+		}
+	}
+	return sink.Close()
+}
+
+// recordsFromCARFile decodes every block in the CAR file at path and
+// extracts the ones that look like AT Protocol records (a DAG-CBOR map
+// with a "$type" field), for converting an archived repo snapshot back
+// into JSON-lines.
+//
+// Every returned Record's URI is left empty: a CAR file's blocks form a
+// merkle search tree linked by CID, and recovering a block's original
+// collection/rkey path means walking that tree from the root commit,
+// which recordsFromCARFile doesn't do — it only decodes blocks (see
+// carworkerpool.go, carfile.go) and doesn't call backfill.WalkMST to
+// resolve their tree position. CID and Value are populated, which is enough to recover the
+// record's content and type, just not its original at:// address.
+func recordsFromCARFile(path string, decodeWorkers int) ([]backfill.Record, error) {
+	blocks, err := backfill.DecodeCARFile(path, decodeWorkers)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []backfill.Record
+	for _, b := range blocks {
+		if b.Err != nil {
+			continue
+		}
+		m, ok := b.Value.(map[string]any)
+		if !ok {
+			continue
+		}
+		if _, ok := m["$type"]; !ok {
+			continue
+		}
+		value, err := json.Marshal(m)
+		if err != nil {
+			return nil, fmt.Errorf("encode record %x: %w", b.CID, err)
+		}
+		records = append(records, backfill.Record{CID: fmt.Sprintf("%x", b.CID), Value: value})
+	}
+	return records, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	backfill "github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync"
+)
+
+// runDaemon re-runs a fixed set of cron-scheduled sync jobs (see
+// backfill.Daemon) until interrupted, for nightly incremental refreshes
+// that shouldn't need a cron entry plus a wrapper script.
+func runDaemon(args []string) error {
+	fs := flag.NewFlagSet("bsky-backfill daemon", flag.ContinueOnError)
+	configPath := fs.String("config", "", "path to a JSON file of scheduled jobs (a []backfill.ScheduledJob array; see JobRequest for the request fields)")
+	statePath := fs.String("state", "bsky-backfill-jobs.json", "path to persist job state across restarts")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *configPath == "" {
+		return fmt.Errorf("daemon: --config is required")
+	}
+
+	body, err := os.ReadFile(*configPath)
+	if err != nil {
+		return fmt.Errorf("read --config: %w", err)
+	}
+	var jobs []backfill.ScheduledJob
+	if err := json.Unmarshal(body, &jobs); err != nil {
+		return fmt.Errorf("parse --config: %w", err)
+	}
+
+	srv, err := backfill.NewJobServer(*statePath)
+	if err != nil {
+		return fmt.Errorf("daemon: %w", err)
+	}
+	d, err := backfill.NewDaemon(srv, jobs)
+	if err != nil {
+		return fmt.Errorf("daemon: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("bsky-backfill: daemon running %d scheduled job(s) (state: %s)\n", len(jobs), *statePath)
+	if err := d.Run(ctx); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
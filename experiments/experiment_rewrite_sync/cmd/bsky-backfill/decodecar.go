@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"time"
+
+	backfill "github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync"
+)
+
+// runDecodeCAR decodes every block in a CAR file as a DAG-CBOR value and
+// reports how many decoded cleanly. --decode-workers sizes the decode
+// worker pool independently from --concurrency, which elsewhere governs
+// network fetch concurrency: decoding an already-downloaded file is
+// CPU-bound and has no reason to stay within a PDS's polite-use limits.
+func runDecodeCAR(args []string) error {
+	fs := flag.NewFlagSet("bsky-backfill decode-car", flag.ContinueOnError)
+	path := fs.String("car", "", "path to the CAR file to decode (required)")
+	decodeWorkers := fs.Int("decode-workers", 0, "number of goroutines decoding blocks concurrently (0 = use every detected CPU)")
+	commitMetadata := fs.Bool("commit-metadata", false, "also print the root commit block's did/rev/prev/commit-time for provenance tracking (see backfill.ParseCommitMetadata)")
+	quarantineDir := fs.String("quarantine-dir", "", "if the CAR's framing becomes unreadable partway through, salvage the blocks decoded so far and write the undecodable remainder here instead of failing outright")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *path == "" {
+		return fmt.Errorf("decode-car: --car is required")
+	}
+
+	var blocks []backfill.DecodedBlock
+	var err error
+	if *quarantineDir != "" {
+		blocks, err = backfill.DecodeCARFileQuarantine(*path, *decodeWorkers, *quarantineDir)
+	} else {
+		blocks, err = backfill.DecodeCARFile(*path, *decodeWorkers)
+	}
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	for _, b := range blocks {
+		if b.Err != nil {
+			failed++
+		}
+	}
+	fmt.Printf("decoded %d block(s), %d failed\n", len(blocks), failed)
+
+	if *commitMetadata {
+		meta, err := rootCommitMetadata(*path, blocks)
+		if err != nil {
+			return fmt.Errorf("decode-car: --commit-metadata: %w", err)
+		}
+		fmt.Printf("commit: did=%s rev=%s prev=%s commit_time=%s signature_valid=%s\n",
+			meta.DID, meta.Rev, formatCommitPrev(meta.Prev), formatCommitTime(meta.CommitTime), formatSignatureValid(meta.SignatureValid))
+	}
+	return nil
+}
+
+// rootCommitMetadata re-opens path just far enough to read its CAR header's
+// root CID, then parses the already-decoded block matching that CID as a
+// CommitMetadata. DecodeCARFile doesn't expose the roots it read, so this
+// reopens the file rather than changing DecodeCARFile's signature for a
+// value only this flag needs.
+func rootCommitMetadata(path string, blocks []backfill.DecodedBlock) (backfill.CommitMetadata, error) {
+	car, err := backfill.OpenCARFile(path)
+	if err != nil {
+		return backfill.CommitMetadata{}, err
+	}
+	defer car.Close()
+
+	roots := car.Roots()
+	if len(roots) == 0 {
+		return backfill.CommitMetadata{}, fmt.Errorf("CAR file has no root block")
+	}
+	root := roots[0]
+
+	for _, b := range blocks {
+		if b.Err == nil && bytes.Equal(b.CID, root) {
+			return backfill.ParseCommitMetadata(b.Value)
+		}
+	}
+	return backfill.CommitMetadata{}, fmt.Errorf("root block %x not found among decoded blocks", root)
+}
+
+// formatCommitPrev renders a possibly-nil prev commit link as hex for CLI
+// output; nil (a genesis commit) renders as "none".
+func formatCommitPrev(prev *backfill.CIDLink) string {
+	if prev == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%x", []byte(*prev))
+}
+
+// formatCommitTime renders a possibly-nil commit timestamp for CLI output.
+func formatCommitTime(t *time.Time) string {
+	if t == nil {
+		return "unknown"
+	}
+	return t.Format("2006-01-02T15:04:05Z")
+}
+
+// formatSignatureValid renders a possibly-nil signature-validity flag for
+// CLI output; nil means ParseCommitMetadata didn't attempt verification.
+func formatSignatureValid(v *bool) string {
+	if v == nil {
+		return "unverified"
+	}
+	if *v {
+		return "true"
+	}
+	return "false"
+}
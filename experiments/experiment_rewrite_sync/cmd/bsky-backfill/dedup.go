@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	backfill "github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync"
+)
+
+// duplicateRecord is one (uri, cid) pair dedup found written more than
+// once, for the duplicates report.
+type duplicateRecord struct {
+	URI   string `json:"uri"`
+	CID   string `json:"cid"`
+	Count int    `json:"count"`
+}
+
+// runDedup scans one or more prior runs' --output-dir directories,
+// identifies exact duplicates by (uri, cid) — the same record written
+// more than once, typically because its DID appeared in more than one
+// overlapping cohort — and writes a deduplicated copy plus a report of
+// what was removed, replacing a hand-rolled cleanup pass over the raw
+// shards.
+func runDedup(args []string) error {
+	fs := flag.NewFlagSet("bsky-backfill dedup", flag.ContinueOnError)
+	inputs := fs.String("inputs", "", "comma-separated list of --output-dir directories to scan (required)")
+	outputDir := fs.String("output-dir", "", "directory to write the deduplicated shards to (required)")
+	reportPath := fs.String("report", "", "write a JSON report of every duplicate (uri, cid) pair found, and how many times it appeared, to this path")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *inputs == "" || *outputDir == "" {
+		return fmt.Errorf("dedup: --inputs and --output-dir are both required")
+	}
+
+	dirs := splitCSV(*inputs)
+
+	type key struct{ uri, cid string }
+	first := make(map[key]backfill.Record)
+	counts := make(map[key]int)
+	var order []key
+
+	for _, dir := range dirs {
+		records, err := readAllShards(dir)
+		if err != nil {
+			return fmt.Errorf("dedup: read --inputs directory %s: %w", dir, err)
+		}
+		for _, rec := range records {
+			k := key{uri: rec.URI, cid: rec.CID}
+			if counts[k] == 0 {
+				first[k] = rec
+				order = append(order, k)
+			}
+			counts[k]++
+		}
+	}
+
+	sink, err := backfill.NewFileSink(*outputDir)
+	if err != nil {
+		return fmt.Errorf("open --output-dir: %w", err)
+	}
+
+	ctx := context.Background()
+	var duplicates []duplicateRecord
+	for _, k := range order {
+		rec := first[k]
+		did, _, _, err := backfill.ParseATURI(rec.URI)
+		if err != nil {
+			did = "unknown"
+		}
+		if err := sink.Write(ctx, did, rec); err != nil {
+			sink.Close()
+			return fmt.Errorf("write deduplicated record %s: %w", rec.URI, err)
+		}
+		if n := counts[k]; n > 1 {
+			duplicates = append(duplicates, duplicateRecord{URI: k.uri, CID: k.cid, Count: n})
+		}
+	}
+
+	if err := sink.Close(); err != nil {
+		return fmt.Errorf("close deduplicated --output-dir: %w", err)
+	}
+
+	sort.Slice(duplicates, func(i, j int) bool { return duplicates[i].URI < duplicates[j].URI })
+
+	if *reportPath != "" {
+		if err := writeDuplicatesReport(*reportPath, duplicates); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("scanned %d input director(ies): %d unique record(s), %d duplicate (uri, cid) pair(s) removed\n",
+		len(dirs), len(order), len(duplicates))
+	return nil
+}
+
+// writeDuplicatesReport writes duplicates as indented JSON to path.
+func writeDuplicatesReport(path string, duplicates []duplicateRecord) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("write duplicates report: %w", err)
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(duplicates)
+}
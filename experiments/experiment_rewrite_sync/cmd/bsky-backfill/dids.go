@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+
+	backfill "github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync"
+)
+
+// readWorkItems reads path and returns its non-blank, trimmed lines as
+// WorkItems. Each line is either a bare DID or "did,priority" — the
+// priority column is optional and defaults to 0 when absent or unparsable.
+func readWorkItems(path string) ([]backfill.WorkItem, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var items []backfill.WorkItem
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		items = append(items, parseWorkItemLine(line))
+	}
+	return items, scanner.Err()
+}
+
+// toWorkItems wraps bare DIDs (e.g. from positional CLI args) as WorkItems
+// with default priority.
+func toWorkItems(dids []string) []backfill.WorkItem {
+	items := make([]backfill.WorkItem, 0, len(dids))
+	for _, did := range dids {
+		items = append(items, parseWorkItemLine(did))
+	}
+	return items
+}
+
+// streamWorkItems reads path's lines into a channel of WorkItems as
+// they're read, instead of loading the whole file into a slice first —
+// required for full-network DID lists that exceed available RAM. The
+// returned error channel receives at most one error (a read failure; a
+// clean EOF sends nothing) once the work channel is closed.
+func streamWorkItems(path string) (<-chan backfill.WorkItem, <-chan error) {
+	items := make(chan backfill.WorkItem, 1024)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(items)
+		defer close(errs)
+
+		f, err := os.Open(path)
+		if err != nil {
+			errs <- err
+			return
+		}
+		defer f.Close()
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			items <- parseWorkItemLine(line)
+		}
+		if err := scanner.Err(); err != nil {
+			errs <- err
+		}
+	}()
+
+	return items, errs
+}
+
+// prependWorkItems returns a channel that yields prefix's items before
+// relaying rest, so positional DIDs given alongside --stream-dids aren't
+// silently dropped in favor of the streamed file (Config.DIDStream
+// otherwise ignores Config.DIDs entirely).
+func prependWorkItems(prefix []backfill.WorkItem, rest <-chan backfill.WorkItem) <-chan backfill.WorkItem {
+	out := make(chan backfill.WorkItem, 1024)
+	go func() {
+		defer close(out)
+		for _, item := range prefix {
+			out <- item
+		}
+		for item := range rest {
+			out <- item
+		}
+	}()
+	return out
+}
+
+// countLines counts path's non-blank lines without holding its contents
+// in memory, so a --stream-dids run can report progress against a known
+// total while streamWorkItems feeds the same file incrementally.
+func countLines(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var n int
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) != "" {
+			n++
+		}
+	}
+	return n, scanner.Err()
+}
+
+func parseWorkItemLine(line string) backfill.WorkItem {
+	did, priorityStr, hasPriority := strings.Cut(line, ",")
+	item := backfill.WorkItem{DID: strings.TrimSpace(did)}
+	if hasPriority {
+		if priority, err := strconv.Atoi(strings.TrimSpace(priorityStr)); err == nil {
+			item.Priority = priority
+		}
+	}
+	return item
+}
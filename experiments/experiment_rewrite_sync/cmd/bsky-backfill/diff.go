@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	backfill "github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync"
+)
+
+// runDiff compares two archived snapshots of the same DID set's records and
+// prints what was added, removed, or changed between them, for churn and
+// deletion studies. Both --old and --new are JSON-lines files of records
+// (the format ListRecords/GetRecord output already use) — diffing raw CAR
+// files directly isn't supported: records decoded from a CAR file (see
+// recordsFromCARFile) carry no AT-URI, and DiffRecords matches by URI.
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("bsky-backfill diff", flag.ContinueOnError)
+	oldPath := fs.String("old", "", "JSON-lines record file from the earlier sync run (required)")
+	newPath := fs.String("new", "", "JSON-lines record file from the later sync run (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *oldPath == "" || *newPath == "" {
+		return fmt.Errorf("diff: --old and --new are both required")
+	}
+
+	oldRecords, err := readRecordsJSONLines(*oldPath)
+	if err != nil {
+		return fmt.Errorf("read --old: %w", err)
+	}
+	newRecords, err := readRecordsJSONLines(*newPath)
+	if err != nil {
+		return fmt.Errorf("read --new: %w", err)
+	}
+
+	diff := backfill.DiffRecords(oldRecords, newRecords)
+
+	fmt.Printf("added\t%d\n", len(diff.Added))
+	for _, r := range diff.Added {
+		fmt.Println("  +", r.URI)
+	}
+	fmt.Printf("removed\t%d\n", len(diff.Removed))
+	for _, r := range diff.Removed {
+		fmt.Println("  -", r.URI)
+	}
+	fmt.Printf("updated\t%d\n", len(diff.Updated))
+	for _, r := range diff.Updated {
+		fmt.Println("  ~", r.URI)
+	}
+
+	return nil
+}
+
+func readRecordsJSONLines(path string) ([]backfill.Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []backfill.Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var rec backfill.Record
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			return nil, fmt.Errorf("parse line %q: %w", line, err)
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	backfill "github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync"
+)
+
+// runDiscover pages com.atproto.sync.listRepos from a relay or PDS host and
+// appends the DIDs it finds to --out, so a full-network or per-PDS backfill
+// doesn't need an externally prepared DID list. --cursor-file persists the
+// listRepos cursor between invocations so a crawl can be interrupted and
+// resumed without starting over.
+func runDiscover(args []string) error {
+	fs := flag.NewFlagSet("bsky-backfill discover", flag.ContinueOnError)
+	host := fs.String("host", "", "relay or PDS host to crawl, e.g. https://bsky.network (required)")
+	out := fs.String("out", "", "file to append discovered DIDs to, one per line (required)")
+	cursorFile := fs.String("cursor-file", "", "file to persist the listRepos cursor in, so a crawl can resume after a restart")
+	limit := fs.Int("limit", 0, "page size to request from listRepos (0 = server default)")
+	maxPages := fs.Int("max-pages", 0, "stop after this many pages (0 = crawl until the cursor is exhausted)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *host == "" || *out == "" {
+		return fmt.Errorf("discover: --host and --out are both required")
+	}
+
+	cursor := ""
+	if *cursorFile != "" {
+		if b, err := os.ReadFile(*cursorFile); err == nil {
+			cursor = strings.TrimSpace(string(b))
+		}
+	}
+
+	outFile, err := os.OpenFile(*out, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open --out: %w", err)
+	}
+	defer outFile.Close()
+
+	ctx := context.Background()
+	pages := 0
+	total := 0
+	for {
+		repos, nextCursor, err := backfill.ListRepos(ctx, nil, *host, cursor, *limit)
+		if err != nil {
+			return fmt.Errorf("listRepos %s: %w", *host, err)
+		}
+
+		for _, r := range repos {
+			if _, err := fmt.Fprintln(outFile, r.DID); err != nil {
+				return fmt.Errorf("write --out: %w", err)
+			}
+		}
+		total += len(repos)
+		pages++
+
+		cursor = nextCursor
+		if *cursorFile != "" {
+			if err := os.WriteFile(*cursorFile, []byte(cursor), 0o644); err != nil {
+				return fmt.Errorf("write --cursor-file: %w", err)
+			}
+		}
+
+		if cursor == "" {
+			break
+		}
+		if *maxPages > 0 && pages >= *maxPages {
+			break
+		}
+	}
+
+	fmt.Printf("discovered %d DIDs across %d page(s)\n", total, pages)
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import "fmt"
+
+// exitCode classifies why the process is exiting, so wrapper scripts
+// and orchestrators can branch on os.Exit's code instead of parsing
+// stdout/stderr.
+type exitCode int
+
+const (
+	exitSuccess exitCode = 0
+	// exitError is the default for any unclassified runtime error,
+	// matching the exit code this binary has always used.
+	exitError exitCode = 1
+	// exitConfigError means a flag or config combination was invalid
+	// and the run never started.
+	exitConfigError exitCode = 2
+	// exitPartialFailure means the run completed but the fraction of
+	// DIDs that failed exceeded --max-error-rate.
+	exitPartialFailure exitCode = 3
+	// exitAborted means a SIGINT/SIGTERM interrupted the run before it
+	// finished. 130 is the conventional 128+SIGINT shells use.
+	exitAborted exitCode = 130
+)
+
+// classifiedError pairs an error with the exitCode main should exit
+// with for it, for the handful of failure modes an orchestrator needs
+// to tell apart without parsing output.
+type classifiedError struct {
+	code exitCode
+	err  error
+}
+
+func (e *classifiedError) Error() string { return e.err.Error() }
+func (e *classifiedError) Unwrap() error { return e.err }
+
+// configErrorf returns an error classified as exitConfigError.
+func configErrorf(format string, args ...any) error {
+	return &classifiedError{code: exitConfigError, err: fmt.Errorf(format, args...)}
+}
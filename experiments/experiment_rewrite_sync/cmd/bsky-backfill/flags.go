@@ -0,0 +1,205 @@
+package main
+
+import (
+	"flag"
+	"time"
+
+	backfill "github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync"
+)
+
+// cliFlags holds the parsed command-line flags for a single run.
+type cliFlags struct {
+	dids []string
+
+	allowDIDs  string
+	blockDIDs  string
+	allowHosts string
+	blockHosts string
+
+	allowDIDsFile           string
+	allowDIDsReloadInterval time.Duration
+
+	maxInflightPerHost int
+	concurrency        int
+	rateLimit          float64
+	rateLimitState     string
+	registryPath       string
+
+	redisRateLimitAddr   string
+	redisRateLimitKey    string
+	redisRateLimit       int
+	redisRateLimitWindow time.Duration
+
+	recordHTTP string
+	replayHTTP string
+
+	chaosTimeoutRate   float64
+	chaosRateLimitRate float64
+	chaosTruncateRate  float64
+	chaosMalformedRate float64
+
+	cpuProfile string
+	memProfile string
+	trace      string
+
+	outputDir             string
+	encryptionKeyFile     string
+	manifestPath          string
+	resultJSONPath        string
+	dataQualityReportPath string
+	filter                string
+	fields                string
+	partitionBy           string
+	mode                  string
+	commitDeltaFormat     bool
+
+	snapshotDir    string
+	snapshotRetain int
+
+	quietRetries          bool
+	verifyHandles         bool
+	healthAwareScheduling bool
+
+	resolutionMapPath     string
+	loadResolutionMapPath string
+
+	warmUpHosts     bool
+	warmUpTopNHosts int
+
+	dnsCacheTTL time.Duration
+
+	egressCostPerGB float64
+
+	minFreeDiskBytes int64
+	diskCheckEvery   int
+
+	sinkBatchSize     int
+	sinkFlushInterval time.Duration
+
+	asyncSinkWorkers    int
+	asyncQueueCapacity  int
+	asyncOverflowPolicy string
+	asyncSpillDir       string
+
+	runManifestPath string
+	runID           string
+
+	errorWebhookURL string
+
+	errorLogSampleRate int
+
+	quiet    bool
+	jsonOnly bool
+
+	maxErrorRate float64
+
+	maxDuration time.Duration
+
+	maxRecords int64
+	maxBytes   int64
+
+	pdsSyncRateLimit float64
+	appViewRateLimit float64
+	blobRateLimit    float64
+
+	heartbeatInterval time.Duration
+
+	slowDIDMultiple float64
+	slowDIDTopN     int
+
+	timeoutRetryTimeout     time.Duration
+	timeoutRetryConcurrency int
+}
+
+type flagSet struct {
+	*flag.FlagSet
+	cliFlags
+	didFile   string
+	streamDID bool
+}
+
+func newFlagSet() *flagSet {
+	fs := &flagSet{FlagSet: flag.NewFlagSet("bsky-backfill", flag.ContinueOnError)}
+	fs.StringVar(&fs.didFile, "dids", "", "path to a file of newline-separated DIDs to back fill")
+	fs.BoolVar(&fs.streamDID, "stream-dids", false, "stream --dids line-by-line into the run instead of loading the whole file into memory first, for full-network DID lists that exceed available RAM; disables priority ordering (lines are processed in file order) and progress is reported against a pre-counted total")
+	fs.StringVar(&fs.allowDIDs, "allow-did", "", "comma-separated list of DIDs to allow; if set, only these DIDs are processed")
+	fs.StringVar(&fs.allowDIDsFile, "allow-dids-file", "", "path to a newline-delimited DID allowlist, re-read every --allow-dids-reload-interval so cohort changes take effect without restarting the run; checked in addition to --allow-did")
+	fs.DurationVar(&fs.allowDIDsReloadInterval, "allow-dids-reload-interval", 30*time.Second, "how often --allow-dids-file is re-read for changes")
+	fs.StringVar(&fs.blockDIDs, "block-did", "", "comma-separated list of DIDs to exclude")
+	fs.StringVar(&fs.allowHosts, "allow-host", "", "comma-separated list of PDS hosts to allow; if set, only these hosts are processed")
+	fs.StringVar(&fs.blockHosts, "block-host", "", "comma-separated list of PDS hosts to exclude")
+	fs.IntVar(&fs.maxInflightPerHost, "max-inflight-per-host", 0, "cap concurrent requests to any single PDS host (0 = unlimited)")
+	fs.IntVar(&fs.concurrency, "concurrency", backfill.DetectCPULimit(), "cap how many DIDs are processed at once overall; defaults to the detected (cgroup-aware) CPU limit, 0 = unlimited")
+	fs.Float64Var(&fs.rateLimit, "rate-limit", 0, "cap PLC directory lookups to this many per second across the whole run (0 = unlimited)")
+	fs.Float64Var(&fs.pdsSyncRateLimit, "pds-sync-rate-limit", 0, "cap PDS repo-sync requests to this many per second, independent of --rate-limit's PLC budget (0 = unlimited; reserved for the repo-fetching stage, not yet implemented)")
+	fs.Float64Var(&fs.appViewRateLimit, "app-view-rate-limit", 0, "cap AppView requests to this many per second, independent of --rate-limit's PLC budget (0 = unlimited; reserved for the AppView stage, not yet implemented)")
+	fs.Float64Var(&fs.blobRateLimit, "blob-rate-limit", 0, "cap blob download requests to this many per second, independent of --rate-limit's PLC budget (0 = unlimited; reserved for the blob-download stage, not yet implemented)")
+	fs.StringVar(&fs.rateLimitState, "rate-limit-state", "", "path to persist the rate limiter's token level across restarts (requires --rate-limit)")
+	fs.StringVar(&fs.registryPath, "registry", "", "path to a JSON registry file tracking each DID's last sync status and record count; created if missing")
+	fs.StringVar(&fs.redisRateLimitAddr, "redis-rate-limit-addr", "", "Redis host:port backing a rate limit shared across processes; if set, takes priority over --rate-limit")
+	fs.StringVar(&fs.redisRateLimitKey, "redis-rate-limit-key", "bsky-backfill:ratelimit", "Redis key namespacing the shared counter; every process sharing a budget must use the same key")
+	fs.IntVar(&fs.redisRateLimit, "redis-rate-limit", 0, "max requests per --redis-rate-limit-window across every process sharing --redis-rate-limit-key")
+	fs.DurationVar(&fs.redisRateLimitWindow, "redis-rate-limit-window", time.Minute, "window duration for --redis-rate-limit")
+	fs.StringVar(&fs.recordHTTP, "record-http", "", "record all HTTP exchanges as a cassette under this directory")
+	fs.StringVar(&fs.replayHTTP, "replay-http", "", "replay HTTP exchanges from a cassette under this directory instead of hitting the network")
+	fs.Float64Var(&fs.chaosTimeoutRate, "chaos-timeout-rate", 0, "probability [0,1] of injecting a simulated PDS timeout per request")
+	fs.Float64Var(&fs.chaosRateLimitRate, "chaos-rate-limit-rate", 0, "probability [0,1] of injecting a simulated 429 per request")
+	fs.Float64Var(&fs.chaosTruncateRate, "chaos-truncate-rate", 0, "probability [0,1] of truncating a response body")
+	fs.Float64Var(&fs.chaosMalformedRate, "chaos-malformed-block-rate", 0, "probability [0,1] of corrupting a response body")
+	fs.StringVar(&fs.cpuProfile, "cpuprofile", "", "write a CPU profile to this file for the duration of the run")
+	fs.StringVar(&fs.memProfile, "memprofile", "", "write a heap profile to this file after the run completes")
+	fs.StringVar(&fs.trace, "trace", "", "write an execution trace to this file for the duration of the run")
+	fs.StringVar(&fs.outputDir, "output-dir", "", "write one JSON-lines shard per DID under this directory instead of discarding records")
+	fs.StringVar(&fs.encryptionKeyFile, "encryption-key-file", "", "path to a 16/24/32-byte AES key; if set, shards under --output-dir are encrypted at rest")
+	fs.StringVar(&fs.manifestPath, "manifest", "", "write a manifest JSON (per-shard size, sha256, record count) to this path after the run (requires --output-dir)")
+	fs.StringVar(&fs.resultJSONPath, "result-json", "", "write a JSON report of every DID's outcome plus per-PDS-host aggregates (DIDs, records, bytes, error rate, P95 latency) to this path after the run")
+	fs.StringVar(&fs.dataQualityReportPath, "data-quality-report", "", "write a data-quality report (duplicate URIs, missing/future-dated createdAt, invalid UTF-8 text, per-field null rates) to this path after the run, conventionally next to --manifest")
+	fs.StringVar(&fs.filter, "filter", "", `only write records matching this expression, e.g. collection == "app.bsky.feed.post" && record.text.contains("climate")`)
+	fs.StringVar(&fs.fields, "fields", "", "comma-separated list of fields to keep per record (e.g. did,uri,createdAt,text), dropping the rest")
+	fs.StringVar(&fs.partitionBy, "partition-by", "did", `how --output-dir shards output: "did" (default, one shard per DID) or "collection" (one shard per app.bsky.* collection across all DIDs)`)
+	fs.StringVar(&fs.mode, "mode", "", `output mode: "" (default, full records) or "ml-text" (emit only uri, did, created_at, text, langs, reply_root for app.bsky.feed.post, deduplicated, 10k records per shard)`)
+	fs.BoolVar(&fs.commitDeltaFormat, "commit-delta-format", false, `write records (and tombstones, if any) as {did, rev, seq, op, uri, cid, record} instead of flattening to bare records, so consumers can reconstruct exact repo evolution; rev and seq are currently always empty/zero, since this tool only sees point-in-time snapshots`)
+	fs.StringVar(&fs.snapshotDir, "snapshot-dir", "", "write --output-dir's shards into a timestamped subdirectory of this path instead, and point a \"latest\" symlink at the most recent successful run")
+	fs.IntVar(&fs.snapshotRetain, "snapshot-retain", 0, "with --snapshot-dir, keep only this many most-recent snapshots, deleting older ones (0 = keep all)")
+	fs.BoolVar(&fs.quietRetries, "quiet-retries", false, "don't print the retry/failure-by-status-code report to stderr after the run")
+	fs.BoolVar(&fs.verifyHandles, "verify-handles", false, "verify each resolved handle's DNS TXT or .well-known record actually points back at its DID, flagging spoofed handles")
+	fs.BoolVar(&fs.healthAwareScheduling, "health-aware-scheduling", false, "continuously rank PDS hosts by recent latency/error rate and defer an unhealthy host's remaining DIDs instead of interleaving them with healthy traffic")
+	fs.StringVar(&fs.resolutionMapPath, "write-resolution-map", "", "write every resolved DID's (pds, handle) to this path as JSON after the resolve phase, for reuse with --load-resolution-map")
+	fs.StringVar(&fs.loadResolutionMapPath, "load-resolution-map", "", "load a resolution map written by --write-resolution-map instead of resolving --dids against the PLC directory")
+	fs.BoolVar(&fs.warmUpHosts, "warm-hosts", false, "pre-establish a connection to the busiest PDS hosts before starting workers, to avoid paying a fresh TLS handshake on their first real request")
+	fs.IntVar(&fs.warmUpTopNHosts, "warm-top-n-hosts", 0, "with --warm-hosts, only warm this many of the busiest hosts by DID count (0 = every distinct host)")
+	fs.DurationVar(&fs.dnsCacheTTL, "dns-cache-ttl", 0, "cache PDS hostname lookups for this long instead of resolving on every connection (0 = disabled)")
+	fs.Float64Var(&fs.egressCostPerGB, "egress-cost-per-gb", 0, "with --result-json, estimate egress cost in USD at this rate per GB downloaded (0 = omit the estimate)")
+	fs.Int64Var(&fs.minFreeDiskBytes, "min-free-disk-bytes", 0, "with --output-dir, abort cleanly once free space on the output volume drops below this many bytes (0 = disabled, Linux only)")
+	fs.IntVar(&fs.diskCheckEvery, "disk-check-every", 50, "with --min-free-disk-bytes, check free space every this many writes instead of on every write")
+	fs.IntVar(&fs.sinkBatchSize, "sink-batch-size", 0, "with --output-dir, buffer this many records per shard before flushing to disk instead of flushing every record (0 = flush every record)")
+	fs.DurationVar(&fs.sinkFlushInterval, "sink-flush-interval", 0, "with --output-dir, flush buffered records at least this often even if --sink-batch-size hasn't been reached (0 = disabled)")
+	fs.IntVar(&fs.asyncSinkWorkers, "async-sink-workers", 0, "write records to the sink from this many background goroutines instead of the fetch workers themselves, so a slow write can't stall fetching (0 = disabled, writes happen synchronously)")
+	fs.IntVar(&fs.asyncQueueCapacity, "async-queue-capacity", 64, "with --async-sink-workers, how many records may be queued for the background writers before --async-overflow-policy kicks in")
+	fs.StringVar(&fs.asyncOverflowPolicy, "async-overflow-policy", "block", `with --async-sink-workers, what to do once the queue is full: "block" (default, apply backpressure to fetching), "drop-oldest", or "spill-to-disk" (requires --async-spill-dir)`)
+	fs.StringVar(&fs.asyncSpillDir, "async-spill-dir", "", `directory overflowed records are appended to as JSON lines when --async-overflow-policy=spill-to-disk`)
+	fs.StringVar(&fs.runManifestPath, "run-manifest", "", "write a run manifest (config, binary version/git SHA, input file hash, start/end time, result summary) to this path after the run, for reproducing a published dataset later")
+	fs.StringVar(&fs.runID, "run-id", "", "tag every log line, output file, and the run manifest with this run id instead of a randomly generated one, for correlating a rerun with its original")
+	fs.StringVar(&fs.errorWebhookURL, "error-webhook-url", "", "POST a JSON event to this URL on every panic and DID-stuck hook, plus an aggregated summary per error class at the end of the run, so unattended runs surface failures instead of going unnoticed until someone checks the logs")
+	fs.IntVar(&fs.errorLogSampleRate, "error-log-sample-rate", 0, "when printing per-DID outcomes, log only every Nth error of a given class (plus the first) and print aggregate counts per class instead, so an error storm doesn't produce one line per failure (0 = log every error, no sampling)")
+	fs.BoolVar(&fs.quiet, "quiet", false, "suppress the per-DID outcome lines and the retry/warm-up progress reports; errors and --json output are unaffected")
+	fs.BoolVar(&fs.jsonOnly, "json", false, "print the final result report as JSON to stdout instead of (or in addition to) --result-json, so the binary composes in shell pipelines without needing a file")
+	fs.Float64Var(&fs.maxErrorRate, "max-error-rate", 0, "exit with a distinct partial-failure exit code if more than this fraction of DIDs fail (e.g. 0.05 for 5%); 0 disables the check")
+	fs.DurationVar(&fs.maxDuration, "max-duration", 0, "stop starting new work and shut down cleanly once the run has been going this long, so a job scheduled in a fixed maintenance window never overruns it (0 = unlimited); DIDs already synced remain recorded in --registry")
+	fs.Int64Var(&fs.maxRecords, "max-records", 0, "stop starting new DIDs once this many records have been fetched across the run, useful for a bounded pilot collection (0 = unlimited); DIDs already in flight are allowed to finish")
+	fs.Int64Var(&fs.maxBytes, "max-bytes", 0, "stop starting new DIDs once this many bytes have been fetched across the run, useful in a storage-quota-limited environment (0 = unlimited); DIDs already in flight are allowed to finish")
+	fs.DurationVar(&fs.heartbeatInterval, "heartbeat-interval", 0, "print a heartbeat line to stderr on this interval with the current in-flight DID count and the oldest in-flight DID's age, even if no DIDs have completed recently, so a quiet-but-alive run doesn't look hung (0 = disabled)")
+	fs.Float64Var(&fs.slowDIDMultiple, "slow-did-multiple", 0, "flag a DID as slow once its processing time exceeds this multiple of the run's median, and list the slowest in the result summary, to guide --max-duration/host-exclusion tuning (0 = disabled)")
+	fs.IntVar(&fs.slowDIDTopN, "slow-did-top-n", 10, "with --slow-did-multiple, list at most this many of the slowest flagged DIDs in the result summary")
+	fs.DurationVar(&fs.timeoutRetryTimeout, "timeout-retry-timeout", 0, "once the run finishes, retry every DID that failed with a PDS timeout one more time using this per-request timeout instead of the default, to recover large legitimate repos the default timeout killed (0 = disabled)")
+	fs.IntVar(&fs.timeoutRetryConcurrency, "timeout-retry-concurrency", 1, "with --timeout-retry-timeout, cap how many timed-out DIDs are retried at once, independent of --concurrency (lower concurrency gives each retry more of the host's attention)")
+	return fs
+}
+
+func (fs *flagSet) Parse(args []string) error {
+	if err := fs.FlagSet.Parse(args); err != nil {
+		return err
+	}
+	fs.dids = fs.FlagSet.Args()
+	return nil
+}
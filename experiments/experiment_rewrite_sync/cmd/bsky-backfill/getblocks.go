@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	backfill "github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync"
+)
+
+// runGetBlocks fetches only the given CIDs from a repo via getBlocks,
+// for pulling down a known delta (e.g. from firehose ops) without a full
+// repo download.
+func runGetBlocks(args []string) error {
+	fs := flag.NewFlagSet("bsky-backfill get-blocks", flag.ContinueOnError)
+	did := fs.String("did", "", "DID whose repo to fetch blocks from (required)")
+	pdsEndpoint := fs.String("pds", "", "PDS endpoint to fetch from (required)")
+	out := fs.String("out", "", "path to write the resulting CAR file to (required)")
+	blockstoreDir := fs.String("blockstore-dir", "", "if set, cache fetched blocks on disk here and reuse them on a later call with the same --did/--pds/CIDs")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cids := fs.Args()
+	if *did == "" || *pdsEndpoint == "" || *out == "" || len(cids) == 0 {
+		return fmt.Errorf("get-blocks: --did, --pds, --out, and at least one positional CID are all required")
+	}
+
+	var blockstore *backfill.Blockstore
+	if *blockstoreDir != "" {
+		bs, err := backfill.NewBlockstore(*blockstoreDir)
+		if err != nil {
+			return err
+		}
+		blockstore = bs
+	}
+
+	n, err := backfill.GetBlocks(context.Background(), nil, *pdsEndpoint, *did, cids, *out, blockstore)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("wrote %d bytes to %s\n", n, *out)
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	backfill "github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync"
+)
+
+// runGetRecord fetches a handful of individual records by AT-URI instead of
+// a whole repo, for spot-checking or repairing specific rows in the dataset.
+func runGetRecord(args []string) error {
+	fs := flag.NewFlagSet("bsky-backfill get-record", flag.ContinueOnError)
+	uriFile := fs.String("uris", "", "path to a file of newline-separated AT-URIs to fetch (required unless positional args are given)")
+	pdsEndpoint := fs.String("pds", "", "PDS endpoint to fetch from, e.g. https://morel.us-east.host.bsky.network (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *pdsEndpoint == "" {
+		return fmt.Errorf("get-record: --pds is required")
+	}
+
+	uris := fs.Args()
+	if *uriFile != "" {
+		fileURIs, err := readLines(*uriFile)
+		if err != nil {
+			return fmt.Errorf("read --uris file: %w", err)
+		}
+		uris = append(uris, fileURIs...)
+	}
+	if len(uris) == 0 {
+		return fmt.Errorf("get-record: no AT-URIs given (pass --uris or positional args)")
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	for _, uri := range uris {
+		rec, err := backfill.GetRecord(context.Background(), nil, *pdsEndpoint, uri)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "bsky-backfill: %s: %v\n", uri, err)
+			continue
+		}
+		if err := enc.Encode(rec); err != nil {
+			return fmt.Errorf("write record %s: %w", uri, err)
+		}
+	}
+
+	return nil
+}
+
+func readLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
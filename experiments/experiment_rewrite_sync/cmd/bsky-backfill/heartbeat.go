@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	backfill "github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync"
+)
+
+// heartbeatLogHooks prints a heartbeat line to stderr on OnHeartbeat, so a
+// --heartbeat-interval run keeps producing log output during a long
+// stretch with few or no completed DIDs instead of going quiet in a way
+// that's indistinguishable from hung.
+type heartbeatLogHooks struct {
+	backfill.NoopHooks
+	quiet bool
+}
+
+func (h *heartbeatLogHooks) OnHeartbeat(inFlight int, oldestAge time.Duration) {
+	if h.quiet {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "bsky-backfill: heartbeat: %d in flight, oldest running %s\n", inFlight, oldestAge.Round(time.Second))
+}
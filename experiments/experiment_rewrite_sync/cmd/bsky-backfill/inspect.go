@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	backfill "github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync"
+)
+
+// runInspect prints a CAR file's commit header, MST stats, and per-
+// collection record counts, and optionally dumps one record by rkey — for
+// debugging an archived repo without writing a throwaway script.
+func runInspect(args []string) error {
+	fs := flag.NewFlagSet("bsky-backfill inspect", flag.ContinueOnError)
+	path := fs.String("car", "", "path to the CAR file to inspect (required)")
+	decodeWorkers := fs.Int("decode-workers", 0, "number of goroutines decoding blocks concurrently (0 = use every detected CPU)")
+	rkey := fs.String("rkey", "", "also dump the record whose key ends in /rkey, as indented JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *path == "" {
+		return fmt.Errorf("inspect: --car is required")
+	}
+
+	blocks, err := backfill.DecodeCARFile(*path, *decodeWorkers)
+	if err != nil {
+		return err
+	}
+
+	meta, err := rootCommitMetadata(*path, blocks)
+	if err != nil {
+		return fmt.Errorf("inspect: commit header: %w", err)
+	}
+	fmt.Printf("commit: did=%s rev=%s commit_time=%s signature_valid=%s\n",
+		meta.DID, meta.Rev, formatCommitTime(meta.CommitTime), formatSignatureValid(meta.SignatureValid))
+
+	entries, stats, err := backfill.WalkMST(blocks, meta.Data)
+	if err != nil {
+		return fmt.Errorf("inspect: walk MST: %w", err)
+	}
+	fmt.Printf("mst: nodes=%d entries=%d depth=%d\n", stats.Nodes, stats.Entries, stats.Depth)
+
+	counts := collectionCounts(entries)
+	collections := make([]string, 0, len(counts))
+	for collection := range counts {
+		collections = append(collections, collection)
+	}
+	sort.Strings(collections)
+	fmt.Println("records per collection:")
+	for _, collection := range collections {
+		fmt.Printf("  %s: %d\n", collection, counts[collection])
+	}
+
+	if *rkey != "" {
+		if err := dumpEntryByRkey(blocks, entries, *rkey); err != nil {
+			return fmt.Errorf("inspect: --rkey %q: %w", *rkey, err)
+		}
+	}
+	return nil
+}
+
+// collectionCounts tallies entries by the collection segment of their MST
+// key ("collection/rkey"), so a malformed key without a "/" is counted
+// under "unknown" rather than panicking or being silently dropped.
+func collectionCounts(entries []backfill.MSTEntry) map[string]int {
+	counts := make(map[string]int)
+	for _, e := range entries {
+		idx := strings.LastIndex(e.Key, "/")
+		collection := "unknown"
+		if idx >= 0 {
+			collection = e.Key[:idx]
+		}
+		counts[collection]++
+	}
+	return counts
+}
+
+// dumpEntryByRkey finds the MST entry whose key ends in "/"+rkey and
+// prints its decoded record value as indented JSON.
+func dumpEntryByRkey(blocks []backfill.DecodedBlock, entries []backfill.MSTEntry, rkey string) error {
+	suffix := "/" + rkey
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Key, suffix) {
+			continue
+		}
+		for _, b := range blocks {
+			if b.Err == nil && bytes.Equal(b.CID, e.CID) {
+				out, err := json.MarshalIndent(b.Value, "", "  ")
+				if err != nil {
+					return fmt.Errorf("encode record %s: %w", e.Key, err)
+				}
+				fmt.Printf("record %s:\n%s\n", e.Key, out)
+				return nil
+			}
+		}
+		return fmt.Errorf("record %s: block %x not found among decoded blocks", e.Key, e.CID)
+	}
+	return fmt.Errorf("no record found with rkey %q", rkey)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	backfill "github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync"
+)
+
+// runLabels fetches moderation labels for the records in --in from
+// --labeler and writes the same records back out with labels attached, so
+// moderation research has a label source to join against.
+func runLabels(args []string) error {
+	fs := flag.NewFlagSet("bsky-backfill labels", flag.ContinueOnError)
+	in := fs.String("in", "", "JSON-lines record file to attach labels to (required)")
+	labelerEndpoint := fs.String("labeler", "", "labeler endpoint to query, e.g. https://mod.bsky.app (required)")
+	sources := fs.String("sources", "", "comma-separated labeler DIDs to restrict results to (empty = all sources on the labeler)")
+	onlyLabels := fs.String("only-labels", "", "comma-separated label values; if set, only records carrying one of these survive")
+	excludeLabels := fs.String("exclude-labels", "", "comma-separated label values to drop records for, e.g. porn,gore; always wins over --only-labels")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *in == "" || *labelerEndpoint == "" {
+		return fmt.Errorf("labels: --in and --labeler are both required")
+	}
+
+	records, err := readRecordsJSONLines(*in)
+	if err != nil {
+		return fmt.Errorf("read --in: %w", err)
+	}
+
+	uriPatterns := make([]string, len(records))
+	for i, r := range records {
+		uriPatterns[i] = r.URI
+	}
+
+	labels, err := backfill.QueryLabels(context.Background(), nil, *labelerEndpoint, uriPatterns, splitCSV(*sources))
+	if err != nil {
+		return fmt.Errorf("query labels: %w", err)
+	}
+
+	labeled := backfill.AttachLabels(records, labels)
+	labeled = backfill.FilterByLabels(labeled, splitCSV(*onlyLabels), splitCSV(*excludeLabels))
+
+	enc := json.NewEncoder(os.Stdout)
+	for _, r := range labeled {
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("write record %s: %w", r.URI, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,507 @@
+// Command bsky-backfill bulk-resolves and fetches Bluesky repos.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	backfill "github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync"
+)
+
+func main() {
+	backfill.SetGOMAXPROCSFromCgroup()
+
+	args := os.Args[1:]
+
+	var err error
+	switch {
+	case len(args) > 0 && args[0] == "bench":
+		err = runBench(args[1:])
+	case len(args) > 0 && args[0] == "compare":
+		err = runCompare(args[1:])
+	case len(args) > 0 && args[0] == "discover":
+		err = runDiscover(args[1:])
+	case len(args) > 0 && args[0] == "resolve":
+		err = runResolve(args[1:])
+	case len(args) > 0 && args[0] == "get-record":
+		err = runGetRecord(args[1:])
+	case len(args) > 0 && args[0] == "get-blocks":
+		err = runGetBlocks(args[1:])
+	case len(args) > 0 && args[0] == "decode-car":
+		err = runDecodeCAR(args[1:])
+	case len(args) > 0 && args[0] == "diff":
+		err = runDiff(args[1:])
+	case len(args) > 0 && args[0] == "labels":
+		err = runLabels(args[1:])
+	case len(args) > 0 && args[0] == "stats":
+		err = runStats(args[1:])
+	case len(args) > 0 && args[0] == "serve":
+		err = runServe(args[1:])
+	case len(args) > 0 && args[0] == "daemon":
+		err = runDaemon(args[1:])
+	case len(args) > 0 && args[0] == "validate":
+		err = runValidate(args[1:])
+	case len(args) > 0 && args[0] == "merge":
+		err = runMerge(args[1:])
+	case len(args) > 0 && args[0] == "convert":
+		err = runConvert(args[1:])
+	case len(args) > 0 && args[0] == "inspect":
+		err = runInspect(args[1:])
+	case len(args) > 0 && args[0] == "dedup":
+		err = runDedup(args[1:])
+	case len(args) > 0 && args[0] == "triage":
+		err = runTriage(args[1:])
+	case len(args) > 0 && args[0] == "install-systemd":
+		err = runInstallSystemd(args[1:])
+	default:
+		err = run(args)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bsky-backfill:", err)
+		code := exitError
+		var ce *classifiedError
+		if errors.As(err, &ce) {
+			code = ce.code
+		}
+		os.Exit(int(code))
+	}
+}
+
+func run(args []string) error {
+	fs := newFlagSet()
+	if err := fs.Parse(args); err != nil {
+		return configErrorf("%w", err)
+	}
+
+	stopProfiling, err := startProfiling(fs.cpuProfile, fs.trace)
+	if err != nil {
+		return err
+	}
+	defer stopProfiling()
+	if fs.memProfile != "" {
+		defer func() {
+			if err := writeMemProfile(fs.memProfile); err != nil {
+				fmt.Fprintln(os.Stderr, "bsky-backfill:", err)
+			}
+		}()
+	}
+
+	dids := toWorkItems(fs.dids)
+	var didStream <-chan backfill.WorkItem
+	var didStreamErrs <-chan error
+	var didStreamTotal int
+	if fs.didFile != "" {
+		if fs.streamDID {
+			total, err := countLines(fs.didFile)
+			if err != nil {
+				return fmt.Errorf("count --dids file: %w", err)
+			}
+			items, errs := streamWorkItems(fs.didFile)
+			didStream = prependWorkItems(dids, items)
+			didStreamErrs = errs
+			didStreamTotal = total + len(dids)
+			dids = nil
+		} else {
+			fileItems, err := readWorkItems(fs.didFile)
+			if err != nil {
+				return fmt.Errorf("read --dids file: %w", err)
+			}
+			dids = append(dids, fileItems...)
+		}
+	}
+
+	cfg := backfill.Config{
+		RunID:                 fs.runID,
+		DIDs:                  dids,
+		DIDStream:             didStream,
+		AllowDIDs:             splitCSV(fs.allowDIDs),
+		BlockDIDs:             splitCSV(fs.blockDIDs),
+		AllowHosts:            splitCSV(fs.allowHosts),
+		BlockHosts:            splitCSV(fs.blockHosts),
+		MaxInFlightPerHost:    fs.maxInflightPerHost,
+		Concurrency:           fs.concurrency,
+		RateLimit:             fs.rateLimit,
+		RateLimitStatePath:    fs.rateLimitState,
+		RegistryPath:          fs.registryPath,
+		VerifyHandles:         fs.verifyHandles,
+		HealthAwareScheduling: fs.healthAwareScheduling,
+		ResolutionMapPath:     fs.resolutionMapPath,
+		LoadResolutionMapPath: fs.loadResolutionMapPath,
+		WarmUpHosts:           fs.warmUpHosts,
+		WarmUpTopNHosts:       fs.warmUpTopNHosts,
+		DNSCacheTTL:           fs.dnsCacheTTL,
+		MaxRecords:            fs.maxRecords,
+		MaxBytes:              fs.maxBytes,
+		PDSSyncRateLimit:      fs.pdsSyncRateLimit,
+		AppViewRateLimit:      fs.appViewRateLimit,
+		BlobRateLimit:         fs.blobRateLimit,
+		HeartbeatInterval:     fs.heartbeatInterval,
+	}
+
+	if fs.timeoutRetryTimeout > 0 {
+		cfg.TimeoutRetry = &backfill.TimeoutRetryConfig{
+			Timeout:     fs.timeoutRetryTimeout,
+			Concurrency: fs.timeoutRetryConcurrency,
+		}
+	}
+
+	if fs.redisRateLimitAddr != "" {
+		cfg.RedisRateLimit = &backfill.RedisRateLimitConfig{
+			Addr:   fs.redisRateLimitAddr,
+			Key:    fs.redisRateLimitKey,
+			Limit:  fs.redisRateLimit,
+			Window: fs.redisRateLimitWindow,
+		}
+	}
+
+	if fs.allowDIDsFile != "" {
+		didSet, stopWatch, err := backfill.WatchDIDSetFile(fs.allowDIDsFile, fs.allowDIDsReloadInterval)
+		if err != nil {
+			return fmt.Errorf("read --allow-dids-file: %w", err)
+		}
+		defer stopWatch()
+		cfg.AllowDIDSet = didSet
+	}
+
+	var transport http.RoundTripper
+	if fs.recordHTTP != "" || fs.replayHTTP != "" {
+		dir, replay := fs.replayHTTP, true
+		if dir == "" {
+			dir, replay = fs.recordHTTP, false
+		}
+		var err error
+		transport, err = backfill.NewCassetteTransport(dir, replay, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	if fs.chaosTimeoutRate > 0 || fs.chaosRateLimitRate > 0 || fs.chaosTruncateRate > 0 || fs.chaosMalformedRate > 0 {
+		transport = backfill.NewChaosTransport(transport, backfill.ChaosConfig{
+			TimeoutRate:        fs.chaosTimeoutRate,
+			RateLimitRate:      fs.chaosRateLimitRate,
+			TruncateRate:       fs.chaosTruncateRate,
+			MalformedBlockRate: fs.chaosMalformedRate,
+		}, 1)
+	}
+
+	if transport != nil {
+		cfg.HTTPClient = &http.Client{Transport: transport}
+	}
+
+	var snapshots *backfill.SnapshotManager
+	var snapshotRunID string
+	if fs.snapshotDir != "" {
+		mgr, err := backfill.NewSnapshotManager(fs.snapshotDir)
+		if err != nil {
+			return err
+		}
+		mgr.Retain = fs.snapshotRetain
+		snapshots = mgr
+
+		snapshotRunID = time.Now().UTC().Format("20060102T150405Z")
+		dir, err := mgr.Dir(snapshotRunID)
+		if err != nil {
+			return err
+		}
+		fs.outputDir = dir
+	}
+
+	var sink *backfill.FileSink
+	var sinkCloser io.Closer
+	var dataQuality *backfill.DataQualitySink
+	switch {
+	case fs.outputDir != "" && fs.mode == "ml-text":
+		if fs.filter != "" || fs.fields != "" || fs.commitDeltaFormat || fs.partitionBy != "" && fs.partitionBy != "did" {
+			return configErrorf("--mode ml-text already fixes its own fields and partitioning; it can't be combined with --filter, --fields, --partition-by, or --commit-delta-format")
+		}
+		mlSink, err := backfill.NewMLTextSink(fs.outputDir, 10000)
+		if err != nil {
+			return err
+		}
+		cfg.Sink = mlSink
+		sinkCloser = mlSink
+	case fs.outputDir != "":
+		var sinkOpts []backfill.FileSinkOption
+		if fs.encryptionKeyFile != "" {
+			key, err := os.ReadFile(fs.encryptionKeyFile)
+			if err != nil {
+				return fmt.Errorf("read --encryption-key-file: %w", err)
+			}
+			sinkOpts = append(sinkOpts, backfill.WithEncryptionKey(key))
+		}
+		switch fs.partitionBy {
+		case "", "did":
+		case "collection":
+			sinkOpts = append(sinkOpts, backfill.WithPartitionKey(backfill.CollectionPartitionKey))
+		default:
+			return configErrorf(`--partition-by %q: must be "did" or "collection"`, fs.partitionBy)
+		}
+		if fs.sinkBatchSize > 0 {
+			sinkOpts = append(sinkOpts, backfill.WithFileSinkBatchSize(fs.sinkBatchSize))
+		}
+		if fs.sinkFlushInterval > 0 {
+			sinkOpts = append(sinkOpts, backfill.WithFileSinkFlushInterval(fs.sinkFlushInterval))
+		}
+		if fs.commitDeltaFormat {
+			sinkOpts = append(sinkOpts, backfill.WithCommitDeltaFormat())
+		}
+
+		var err error
+		sink, err = backfill.NewFileSink(fs.outputDir, sinkOpts...)
+		if err != nil {
+			return err
+		}
+		cfg.Sink = sink
+		sinkCloser = sink
+
+		if fs.filter != "" {
+			expr, err := backfill.CompileFilter(fs.filter)
+			if err != nil {
+				return fmt.Errorf("parse --filter: %w", err)
+			}
+			cfg.Sink = backfill.NewFilterRecordSink(expr, cfg.Sink)
+		}
+
+		if fs.fields != "" {
+			cfg.Sink = backfill.NewProjectSink(splitCSV(fs.fields), cfg.Sink)
+		}
+
+		if fs.minFreeDiskBytes > 0 {
+			cfg.Sink = backfill.NewDiskSpaceGuardSink(fs.outputDir, fs.minFreeDiskBytes, fs.diskCheckEvery, cfg.Sink)
+		}
+	}
+
+	if fs.dataQualityReportPath != "" && cfg.Sink != nil {
+		dataQuality = backfill.NewDataQualitySink(cfg.Sink)
+		cfg.Sink = dataQuality
+	}
+
+	if didStream != nil {
+		cfg.Hooks = &streamProgressHooks{total: int64(didStreamTotal), quiet: fs.quiet}
+	}
+
+	// cfg.Hooks has no composition mechanism, so each assignment below
+	// replaces the last; --error-webhook-url takes priority over the two
+	// above since failure reporting matters more than progress logging.
+	if fs.heartbeatInterval > 0 {
+		cfg.Hooks = &heartbeatLogHooks{quiet: fs.quiet}
+	}
+
+	var webhookReporter *backfill.WebhookReporter
+	if fs.errorWebhookURL != "" {
+		if cfg.RunID == "" {
+			cfg.RunID = backfill.NewRunID()
+		}
+		webhookReporter = backfill.NewWebhookReporter(fs.errorWebhookURL, cfg.RunID)
+		cfg.Hooks = webhookReporter
+	}
+
+	var asyncSink *backfill.AsyncSink
+	if fs.asyncSinkWorkers > 0 && cfg.Sink != nil {
+		var asyncOpts []backfill.AsyncSinkOption
+		switch fs.asyncOverflowPolicy {
+		case "", "block":
+		case "drop-oldest":
+			asyncOpts = append(asyncOpts, backfill.WithOverflowPolicy(backfill.OverflowDropOldest))
+		case "spill-to-disk":
+			asyncOpts = append(asyncOpts, backfill.WithOverflowPolicy(backfill.OverflowSpillToDisk))
+			if fs.asyncSpillDir != "" {
+				asyncOpts = append(asyncOpts, backfill.WithSpillDir(fs.asyncSpillDir))
+			}
+		default:
+			return configErrorf(`--async-overflow-policy %q: must be "block", "drop-oldest", or "spill-to-disk"`, fs.asyncOverflowPolicy)
+		}
+		asyncSink = backfill.NewAsyncSink(cfg.Sink, fs.asyncSinkWorkers, fs.asyncQueueCapacity, asyncOpts...)
+		cfg.Sink = asyncSink
+	}
+
+	ctx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	if fs.maxDuration > 0 {
+		var cancelDeadline context.CancelFunc
+		ctx, cancelDeadline = context.WithTimeout(ctx, fs.maxDuration)
+		defer cancelDeadline()
+	}
+
+	runStart := time.Now()
+	result, err := backfill.Run(ctx, cfg)
+	runEnd := time.Now()
+	aborted := ctx.Err() != nil
+	if aborted && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		fmt.Fprintf(os.Stderr, "bsky-backfill: --max-duration %s elapsed, shutting down; DIDs already synced remain recorded\n", fs.maxDuration)
+	}
+	if webhookReporter != nil {
+		if closeErr := webhookReporter.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	if asyncSink != nil {
+		if closeErr := asyncSink.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	if sinkCloser != nil {
+		if closeErr := sinkCloser.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	if didStreamErrs != nil {
+		if streamErr := <-didStreamErrs; streamErr != nil && err == nil {
+			err = fmt.Errorf("stream --dids file: %w", streamErr)
+		}
+	}
+	if err != nil {
+		if aborted {
+			return &classifiedError{code: exitAborted, err: err}
+		}
+		return err
+	}
+
+	if fs.maxErrorRate > 0 {
+		if rate := errorRate(result.PerDID); rate > fs.maxErrorRate {
+			return &classifiedError{code: exitPartialFailure, err: fmt.Errorf("%.1f%% of DIDs failed, exceeding --max-error-rate %.1f%%", rate*100, fs.maxErrorRate*100)}
+		}
+	}
+
+	if sink != nil && fs.manifestPath != "" {
+		if err := sink.WriteManifest(fs.manifestPath); err != nil {
+			return err
+		}
+	}
+
+	if dataQuality != nil {
+		if err := dataQuality.WriteReport(fs.dataQualityReportPath); err != nil {
+			return err
+		}
+	}
+
+	if snapshots != nil {
+		if err := snapshots.Promote(snapshotRunID); err != nil {
+			return err
+		}
+	}
+
+	if fs.resultJSONPath != "" {
+		if err := backfill.WriteResultReport(result, fs.resultJSONPath, fs.egressCostPerGB); err != nil {
+			return err
+		}
+	}
+
+	if fs.runManifestPath != "" {
+		if err := backfill.WriteRunManifest(cfg, fs.didFile, runStart, runEnd, result, fs.runManifestPath); err != nil {
+			return err
+		}
+	}
+
+	if fs.jsonOnly {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(backfill.NewResultReport(result, fs.egressCostPerGB)); err != nil {
+			return fmt.Errorf("encode --json result report: %w", err)
+		}
+	}
+
+	if !fs.quiet {
+		errorSampler := backfill.NewErrorSampler(fs.errorLogSampleRate)
+		for _, d := range result.PerDID {
+			switch {
+			case d.Err != nil:
+				if errorSampler.ShouldLog(d.Err) {
+					fmt.Printf("%s\t%s\t%s\terror\t%v\n", result.RunID, d.SpanID, d.DID, d.Err)
+				}
+			case d.Filtered:
+				fmt.Printf("%s\t%s\t%s\tskipped\t%s\n", result.RunID, d.SpanID, d.DID, d.Reason)
+			case fs.verifyHandles && d.Handle != "" && !d.HandleVerified:
+				fmt.Printf("%s\t%s\t%s\thandle-mismatch\t%s\n", result.RunID, d.SpanID, d.DID, d.Handle)
+			default:
+				fmt.Printf("%s\t%s\t%s\tresolved\t%s\n", result.RunID, d.SpanID, d.DID, d.PDSHost)
+			}
+		}
+		if fs.errorLogSampleRate > 1 {
+			if report := errorSampler.Report(); report != "" {
+				fmt.Fprint(os.Stderr, report)
+			}
+		}
+
+		if !fs.quietRetries {
+			retryStats := backfill.ComputeRetryStats(result.PerDID)
+			if retryStats.TotalRetries > 0 || retryStats.TotalFailures > 0 {
+				fmt.Fprint(os.Stderr, retryStats.Report())
+			}
+		}
+
+		if fs.slowDIDMultiple > 0 {
+			slowStats := backfill.ComputeSlowDIDStats(result.PerDID, fs.slowDIDMultiple, fs.slowDIDTopN)
+			if report := slowStats.Report(); report != "" {
+				fmt.Fprint(os.Stderr, report)
+			}
+		}
+
+		if fs.warmUpHosts {
+			fmt.Fprintf(os.Stderr, "warm-up: %d handshake(s), %d reused connection(s)\n", result.ConnectionStats.Handshakes, result.ConnectionStats.Reused)
+		}
+
+		if counts := result.CollectionCounts(); len(counts) > 0 {
+			fmt.Fprint(os.Stderr, "collections:")
+			for _, collection := range sortedKeys(counts) {
+				fmt.Fprintf(os.Stderr, " %s=%d", collection, counts[collection])
+			}
+			fmt.Fprintln(os.Stderr)
+		}
+	}
+
+	return nil
+}
+
+// errorRate returns the fraction of results with a non-nil Err, or 0 if
+// results is empty.
+func errorRate(results []backfill.DIDResult) float64 {
+	if len(results) == 0 {
+		return 0
+	}
+	var failed int
+	for _, d := range results {
+		if d.Err != nil {
+			failed++
+		}
+	}
+	return float64(failed) / float64(len(results))
+}
+
+// sortedKeys returns counts' keys in ascending order, for stable,
+// diff-friendly output.
+func sortedKeys(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func splitCSV(s string) []string {
+	if s == "" {
+		return nil
+	}
+	parts := strings.Split(s, ",")
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
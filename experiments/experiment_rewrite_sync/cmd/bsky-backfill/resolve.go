@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"os"
+
+	backfill "github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync"
+)
+
+// runResolve resolves every DID in --input to its (handle, PDS, status)
+// and writes the mapping to --output as CSV, without fetching any repo
+// data — several analyses only need identity mapping, and resolution
+// alone is far cheaper to run at high concurrency than a full backfill.
+func runResolve(args []string) error {
+	fs := flag.NewFlagSet("bsky-backfill resolve", flag.ContinueOnError)
+	input := fs.String("input", "", "path to a file of newline-separated DIDs to resolve (required)")
+	output := fs.String("output", "", "path to write resolved identities as CSV: did,handle,pds_host,status (required)")
+	concurrency := fs.Int("concurrency", backfill.DetectCPULimit(), "cap how many DIDs are resolved at once; defaults to the detected CPU limit, 0 = unlimited")
+	plcDirectoryURL := fs.String("plc-directory", "", "override the PLC directory URL (for tests)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *input == "" || *output == "" {
+		return fmt.Errorf("resolve: --input and --output are both required")
+	}
+
+	items, err := readWorkItems(*input)
+	if err != nil {
+		return fmt.Errorf("read --input: %w", err)
+	}
+
+	cfg := backfill.Config{
+		DIDs:            items,
+		Concurrency:     *concurrency,
+		PLCDirectoryURL: *plcDirectoryURL,
+	}
+	result, err := backfill.Run(context.Background(), cfg)
+	if err != nil {
+		return fmt.Errorf("resolve: %w", err)
+	}
+
+	outFile, err := os.Create(*output)
+	if err != nil {
+		return fmt.Errorf("create --output: %w", err)
+	}
+	defer outFile.Close()
+
+	w := csv.NewWriter(outFile)
+	if err := w.Write([]string{"did", "handle", "pds_host", "status"}); err != nil {
+		return fmt.Errorf("write --output: %w", err)
+	}
+	for _, r := range result.PerDID {
+		status := "ok"
+		if r.Err != nil {
+			status = "error"
+		}
+		if err := w.Write([]string{r.DID, r.Handle, r.PDSHost, status}); err != nil {
+			return fmt.Errorf("write --output: %w", err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("write --output: %w", err)
+	}
+
+	fmt.Printf("resolved %d DID(s) to %s\n", len(result.PerDID), *output)
+	return nil
+}
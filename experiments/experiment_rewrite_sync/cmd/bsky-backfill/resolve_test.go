@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync/backfilltest"
+)
+
+func TestRunResolveWritesCSVOfResolvedIdentities(t *testing.T) {
+	pds := backfilltest.NewPDS(nil)
+	defer pds.Close()
+
+	plc := backfilltest.NewPLCDirectory(map[string]backfilltest.PLCDoc{
+		"did:plc:alice": backfilltest.PDSDoc(pds.URL, "alice.bsky.social"),
+	})
+	defer plc.Close()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "dids.txt")
+	if err := os.WriteFile(input, []byte("did:plc:alice\n"), 0o644); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+	output := filepath.Join(dir, "identities.csv")
+
+	if err := runResolve([]string{"--input", input, "--output", output, "--plc-directory", plc.URL}); err != nil {
+		t.Fatalf("runResolve() error = %v", err)
+	}
+
+	f, err := os.Open(output)
+	if err != nil {
+		t.Fatalf("open output: %v", err)
+	}
+	defer f.Close()
+
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2 (header + 1 DID)", len(rows))
+	}
+	if got := rows[0]; got[0] != "did" || got[1] != "handle" || got[2] != "pds_host" || got[3] != "status" {
+		t.Fatalf("unexpected header: %v", got)
+	}
+	if got := rows[1]; got[0] != "did:plc:alice" || got[1] != "alice.bsky.social" || got[3] != "ok" {
+		t.Fatalf("unexpected row: %v", got)
+	}
+}
+
+func TestRunResolveRequiresInputAndOutput(t *testing.T) {
+	if err := runResolve(nil); err == nil {
+		t.Fatal("runResolve() with no flags, error = nil, want an error")
+	}
+}
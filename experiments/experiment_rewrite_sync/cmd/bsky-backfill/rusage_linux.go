@@ -0,0 +1,21 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// processMaxRSSKB returns the peak resident set size, in KB, of a finished
+// child process. Linux reports ru_maxrss in KB already.
+func processMaxRSSKB(state *os.ProcessState) int64 {
+	if state == nil {
+		return 0
+	}
+	rusage, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok {
+		return 0
+	}
+	return int64(rusage.Maxrss)
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package main
+
+import "os"
+
+// processMaxRSSKB is only implemented on Linux, where ru_maxrss is reported
+// in KB; elsewhere compare just reports 0 rather than guessing at units.
+func processMaxRSSKB(state *os.ProcessState) int64 {
+	return 0
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	backfill "github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync"
+)
+
+// runServe starts the REST job API (see backfill.NewJobServer), for teams
+// that want to submit backfill runs over HTTP instead of invoking this
+// binary directly once per run.
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("bsky-backfill serve", flag.ContinueOnError)
+	addr := fs.String("addr", ":8080", "address to listen on")
+	statePath := fs.String("state", "bsky-backfill-jobs.json", "path to persist job state across restarts")
+	maxConcurrentJobs := fs.Int("max-concurrent-jobs", 4, "how many submitted jobs may run at once")
+	tenantQuotas := fs.String("tenant-quota", "", `comma-separated per-tenant quotas as tenant:maxCalls:maxBytes (0 means unlimited on that dimension), e.g. "acme:100:5000000000,other:50:0"`)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	opts := []backfill.JobServerOption{backfill.WithMaxConcurrentJobs(*maxConcurrentJobs)}
+	quotaOpts, err := parseTenantQuotas(*tenantQuotas)
+	if err != nil {
+		return fmt.Errorf("parse --tenant-quota: %w", err)
+	}
+	opts = append(opts, quotaOpts...)
+
+	srv, err := backfill.NewJobServer(*statePath, opts...)
+	if err != nil {
+		return fmt.Errorf("serve: %w", err)
+	}
+
+	fmt.Printf("bsky-backfill: serving job API on %s (state: %s)\n", *addr, *statePath)
+	return http.ListenAndServe(*addr, srv.Handler())
+}
+
+// parseTenantQuotas parses the --tenant-quota flag's
+// "tenant:maxCalls:maxBytes,..." syntax into JobServerOptions.
+func parseTenantQuotas(s string) ([]backfill.JobServerOption, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var opts []backfill.JobServerOption
+	for _, entry := range strings.Split(s, ",") {
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf(`%q: want "tenant:maxCalls:maxBytes"`, entry)
+		}
+		maxCalls, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("%q: maxCalls: %w", entry, err)
+		}
+		maxBytes, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q: maxBytes: %w", entry, err)
+		}
+		opts = append(opts, backfill.WithTenantQuota(parts[0], maxCalls, maxBytes))
+	}
+	return opts, nil
+}
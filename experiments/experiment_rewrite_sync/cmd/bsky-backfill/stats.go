@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	backfill "github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync"
+)
+
+// runStats scans every JSON-lines shard under --dir (as written by
+// FileSink) and prints per-collection counts, per-DID distribution, a date
+// coverage histogram, and null rates, so dataset QA doesn't need a
+// separate Python script over the output directory.
+func runStats(args []string) error {
+	fs := flag.NewFlagSet("bsky-backfill stats", flag.ContinueOnError)
+	dir := fs.String("dir", "", "output directory to scan (required)")
+	top := fs.Int("top", 10, "how many DIDs/collections to list in each ranking")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *dir == "" {
+		return fmt.Errorf("stats: --dir is required")
+	}
+
+	records, err := readShardDir(*dir)
+	if err != nil {
+		return err
+	}
+
+	s := backfill.ComputeStats(records)
+
+	fmt.Printf("total_records\t%d\n", s.TotalRecords)
+	fmt.Printf("missing_timestamp\t%d\n", s.MissingTimestamp)
+	fmt.Printf("missing_value\t%d\n", s.MissingValue)
+
+	fmt.Println("top_collections:")
+	for _, c := range s.TopCollections(*top) {
+		fmt.Printf("  %s\t%d\n", c, s.PerCollection[c])
+	}
+
+	fmt.Println("top_dids:")
+	for _, d := range s.TopDIDs(*top) {
+		fmt.Printf("  %s\t%d\n", d, s.PerDID[d])
+	}
+
+	fmt.Println("date_coverage:")
+	days := make([]string, 0, len(s.DateCoverage))
+	for day := range s.DateCoverage {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+	for _, day := range days {
+		fmt.Printf("  %s\t%d\n", day, s.DateCoverage[day])
+	}
+
+	return nil
+}
+
+// readShardDir reads every *.jsonl file directly under dir (skipping
+// *.jsonl.tmp shards still being written and any subdirectories) as
+// records.
+func readShardDir(dir string) ([]backfill.Record, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("stats: read %s: %w", dir, err)
+	}
+
+	var records []backfill.Record
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".jsonl") {
+			continue
+		}
+		shardRecords, err := readRecordsJSONLines(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("stats: %w", err)
+		}
+		records = append(records, shardRecords...)
+	}
+	return records, nil
+}
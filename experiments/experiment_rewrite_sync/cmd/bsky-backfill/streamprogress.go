@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	backfill "github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync"
+)
+
+// progressReportEvery controls how often streamProgressHooks prints a
+// progress line, to avoid flooding stderr on a multi-million-DID run.
+const progressReportEvery = 10000
+
+// streamProgressHooks reports progress against a pre-counted total as DIDs
+// complete, for a --stream-dids run over a list too large to show a
+// per-DID outcome line for every single one.
+type streamProgressHooks struct {
+	backfill.NoopHooks
+	total int64
+	done  int64
+	quiet bool
+}
+
+func (h *streamProgressHooks) OnDIDComplete(backfill.DIDResult) {
+	n := atomic.AddInt64(&h.done, 1)
+	if h.quiet {
+		return
+	}
+	if n%progressReportEvery == 0 || n == h.total {
+		fmt.Fprintf(os.Stderr, "bsky-backfill: processed %d/%d DIDs\n", n, h.total)
+	}
+}
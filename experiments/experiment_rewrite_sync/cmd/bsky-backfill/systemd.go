@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// systemdUnitTemplate is a systemd unit for running `bsky-backfill daemon`
+// as a supervised service. Type=notify plus WatchdogSec= rely on the
+// sd_notify readiness and watchdog pings daemon.go's Daemon.Run sends
+// (see backfill.SDNotifier) to let systemd detect both startup failure
+// and a wedged daemon, not just a crashed process.
+const systemdUnitTemplate = `[Unit]
+Description=bsky-backfill scheduled sync daemon
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=notify
+NotifyAccess=main
+ExecStart=%s daemon --config %s --state %s
+WatchdogSec=%d
+Restart=on-failure
+RestartSec=5s
+
+[Install]
+WantedBy=multi-user.target
+`
+
+// runInstallSystemd generates a systemd unit file for running this binary
+// as a `daemon` service, so ops can supervise it with systemctl instead of
+// a cron entry plus a wrapper script.
+func runInstallSystemd(args []string) error {
+	fs := flag.NewFlagSet("bsky-backfill install-systemd", flag.ContinueOnError)
+	configPath := fs.String("config", "", "path to the daemon's --config job file (required)")
+	statePath := fs.String("state", "bsky-backfill-jobs.json", "path to the daemon's --state file")
+	watchdogSec := fs.Int("watchdog-sec", 60, "WatchdogSec= in the generated unit; the daemon pings systemd at half this interval")
+	output := fs.String("output", "", "write the unit file here instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *configPath == "" {
+		return fmt.Errorf("install-systemd: --config is required")
+	}
+
+	exePath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("install-systemd: locate running binary: %w", err)
+	}
+
+	unit := fmt.Sprintf(systemdUnitTemplate, exePath, *configPath, *statePath, *watchdogSec)
+
+	if *output == "" {
+		fmt.Print(unit)
+		return nil
+	}
+	if err := os.WriteFile(*output, []byte(unit), 0644); err != nil {
+		return fmt.Errorf("install-systemd: write --output: %w", err)
+	}
+	fmt.Printf("wrote unit file to %s — install with: sudo cp %s /etc/systemd/system/ && sudo systemctl daemon-reload\n", *output, *output)
+	return nil
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	backfill "github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync"
+)
+
+// runTriage reads a prior run's --result-json report and walks an operator
+// through its failures interactively: page through failure classes,
+// inspect an example error per class, and select DIDs to retry. Selected
+// DIDs are written out one per line, the format --dids reads, so they can
+// be fed straight into a follow-up run's --dids, replacing hand-editing the failed
+// DIDs out of the original DID list.
+func runTriage(args []string) error {
+	fs := flag.NewFlagSet("bsky-backfill triage", flag.ContinueOnError)
+	resultJSONPath := fs.String("result-json", "", "path to a previous run's --result-json report (required)")
+	retryDIDFile := fs.String("retry-did-file", "", "write DIDs selected for retry here, in --dids file format, for a follow-up run (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *resultJSONPath == "" || *retryDIDFile == "" {
+		return fmt.Errorf("triage: --result-json and --retry-did-file are both required")
+	}
+
+	report, err := readResultReport(*resultJSONPath)
+	if err != nil {
+		return fmt.Errorf("triage: %w", err)
+	}
+
+	classes := groupFailuresByErrClass(report.PerDID)
+	if len(classes) == 0 {
+		fmt.Println("no failures in this result report")
+		return nil
+	}
+
+	selected, err := triageLoop(os.Stdin, os.Stdout, classes)
+	if err != nil {
+		return fmt.Errorf("triage: %w", err)
+	}
+	if len(selected) == 0 {
+		fmt.Println("no DIDs selected for retry")
+		return nil
+	}
+
+	if err := writeRetryDIDFile(*retryDIDFile, selected); err != nil {
+		return fmt.Errorf("triage: write --retry-did-file: %w", err)
+	}
+	fmt.Printf("wrote %d DID(s) to %s\n", len(selected), *retryDIDFile)
+	return nil
+}
+
+// readResultReport reads and parses a --result-json report from path.
+func readResultReport(path string) (backfill.ResultReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return backfill.ResultReport{}, fmt.Errorf("read --result-json: %w", err)
+	}
+	var report backfill.ResultReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return backfill.ResultReport{}, fmt.Errorf("parse --result-json: %w", err)
+	}
+	return report, nil
+}
+
+// failureClass is every failed DID sharing one ErrClass, for paging through
+// a result report one category of failure at a time.
+type failureClass struct {
+	Name    string
+	Entries []backfill.DIDResultJSON
+}
+
+// unclassifiedErrClass groups failures whose report entry has an empty
+// ErrClass (see DIDResult.ErrClass), so they're still visible to triage
+// instead of silently dropped. Errors the taxonomy didn't recognize carry
+// the class "unknown" and are grouped under that name instead.
+const unclassifiedErrClass = "unclassified"
+
+// groupFailuresByErrClass groups every failed, unfiltered DID in perDID by
+// ErrClass, sorted by name for a stable paging order.
+func groupFailuresByErrClass(perDID []backfill.DIDResultJSON) []failureClass {
+	byClass := make(map[string][]backfill.DIDResultJSON)
+	for _, d := range perDID {
+		if d.Filtered || d.Err == "" {
+			continue
+		}
+		name := d.ErrClass
+		if name == "" {
+			name = unclassifiedErrClass
+		}
+		byClass[name] = append(byClass[name], d)
+	}
+
+	names := make([]string, 0, len(byClass))
+	for name := range byClass {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	classes := make([]failureClass, 0, len(names))
+	for _, name := range names {
+		classes = append(classes, failureClass{Name: name, Entries: byClass[name]})
+	}
+	return classes
+}
+
+// triageLoop runs the interactive prompt over in/out: list, show <n>,
+// example <n>, select <n>, done, quit. It returns the DIDs accumulated via
+// select once the operator types "done", or an empty slice on "quit".
+func triageLoop(in io.Reader, out io.Writer, classes []failureClass) ([]backfill.DIDResultJSON, error) {
+	fmt.Fprintln(out, "failure triage — commands: list, show <n>, example <n>, select <n>, done, quit")
+	printClassList(out, classes)
+
+	var selected []backfill.DIDResultJSON
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		fields := strings.Fields(strings.TrimSpace(scanner.Text()))
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "list":
+			printClassList(out, classes)
+		case "show":
+			class, err := selectClass(classes, fields)
+			if err != nil {
+				fmt.Fprintln(out, err)
+				continue
+			}
+			for _, d := range class.Entries {
+				fmt.Fprintf(out, "  %s (host=%s): %s\n", d.DID, d.PDSHost, d.Err)
+			}
+		case "example":
+			class, err := selectClass(classes, fields)
+			if err != nil {
+				fmt.Fprintln(out, err)
+				continue
+			}
+			fmt.Fprintf(out, "  %s\n", class.Entries[0].Err)
+		case "select":
+			class, err := selectClass(classes, fields)
+			if err != nil {
+				fmt.Fprintln(out, err)
+				continue
+			}
+			selected = append(selected, class.Entries...)
+			fmt.Fprintf(out, "  selected %d DID(s) from %q (%d total selected)\n", len(class.Entries), class.Name, len(selected))
+		case "done":
+			return selected, scanner.Err()
+		case "quit":
+			return nil, scanner.Err()
+		default:
+			fmt.Fprintf(out, "unrecognized command %q\n", fields[0])
+		}
+	}
+	return selected, scanner.Err()
+}
+
+// printClassList prints every failure class with its index and size, so
+// an operator can page through classes by number.
+func printClassList(out io.Writer, classes []failureClass) {
+	for i, class := range classes {
+		fmt.Fprintf(out, "  [%d] %s (%d DID(s))\n", i, class.Name, len(class.Entries))
+	}
+}
+
+// selectClass parses the numeric index argument common to show, example,
+// and select, and returns the corresponding class.
+func selectClass(classes []failureClass, fields []string) (failureClass, error) {
+	if len(fields) < 2 {
+		return failureClass{}, fmt.Errorf("%s: requires a class index, e.g. %q", fields[0], fields[0]+" 0")
+	}
+	n, err := strconv.Atoi(fields[1])
+	if err != nil || n < 0 || n >= len(classes) {
+		return failureClass{}, fmt.Errorf("%s: %q is not a valid class index", fields[0], fields[1])
+	}
+	return classes[n], nil
+}
+
+// writeRetryDIDFile writes selected as a --dids file: one "did" per line,
+// in the order DIDs were selected.
+func writeRetryDIDFile(path string, selected []backfill.DIDResultJSON) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	for _, d := range selected {
+		fmt.Fprintln(w, d.DID)
+	}
+	return w.Flush()
+}
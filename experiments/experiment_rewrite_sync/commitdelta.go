@@ -0,0 +1,60 @@
+package backfill
+
+import "encoding/json"
+
+// CommitDeltaOp is the operation a CommitDelta represents, mirroring the
+// operations a repo commit can carry for a given record.
+type CommitDeltaOp string
+
+const (
+	CommitDeltaOpCreate CommitDeltaOp = "create"
+	CommitDeltaOpUpdate CommitDeltaOp = "update"
+	CommitDeltaOpDelete CommitDeltaOp = "delete"
+)
+
+// CommitDelta is a single repo-commit-level change: did's repo, at
+// revision Rev, had Op applied to the record at URI. Seq orders deltas
+// the way the AT Protocol's firehose #commit events do; both are left
+// zero when a delta is derived from something that isn't commit-sequenced,
+// such as a listRecords/CAR snapshot (see CommitDeltaFromRecord).
+//
+// CommitDelta is a richer alternative to Record for a sink that needs to
+// reconstruct exact repo evolution rather than a flattened current-state
+// view: a Record only ever says "this currently exists", where a
+// CommitDelta also says what change produced that state.
+type CommitDelta struct {
+	DID    string          `json:"did"`
+	Rev    string          `json:"rev,omitempty"`
+	Seq    int64           `json:"seq,omitempty"`
+	Op     CommitDeltaOp   `json:"op"`
+	URI    string          `json:"uri"`
+	CID    string          `json:"cid,omitempty"`
+	Record json.RawMessage `json:"record,omitempty"`
+}
+
+// CommitDeltaFromRecord builds the CommitDelta for rec as observed in a
+// point-in-time snapshot (listRecords or a CAR file), which carries no
+// commit history: every record a snapshot returns is reported as a
+// create, since there's no prior revision to diff against. Rev and Seq
+// are left zero; a commit-sequenced source would set them from the
+// #commit event that produced the delta.
+func CommitDeltaFromRecord(did string, rec Record) CommitDelta {
+	return CommitDelta{
+		DID:    did,
+		Op:     CommitDeltaOpCreate,
+		URI:    rec.URI,
+		CID:    rec.CID,
+		Record: rec.Value,
+	}
+}
+
+// CommitDeltaFromTombstone builds the delete CommitDelta for t, so a sink
+// using the CommitDelta format gets deletions in the same shape as
+// creates instead of switching between CommitDelta and Tombstone.
+func CommitDeltaFromTombstone(did string, t Tombstone) CommitDelta {
+	return CommitDelta{
+		DID: did,
+		Op:  CommitDeltaOpDelete,
+		URI: t.URI,
+	}
+}
@@ -0,0 +1,66 @@
+package backfill
+
+import (
+	"fmt"
+	"time"
+)
+
+// CommitMetadata is the provenance data carried by a repo's signed commit
+// object (a CAR file's root block): which revision it is, what it
+// superseded, and whether its signature can be trusted — for tracking
+// exactly which version of a repo a dataset was built from, rather than
+// just the records that happened to come out of it.
+//
+// SignatureValid is always nil today: this package resolves a DID's PDS
+// endpoint and handle (see ResolveDID) but doesn't fetch or cache its
+// verification key, so ParseCommitMetadata can report what a commit
+// claims but not yet whether the claim holds.
+type CommitMetadata struct {
+	DID  string
+	Rev  string
+	Prev *CIDLink
+	Data CIDLink
+	Sig  []byte
+
+	// CommitTime is derived from Rev, itself a TID (see ParseTID); nil if
+	// Rev isn't a valid TID.
+	CommitTime *time.Time
+
+	SignatureValid *bool
+}
+
+// ParseCommitMetadata extracts a CommitMetadata from v, the decoded
+// DAG-CBOR value of a repo's root commit block (as DecodeDAGCBORValue
+// would decode it). It returns an error if v isn't a map or is missing
+// the "did", "rev", or "data" fields every signed commit carries.
+func ParseCommitMetadata(v any) (CommitMetadata, error) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return CommitMetadata{}, fmt.Errorf("parse commit metadata: not a map (got %T)", v)
+	}
+
+	did, ok := m["did"].(string)
+	if !ok {
+		return CommitMetadata{}, fmt.Errorf(`parse commit metadata: missing or non-string "did"`)
+	}
+	rev, ok := m["rev"].(string)
+	if !ok {
+		return CommitMetadata{}, fmt.Errorf(`parse commit metadata: missing or non-string "rev"`)
+	}
+	data, ok := m["data"].(CIDLink)
+	if !ok {
+		return CommitMetadata{}, fmt.Errorf(`parse commit metadata: missing or non-link "data"`)
+	}
+
+	meta := CommitMetadata{DID: did, Rev: rev, Data: data}
+	if prev, ok := m["prev"].(CIDLink); ok {
+		meta.Prev = &prev
+	}
+	if sig, ok := m["sig"].([]byte); ok {
+		meta.Sig = sig
+	}
+	if ts, err := ParseTID(rev); err == nil {
+		meta.CommitTime = &ts
+	}
+	return meta, nil
+}
@@ -0,0 +1,91 @@
+package backfill
+
+import "testing"
+
+func TestParseCommitMetadataExtractsFields(t *testing.T) {
+	data := CIDLink([]byte("data-cid"))
+	prev := CIDLink([]byte("prev-cid"))
+	sig := []byte("sig-bytes")
+
+	v := map[string]any{
+		"did":  "did:plc:alice",
+		"rev":  "3juj6qj52cl22",
+		"data": data,
+		"prev": prev,
+		"sig":  sig,
+	}
+
+	meta, err := ParseCommitMetadata(v)
+	if err != nil {
+		t.Fatalf("ParseCommitMetadata() error = %v", err)
+	}
+	if meta.DID != "did:plc:alice" || meta.Rev != "3juj6qj52cl22" {
+		t.Errorf("got did=%q rev=%q, want did:plc:alice / 3juj6qj52cl22", meta.DID, meta.Rev)
+	}
+	if string(meta.Data) != "data-cid" {
+		t.Errorf("got data cid %q, want data-cid", meta.Data)
+	}
+	if meta.Prev == nil || string(*meta.Prev) != "prev-cid" {
+		t.Errorf("got prev %v, want prev-cid", meta.Prev)
+	}
+	if string(meta.Sig) != "sig-bytes" {
+		t.Errorf("got sig %q, want sig-bytes", meta.Sig)
+	}
+	if meta.CommitTime == nil {
+		t.Errorf("got nil CommitTime for a valid TID rev")
+	}
+	if meta.SignatureValid != nil {
+		t.Errorf("got SignatureValid %v, want nil (not yet verified)", meta.SignatureValid)
+	}
+}
+
+func TestParseCommitMetadataOmitsPrevWhenGenesisCommit(t *testing.T) {
+	v := map[string]any{
+		"did":  "did:plc:alice",
+		"rev":  "3juj6qj52cl22",
+		"data": CIDLink([]byte("data-cid")),
+	}
+
+	meta, err := ParseCommitMetadata(v)
+	if err != nil {
+		t.Fatalf("ParseCommitMetadata() error = %v", err)
+	}
+	if meta.Prev != nil {
+		t.Errorf("got Prev %v, want nil for a genesis commit", meta.Prev)
+	}
+}
+
+func TestParseCommitMetadataRejectsNonMap(t *testing.T) {
+	if _, err := ParseCommitMetadata("not a map"); err == nil {
+		t.Error("ParseCommitMetadata() error = nil, want error for non-map input")
+	}
+}
+
+func TestParseCommitMetadataRejectsMissingRequiredFields(t *testing.T) {
+	cases := []map[string]any{
+		{"rev": "3juj6qj52cl22", "data": CIDLink([]byte("cid"))},
+		{"did": "did:plc:alice", "data": CIDLink([]byte("cid"))},
+		{"did": "did:plc:alice", "rev": "3juj6qj52cl22"},
+	}
+	for _, v := range cases {
+		if _, err := ParseCommitMetadata(v); err == nil {
+			t.Errorf("ParseCommitMetadata(%v) error = nil, want error for missing field", v)
+		}
+	}
+}
+
+func TestParseCommitMetadataLeavesCommitTimeNilForNonTIDRev(t *testing.T) {
+	v := map[string]any{
+		"did":  "did:plc:alice",
+		"rev":  "not-a-tid",
+		"data": CIDLink([]byte("data-cid")),
+	}
+
+	meta, err := ParseCommitMetadata(v)
+	if err != nil {
+		t.Fatalf("ParseCommitMetadata() error = %v", err)
+	}
+	if meta.CommitTime != nil {
+		t.Errorf("got CommitTime %v, want nil for a non-TID rev", meta.CommitTime)
+	}
+}
@@ -0,0 +1,231 @@
+package backfill
+
+import (
+	"net/http"
+	"time"
+)
+
+// WorkItem is a single DID to back fill, with an optional priority used to
+// order the run. Higher Priority values are processed first; items that
+// don't specify one default to 0 and are processed in input order relative
+// to each other.
+type WorkItem struct {
+	DID      string
+	Priority int
+}
+
+// Config controls a single backfill run: which DIDs to resolve and fetch,
+// and which of them to skip.
+type Config struct {
+	// DIDs is the input set of actor DIDs to back fill.
+	DIDs []WorkItem
+
+	// DIDStream, if set, supplies work items in arrival order instead of
+	// DIDs, for input sets too large to hold in memory as a single slice
+	// (e.g. a full-network DID list streamed line-by-line from a file).
+	// DIDs is ignored when this is set. Priority ordering doesn't apply:
+	// items are resolved in the order they arrive on the channel, since
+	// honoring WorkItem.Priority would require buffering the whole
+	// stream to find the highest-priority item first.
+	DIDStream <-chan WorkItem
+
+	// AllowDIDs, if non-empty, restricts the run to only these DIDs (after
+	// resolution). BlockDIDs is applied on top of AllowDIDs and always wins.
+	AllowDIDs []string
+	BlockDIDs []string
+
+	// AllowDIDSet, if set, restricts the run the same way AllowDIDs does,
+	// except its membership can change while a run is using it (see
+	// WatchDIDSetFile) — for a long-lived consumer whose cohort is
+	// updated out-of-band rather than fixed for the run's lifetime.
+	// Checked in addition to AllowDIDs, not instead of it.
+	AllowDIDSet *DIDSet
+
+	// AllowHosts and BlockHosts filter by the PDS host a DID resolves to,
+	// e.g. "bsky.social" or a self-hosted PDS hostname. BlockHosts always
+	// wins over AllowHosts.
+	AllowHosts []string
+	BlockHosts []string
+
+	// MaxInFlightPerHost caps concurrent requests against any single PDS
+	// host, independent of overall concurrency. Zero means unlimited.
+	MaxInFlightPerHost int
+
+	// MaxRepoSizeBytes, if non-zero, is the largest repo we'll pull down
+	// as a single CAR file. Repos over this size fall back to paging
+	// listRecords for Collections instead of being skipped.
+	MaxRepoSizeBytes int64
+
+	// Collections restricts the listRecords fallback (see
+	// MaxRepoSizeBytes) to these NSIDs, e.g. "app.bsky.feed.post".
+	Collections []string
+
+	// Concurrency bounds how many DIDs are processed at once, overall.
+	// Zero means unlimited (bounded only by MaxInFlightPerHost, if set).
+	// Callers running inside a CPU-limited container should set this from
+	// DetectCPULimit rather than leaving it unbounded, which otherwise
+	// lets goroutine count scale with input size regardless of how many
+	// CPUs are actually available.
+	Concurrency int
+
+	// RateLimit, if non-zero, caps PLC directory lookups to this many per
+	// second across the whole run. See PDSSyncRateLimit, AppViewRateLimit,
+	// and BlobRateLimit for the other endpoint classes: the PLC
+	// directory, PDS hosts, the AppView, and blob storage each publish
+	// their own independent rate limits, so one shared budget either
+	// wastes the generous ones or trips the strict ones.
+	RateLimit float64
+
+	// PDSSyncRateLimit, AppViewRateLimit, and BlobRateLimit cap outbound
+	// requests per second to those endpoint classes, independent of
+	// RateLimit's PLC-directory budget. Zero means unlimited for that
+	// class. Reserved for the repo-fetching, AppView, and blob-download
+	// stages, which aren't implemented by Run yet (see MaxRepoSizeBytes).
+	PDSSyncRateLimit float64
+	AppViewRateLimit float64
+	BlobRateLimit    float64
+
+	// RateLimitStatePath, if set, persists RateLimit's token bucket level
+	// to this path at the end of Run and restores it at the start of the
+	// next Run, so a restart shortly after a crash doesn't get a fresh
+	// full burst and blow through the provider's window limit. Ignored
+	// if RateLimit is zero or RedisRateLimit is set.
+	RateLimitStatePath string
+
+	// RedisRateLimit, if set, enforces a request budget shared across
+	// every backfill process pointed at the same Redis key, instead of
+	// RateLimit's local-to-this-process token bucket. Takes priority
+	// over RateLimit when both are set.
+	RedisRateLimit *RedisRateLimitConfig
+
+	// Sink is the destination for records produced while processing each
+	// DID. Run and RunStream do not fetch repo records and never write to
+	// it.
+	Sink Sink
+
+	// Hooks, if set, is notified of run progress. Nil means no
+	// notifications are sent.
+	Hooks Hooks
+
+	// PLCDirectoryURL overrides the PLC directory used to resolve DIDs.
+	// Empty means the real plc.directory; tests point this at a mock
+	// server.
+	PLCDirectoryURL string
+
+	// HTTPClient, if set, is used for all outbound requests instead of
+	// http.DefaultClient — e.g. to install a cassette transport for
+	// record/replay mode.
+	HTTPClient *http.Client
+
+	// SkipPerDIDResults drops Result.PerDID instead of accumulating one
+	// DIDResult per input DID. Set this for huge runs where retaining a
+	// result for every DID would itself be a meaningful amount of memory;
+	// Hooks.OnDIDComplete still fires for every DID either way.
+	SkipPerDIDResults bool
+
+	// StuckDIDTimeout, if non-zero, bounds how long Run waits for a
+	// single DID's resolution before treating it as stuck: Hooks.OnDIDStuck
+	// fires with a stack dump, the stuck request is canceled, and it's
+	// retried once with a fresh context. Zero disables the watchdog.
+	StuckDIDTimeout time.Duration
+
+	// RegistryPath, if set, opens a RepoRegistry at this path and upserts
+	// an entry for every DID Run processes, recording its outcome and
+	// (once a later stage fetches the repo) its record count. Empty
+	// disables the registry entirely.
+	RegistryPath string
+
+	// VerifyHandles, if set, calls VerifyHandle for every resolved DID
+	// that has a handle and wasn't filtered out, flagging a handle that
+	// doesn't actually resolve back to its claimed DID via
+	// DIDResult.HandleVerified. Off by default since it costs an extra
+	// DNS lookup (and sometimes an HTTP request) per DID.
+	VerifyHandles bool
+
+	// HealthAwareScheduling, if set, replaces the default static
+	// scheduleByHost ordering with a dynamic one that continuously ranks
+	// PDS hosts by recent latency/error rate and prefers sending the next
+	// worker's capacity to whichever host with pending work currently
+	// looks healthiest, deferring an unhealthy host's remaining DIDs
+	// until it recovers or everything else is done. Off by default: the
+	// static ordering is simpler to reason about and is the right choice
+	// when every host is expected to behave similarly.
+	HealthAwareScheduling bool
+
+	// ResolutionMapPath, if set, writes every DID's resolution outcome
+	// (PDS host/endpoint and handle, or the error hit) to this path as
+	// JSON once the resolve phase completes, producing a reusable
+	// artifact for LoadResolutionMapPath.
+	ResolutionMapPath string
+
+	// LoadResolutionMapPath, if set, reads a resolution map previously
+	// written via ResolutionMapPath and uses it as the resolved set
+	// instead of resolving cfg.DIDs against the PLC directory, so a
+	// rerun that only needs to fetch repos (not re-resolve identities)
+	// can skip PLC entirely. AllowDIDs, BlockDIDs, AllowHosts, and
+	// BlockHosts still apply as filters against the loaded set; cfg.DIDs
+	// itself is ignored, since the map already says which DIDs resolved.
+	LoadResolutionMapPath string
+
+	// WarmUpHosts, if set, pre-establishes a connection to the busiest
+	// PDS hosts in the resolved set before starting workers, so their
+	// first real request doesn't pay a fresh TLS handshake. See
+	// WarmHosts.
+	WarmUpHosts bool
+
+	// WarmUpTopNHosts caps how many of the busiest hosts WarmUpHosts
+	// warms, by DID count. Zero (the default) warms every distinct host
+	// in the resolved set.
+	WarmUpTopNHosts int
+
+	// DNSCacheTTL, if non-zero and HTTPClient is unset, routes outbound
+	// requests through an http.Client backed by NewDNSCachingTransport,
+	// so repeated connections to the same PDS hostnames don't hit the
+	// system resolver on every dial. Ignored if HTTPClient is set — an
+	// explicit client's transport is never overridden.
+	DNSCacheTTL time.Duration
+
+	// ResultQueueCapacity sets the buffer size of RunStream's results
+	// channel. Zero (the default) keeps it unbuffered, so a slow
+	// consumer exerts immediate backpressure on the worker pool instead
+	// of letting completed-but-undelivered results accumulate in memory.
+	// Raising it trades that backpressure for smoothing out bursts when
+	// the consumer is usually fast but occasionally stalls. Ignored by
+	// Run, which has no streaming consumer to apply backpressure to.
+	ResultQueueCapacity int
+
+	// RunID identifies this run across logs, metrics, and output files,
+	// so multi-run aggregation can tell which job produced a given line.
+	// Empty (the default) has Run generate one with NewRunID.
+	RunID string
+
+	// MaxRecords, if non-zero, caps the total number of records fetched
+	// across the whole run. Once the cap is reached, DIDs that haven't
+	// started fetching yet are skipped (DIDResult.Filtered, with Reason
+	// describing the budget) rather than fetched, so a bounded pilot
+	// collection or a storage-quota-limited environment stops cleanly
+	// instead of running until cfg.DIDs is exhausted. DIDs already
+	// in flight when the cap is hit are allowed to finish, so the final
+	// total may exceed MaxRecords slightly.
+	MaxRecords int64
+
+	// MaxBytes behaves like MaxRecords but caps total bytes fetched
+	// instead of record count.
+	MaxBytes int64
+
+	// TimeoutRetry, if set, has Run retry every DID that failed with
+	// ErrPDSTimeout once the main pass finishes, using TimeoutRetryConfig's
+	// longer per-request timeout and lower concurrency, so a large
+	// legitimate repo that the default timeout killed gets a real chance
+	// to finish instead of being recorded as a permanent failure. Nil
+	// disables the retry pass.
+	TimeoutRetry *TimeoutRetryConfig
+
+	// HeartbeatInterval, if non-zero, has Run call Hooks.OnHeartbeat on
+	// this interval for the run's duration, reporting how many DIDs are
+	// currently in flight and how long the oldest of them has been
+	// running — so an operator watching logs can tell a run that's
+	// slow but alive apart from one that's hung, even during a stretch
+	// where few or no DIDs finish. Zero disables heartbeats.
+	HeartbeatInterval time.Duration
+}
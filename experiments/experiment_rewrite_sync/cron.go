@@ -0,0 +1,128 @@
+package backfill
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a parsed standard 5-field cron expression (minute hour
+// day-of-month month day-of-week), enough for nightly/hourly refresh
+// schedules. Named schedules ("@daily") and a seconds field aren't
+// supported.
+type CronSchedule struct {
+	minutes, hours, doms, months, dows map[int]bool
+}
+
+var cronFieldRanges = [5]struct{ min, max int }{
+	{0, 59}, // minute
+	{0, 23}, // hour
+	{1, 31}, // day of month
+	{1, 12}, // month
+	{0, 6},  // day of week, 0 = Sunday
+}
+
+// ParseCronSchedule parses a standard 5-field cron expression. Each field
+// accepts "*", a single value, a range ("a-b"), a comma-separated list of
+// any of those, and a "/step" suffix on a value or range.
+func ParseCronSchedule(expr string) (*CronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q: want 5 fields (minute hour dom month dow), got %d", expr, len(fields))
+	}
+
+	parsed := make([]map[int]bool, 5)
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFieldRanges[i].min, cronFieldRanges[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("cron expression %q: field %d: %w", expr, i+1, err)
+		}
+		parsed[i] = set
+	}
+	return &CronSchedule{minutes: parsed[0], hours: parsed[1], doms: parsed[2], months: parsed[3], dows: parsed[4]}, nil
+}
+
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	set := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		valueRange := part
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			valueRange = part[:i]
+		}
+
+		lo, hi := min, max
+		switch {
+		case valueRange == "*":
+			// lo, hi already cover the field's full range.
+		case strings.Contains(valueRange, "-"):
+			bounds := strings.SplitN(valueRange, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, fmt.Errorf("invalid range %q", valueRange)
+			}
+			if hi, err = strconv.Atoi(bounds[1]); err != nil {
+				return nil, fmt.Errorf("invalid range %q", valueRange)
+			}
+		default:
+			v, err := strconv.Atoi(valueRange)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q", valueRange)
+			}
+			lo, hi = v, v
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("value %q out of range [%d,%d]", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
+
+// Next returns the first minute-aligned time strictly after `after` that
+// matches the schedule.
+func (c *CronSchedule) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	// Bound the search so a pathological expression can't loop forever;
+	// six years covers every real calendar edge case (leap years, a Feb
+	// 29 day-of-month that recurs every 4 years) with room to spare.
+	for limit := 0; limit < 6*366*24*60; limit++ {
+		if c.matches(t) {
+			return t
+		}
+		t = t.Add(time.Minute)
+	}
+	return time.Time{}
+}
+
+// matches reports whether t satisfies the schedule. When both
+// day-of-month and day-of-week are restricted (not "*"), cron treats them
+// as an OR, matching either the 1st of the month or every Monday for
+// "0 0 1 * 1", not just days that are both.
+func (c *CronSchedule) matches(t time.Time) bool {
+	if !c.minutes[t.Minute()] || !c.hours[t.Hour()] || !c.months[int(t.Month())] {
+		return false
+	}
+
+	domRestricted := len(c.doms) < cronFieldRanges[2].max
+	dowRestricted := len(c.dows) < cronFieldRanges[4].max+1
+	switch {
+	case domRestricted && dowRestricted:
+		return c.doms[t.Day()] || c.dows[int(t.Weekday())]
+	case domRestricted:
+		return c.doms[t.Day()]
+	case dowRestricted:
+		return c.dows[int(t.Weekday())]
+	default:
+		return true
+	}
+}
@@ -0,0 +1,58 @@
+package backfill
+
+import (
+	"testing"
+	"time"
+)
+
+func mustParseCron(t *testing.T, expr string) *CronSchedule {
+	t.Helper()
+	s, err := ParseCronSchedule(expr)
+	if err != nil {
+		t.Fatalf("ParseCronSchedule(%q) error = %v", expr, err)
+	}
+	return s
+}
+
+func TestParseCronScheduleRejectsWrongFieldCount(t *testing.T) {
+	if _, err := ParseCronSchedule("0 0 * *"); err == nil {
+		t.Error("expected an error for a 4-field expression")
+	}
+}
+
+func TestParseCronScheduleRejectsOutOfRangeValue(t *testing.T) {
+	if _, err := ParseCronSchedule("60 0 * * *"); err == nil {
+		t.Error("expected an error for minute 60")
+	}
+}
+
+func TestCronScheduleNextDailyAtMidnight(t *testing.T) {
+	s := mustParseCron(t, "0 0 * * *")
+	after := time.Date(2026, 8, 9, 13, 45, 0, 0, time.UTC)
+	got := s.Next(after)
+	want := time.Date(2026, 8, 10, 0, 0, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("Next(%v) = %v, want %v", after, got, want)
+	}
+}
+
+func TestCronScheduleNextHourlyStep(t *testing.T) {
+	s := mustParseCron(t, "30 */6 * * *")
+	after := time.Date(2026, 8, 9, 1, 0, 0, 0, time.UTC)
+	got := s.Next(after)
+	want := time.Date(2026, 8, 9, 6, 30, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("Next(%v) = %v, want %v", after, got, want)
+	}
+}
+
+func TestCronScheduleDayOfMonthAndDayOfWeekAreOred(t *testing.T) {
+	// 2026-08-10 is a Monday, and is not the 1st of the month.
+	s := mustParseCron(t, "0 9 1 * 1")
+	after := time.Date(2026, 8, 9, 23, 59, 0, 0, time.UTC)
+	got := s.Next(after)
+	want := time.Date(2026, 8, 10, 9, 0, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("Next(%v) = %v, want %v", after, got, want)
+	}
+}
@@ -0,0 +1,101 @@
+package backfill
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// encryptingWriteCloser wraps an io.WriteCloser and encrypts each Write call
+// as its own AES-GCM sealed chunk, framed as a 4-byte big-endian length
+// prefix followed by a fresh nonce and the ciphertext. AES-GCM has no
+// native streaming mode, so chunking per-call (each call already being one
+// JSON-encoded record, from FileSink's use of json.Encoder) is the simplest
+// construction that still lets us use a fresh nonce per seal.
+type encryptingWriteCloser struct {
+	w    io.WriteCloser
+	aead cipher.AEAD
+}
+
+func newEncryptingWriteCloser(w io.WriteCloser, key []byte) (*encryptingWriteCloser, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("new encrypting writer: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("new encrypting writer: %w", err)
+	}
+	return &encryptingWriteCloser{w: w, aead: aead}, nil
+}
+
+func (e *encryptingWriteCloser) Write(p []byte) (int, error) {
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return 0, fmt.Errorf("encrypt chunk: %w", err)
+	}
+	sealed := e.aead.Seal(nonce, nonce, p, nil)
+
+	var lenBuf [4]byte
+	binary.BigEndian.PutUint32(lenBuf[:], uint32(len(sealed)))
+	if _, err := e.w.Write(lenBuf[:]); err != nil {
+		return 0, fmt.Errorf("encrypt chunk: %w", err)
+	}
+	if _, err := e.w.Write(sealed); err != nil {
+		return 0, fmt.Errorf("encrypt chunk: %w", err)
+	}
+
+	return len(p), nil
+}
+
+func (e *encryptingWriteCloser) Close() error {
+	return e.w.Close()
+}
+
+// DecryptShard reads a shard written by an encrypting FileSink and returns
+// its decrypted chunks in order, for verifying or repairing encrypted
+// output without the Python side needing to know our framing.
+func DecryptShard(r io.Reader, key []byte) ([][]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt shard: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt shard: %w", err)
+	}
+
+	var chunks [][]byte
+	for {
+		var lenBuf [4]byte
+		_, err := io.ReadFull(r, lenBuf[:])
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return chunks, fmt.Errorf("decrypt shard: read length: %w", err)
+		}
+
+		sealed := make([]byte, binary.BigEndian.Uint32(lenBuf[:]))
+		if _, err := io.ReadFull(r, sealed); err != nil {
+			return chunks, fmt.Errorf("decrypt shard: read chunk: %w", err)
+		}
+
+		nonceSize := aead.NonceSize()
+		if len(sealed) < nonceSize {
+			return chunks, fmt.Errorf("decrypt shard: chunk too short")
+		}
+		nonce, ciphertext := sealed[:nonceSize], sealed[nonceSize:]
+
+		plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+		if err != nil {
+			return chunks, fmt.Errorf("decrypt shard: %w", err)
+		}
+		chunks = append(chunks, plaintext)
+	}
+
+	return chunks, nil
+}
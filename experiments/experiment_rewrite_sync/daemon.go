@@ -0,0 +1,148 @@
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ScheduledJob is one cron-scheduled entry in a Daemon: a Name (used for
+// overlap prevention and logging) and a 5-field Cron expression that
+// controls when the Request is submitted.
+type ScheduledJob struct {
+	Name    string
+	Cron    string
+	Request JobRequest
+}
+
+type scheduledJobState struct {
+	job         ScheduledJob
+	schedule    *CronSchedule
+	nextRun     time.Time
+	activeJobID string
+}
+
+// Daemon re-submits a fixed set of ScheduledJobs to a JobServer on their
+// cron schedules (e.g. a nightly incremental refresh of the study
+// cohort), skipping a firing if the previous run of the same
+// ScheduledJob.Name hasn't finished yet rather than piling up concurrent
+// runs of the same job.
+type Daemon struct {
+	server *JobServer
+	states []*scheduledJobState
+
+	// PollInterval controls how often Run checks whether a schedule has
+	// fired. Defaults to time.Minute, matching cron's own resolution.
+	PollInterval time.Duration
+
+	// Now returns the current time; overridable in tests so they don't
+	// depend on wall-clock timing. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// DaemonOption configures a Daemon at construction, before it computes
+// its scheduled jobs' initial firing times.
+type DaemonOption func(*Daemon)
+
+// WithDaemonNow overrides the daemon's clock. Since NewDaemon uses Now to
+// compute each scheduled job's initial nextRun, a clock swapped in after
+// construction (e.g. by assigning to the Now field directly) would leave
+// that first nextRun computed from the real wall clock; tests that need
+// deterministic firing should supply this option instead.
+func WithDaemonNow(now func() time.Time) DaemonOption {
+	return func(d *Daemon) { d.Now = now }
+}
+
+// NewDaemon builds a Daemon over the given scheduled jobs, parsing each
+// one's cron expression up front so a typo fails at startup instead of at
+// the first missed firing.
+func NewDaemon(server *JobServer, jobs []ScheduledJob, opts ...DaemonOption) (*Daemon, error) {
+	d := &Daemon{server: server, PollInterval: time.Minute, Now: time.Now}
+	for _, opt := range opts {
+		opt(d)
+	}
+	now := d.Now()
+	for _, job := range jobs {
+		schedule, err := ParseCronSchedule(job.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("scheduled job %q: %w", job.Name, err)
+		}
+		// If the schedule already matches the current minute (e.g. the
+		// daemon starts at exactly a job's cron time), fire on the very
+		// first tick rather than skipping straight to the following
+		// occurrence: Next returns the first match strictly after now,
+		// which would otherwise push a job that's due right now to its
+		// next recurrence.
+		nextRun := now.Truncate(time.Minute)
+		if !schedule.matches(nextRun) {
+			nextRun = schedule.Next(now)
+		}
+		d.states = append(d.states, &scheduledJobState{
+			job:      job,
+			schedule: schedule,
+			nextRun:  nextRun,
+		})
+	}
+	return d, nil
+}
+
+// Run blocks, submitting scheduled jobs as their cron expressions fire,
+// until ctx is canceled. When running under systemd with Type=notify, it
+// reports readiness on entry and, if WatchdogSec= is configured, pings
+// the watchdog at half that interval so a wedged daemon gets restarted
+// instead of silently dropping every scheduled job.
+func (d *Daemon) Run(ctx context.Context) error {
+	notifier := NewSDNotifier()
+	if err := notifier.Ready(); err != nil {
+		return fmt.Errorf("daemon: %w", err)
+	}
+	defer notifier.Stopping()
+
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+
+	var watchdogC <-chan time.Time
+	if interval, ok := WatchdogInterval(); ok {
+		watchdogTicker := time.NewTicker(interval)
+		defer watchdogTicker.Stop()
+		watchdogC = watchdogTicker.C
+	}
+
+	d.tick()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			d.tick()
+		case <-watchdogC:
+			if err := notifier.Watchdog(); err != nil {
+				return fmt.Errorf("daemon: %w", err)
+			}
+		}
+	}
+}
+
+func (d *Daemon) tick() {
+	now := d.Now()
+	for _, state := range d.states {
+		if now.Before(state.nextRun) {
+			continue
+		}
+		state.nextRun = state.schedule.Next(now)
+
+		if state.activeJobID != "" {
+			if job, ok := d.server.GetJob(state.activeJobID); ok {
+				if job.Status == JobQueued || job.Status == JobRunning {
+					continue // overlap prevention: the previous run is still in flight.
+				}
+			}
+		}
+
+		job, err := d.server.SubmitJob(state.job.Request)
+		if err != nil {
+			continue // e.g. a tenant quota rejection; try again next tick.
+		}
+		state.activeJobID = job.ID
+	}
+}
@@ -0,0 +1,86 @@
+package backfill
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDaemonSubmitsJobWhenScheduleFires(t *testing.T) {
+	srv, err := NewJobServer(filepath.Join(t.TempDir(), "jobs.json"))
+	if err != nil {
+		t.Fatalf("NewJobServer() error = %v", err)
+	}
+
+	now := time.Date(2026, 8, 9, 8, 59, 0, 0, time.UTC)
+	d, err := NewDaemon(srv, []ScheduledJob{
+		{Name: "nightly", Cron: "0 9 * * *", Request: JobRequest{DIDs: []string{"did:plc:alice"}}},
+	}, WithDaemonNow(func() time.Time { return now }))
+	if err != nil {
+		t.Fatalf("NewDaemon() error = %v", err)
+	}
+	d.PollInterval = time.Millisecond
+
+	d.tick() // now is before 09:00, shouldn't fire yet
+	if len(srv.jobs) != 0 {
+		t.Fatalf("tick() submitted a job before the schedule fired")
+	}
+
+	now = now.Add(time.Minute) // now 09:00
+	d.tick()
+	if len(srv.jobs) != 1 {
+		t.Fatalf("tick() at schedule time submitted %d jobs, want 1", len(srv.jobs))
+	}
+}
+
+func TestDaemonSkipsOverlappingRun(t *testing.T) {
+	srv, err := NewJobServer(filepath.Join(t.TempDir(), "jobs.json"), WithMaxConcurrentJobs(1))
+	if err != nil {
+		t.Fatalf("NewJobServer() error = %v", err)
+	}
+	srv.mu.Lock()
+	srv.running = 1 // simulate a slow run occupying the only slot
+	srv.mu.Unlock()
+
+	now := time.Date(2026, 8, 9, 9, 0, 0, 0, time.UTC)
+	d, err := NewDaemon(srv, []ScheduledJob{
+		{Name: "nightly", Cron: "0 9 * * *", Request: JobRequest{DIDs: []string{"did:plc:alice"}}},
+	}, WithDaemonNow(func() time.Time { return now }))
+	if err != nil {
+		t.Fatalf("NewDaemon() error = %v", err)
+	}
+
+	d.tick()
+	if len(srv.jobs) != 1 {
+		t.Fatalf("first tick submitted %d jobs, want 1 (it should queue, not run)", len(srv.jobs))
+	}
+
+	// A day later the schedule fires again; the first run is still
+	// queued (never dispatched, since the only slot is occupied), so the
+	// second firing must be skipped.
+	now = now.AddDate(0, 0, 1)
+	d.tick()
+	if len(srv.jobs) != 1 {
+		t.Errorf("overlapping tick submitted a second job, want the original still in flight")
+	}
+}
+
+func TestDaemonRunStopsOnContextCancel(t *testing.T) {
+	srv, err := NewJobServer(filepath.Join(t.TempDir(), "jobs.json"))
+	if err != nil {
+		t.Fatalf("NewJobServer() error = %v", err)
+	}
+	d, err := NewDaemon(srv, nil)
+	if err != nil {
+		t.Fatalf("NewDaemon() error = %v", err)
+	}
+	d.PollInterval = time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := d.Run(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Run() error = %v, want context.DeadlineExceeded", err)
+	}
+}
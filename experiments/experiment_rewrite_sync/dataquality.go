@@ -0,0 +1,201 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// FieldNullRate is the fraction of scanned records missing or explicitly
+// null for one JSON field.
+type FieldNullRate struct {
+	Field    string  `json:"field"`
+	NullRate float64 `json:"null_rate"`
+}
+
+// DataQualityReport summarizes common data-quality issues across every
+// record a DataQualitySink saw, so a dataset can be spot-checked before
+// publication without a human scanning individual shard files.
+type DataQualityReport struct {
+	TotalRecords int `json:"total_records"`
+
+	// DuplicateURIs are AT-URIs that were written more than once, with
+	// how many times each occurred.
+	DuplicateURIs map[string]int `json:"duplicate_uris,omitempty"`
+
+	// MissingCreatedAt counts records whose JSON value had no "createdAt"
+	// field, an explicitly null one, an empty string, or a non-JSON
+	// value (malformed records count as missing every field).
+	MissingCreatedAt int `json:"missing_created_at"`
+
+	// FutureDatedCreatedAt counts records whose "createdAt" parsed as a
+	// valid RFC3339 timestamp later than the sink's reference time (see
+	// DataQualitySink.Now).
+	FutureDatedCreatedAt int `json:"future_dated_created_at"`
+
+	// InvalidUTF8Text counts records whose "text" field is present,
+	// non-null, and not valid UTF-8.
+	InvalidUTF8Text int `json:"invalid_utf8_text"`
+
+	// FieldNullRates is the null rate of every field observed across the
+	// scanned records, sorted by field name.
+	FieldNullRates []FieldNullRate `json:"field_null_rates,omitempty"`
+}
+
+// DataQualitySink wraps an underlying Sink, passing every record through
+// unchanged while incrementally tallying the data-quality signals
+// DataQualityReport surfaces. Wrap a run's real sink with one of these to
+// get a report as a side effect of the run, instead of paying for a
+// second pass over every record afterward.
+type DataQualitySink struct {
+	sink Sink
+
+	// Now returns the current time, used to flag future-dated createdAt
+	// values. Defaults to time.Now.
+	Now func() time.Time
+
+	mu                 sync.Mutex
+	total              int
+	uriCounts          map[string]int
+	fieldPresentCounts map[string]int
+	missingCreatedAt   int
+	futureCreatedAt    int
+	invalidUTF8Text    int
+}
+
+// NewDataQualitySink creates a DataQualitySink that writes through to
+// sink.
+func NewDataQualitySink(sink Sink) *DataQualitySink {
+	return &DataQualitySink{
+		sink:               sink,
+		Now:                time.Now,
+		uriCounts:          make(map[string]int),
+		fieldPresentCounts: make(map[string]int),
+	}
+}
+
+// Write forwards rec to the wrapped sink and tallies its data-quality
+// signals regardless of whether the wrapped write succeeds, so a report
+// covers every record the run attempted to persist, not just the ones
+// that made it.
+func (s *DataQualitySink) Write(ctx context.Context, did string, rec Record) error {
+	s.observe(rec)
+	return s.sink.Write(ctx, did, rec)
+}
+
+func (s *DataQualitySink) observe(rec Record) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.total++
+	s.uriCounts[rec.URI]++
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(rec.Value, &fields); err != nil {
+		s.missingCreatedAt++
+		return
+	}
+
+	for field, v := range fields {
+		if !isJSONNull(v) {
+			s.fieldPresentCounts[field]++
+		}
+	}
+
+	createdAt, hasCreatedAt := fields["createdAt"]
+	switch {
+	case !hasCreatedAt || isJSONNull(createdAt):
+		s.missingCreatedAt++
+	default:
+		var str string
+		if err := json.Unmarshal(createdAt, &str); err != nil || str == "" {
+			s.missingCreatedAt++
+		} else if t, err := time.Parse(time.RFC3339, str); err == nil && t.After(s.Now()) {
+			s.futureCreatedAt++
+		}
+	}
+
+	if text, ok := fields["text"]; ok && !isJSONNull(text) {
+		// Check the raw JSON bytes, not a decoded string: json.Unmarshal
+		// replaces invalid UTF-8 byte sequences with U+FFFD instead of
+		// erroring, so a string decoded from genuinely invalid input is
+		// always valid UTF-8 by the time utf8.ValidString would see it.
+		if !utf8.Valid(text) {
+			s.invalidUTF8Text++
+		}
+	}
+}
+
+// Report builds a DataQualityReport from everything observed so far. Safe
+// to call mid-run; later writes aren't reflected in a report already
+// returned.
+func (s *DataQualitySink) Report() DataQualityReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report := DataQualityReport{
+		TotalRecords:         s.total,
+		MissingCreatedAt:     s.missingCreatedAt,
+		FutureDatedCreatedAt: s.futureCreatedAt,
+		InvalidUTF8Text:      s.invalidUTF8Text,
+	}
+
+	for uri, count := range s.uriCounts {
+		if count > 1 {
+			if report.DuplicateURIs == nil {
+				report.DuplicateURIs = make(map[string]int)
+			}
+			report.DuplicateURIs[uri] = count
+		}
+	}
+
+	if s.total > 0 {
+		fields := make([]string, 0, len(s.fieldPresentCounts))
+		for field := range s.fieldPresentCounts {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			report.FieldNullRates = append(report.FieldNullRates, FieldNullRate{
+				Field:    field,
+				NullRate: 1 - float64(s.fieldPresentCounts[field])/float64(s.total),
+			})
+		}
+	}
+
+	return report
+}
+
+// WriteReport builds a DataQualityReport (see Report) and writes it as
+// indented JSON to path, conventionally alongside a run's RunManifest.
+func (s *DataQualitySink) WriteReport(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("write data quality report: %w", err)
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s.Report())
+}
+
+// Close closes the wrapped sink if it implements io.Closer, matching the
+// optional-interface pattern used throughout this package (see
+// TombstoneSink).
+func (s *DataQualitySink) Close() error {
+	closer, ok := s.sink.(interface{ Close() error })
+	if !ok {
+		return nil
+	}
+	return closer.Close()
+}
+
+func isJSONNull(raw json.RawMessage) bool {
+	return string(raw) == "null"
+}
@@ -0,0 +1,178 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+type nullSink struct{ err error }
+
+func (s *nullSink) Write(ctx context.Context, did string, rec Record) error { return s.err }
+
+type fakeDataQualityCaptureSink struct{ records []Record }
+
+func (s *fakeDataQualityCaptureSink) Write(ctx context.Context, did string, rec Record) error {
+	s.records = append(s.records, rec)
+	return nil
+}
+
+func jsonValue(t *testing.T, fields map[string]any) json.RawMessage {
+	t.Helper()
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		t.Fatalf("marshal fields: %v", err)
+	}
+	return raw
+}
+
+func TestDataQualitySinkWritesThroughToWrappedSink(t *testing.T) {
+	captured := &fakeDataQualityCaptureSink{}
+	s := NewDataQualitySink(captured)
+	ctx := context.Background()
+
+	rec := Record{URI: "at://did:plc:alice/app.bsky.feed.post/1", Value: jsonValue(t, map[string]any{"createdAt": "2024-01-01T00:00:00Z"})}
+	if err := s.Write(ctx, "did:plc:alice", rec); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if len(captured.records) != 1 || captured.records[0].URI != rec.URI {
+		t.Fatalf("wrapped sink did not receive the record: %+v", captured.records)
+	}
+}
+
+func TestDataQualitySinkReportDuplicateURIs(t *testing.T) {
+	s := NewDataQualitySink(&nullSink{})
+	ctx := context.Background()
+	rec := Record{URI: "at://did:plc:alice/app.bsky.feed.post/1", Value: jsonValue(t, map[string]any{"createdAt": "2024-01-01T00:00:00Z"})}
+
+	for i := 0; i < 2; i++ {
+		if err := s.Write(ctx, "did:plc:alice", rec); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	report := s.Report()
+	if report.TotalRecords != 2 {
+		t.Fatalf("TotalRecords = %d, want 2", report.TotalRecords)
+	}
+	if report.DuplicateURIs[rec.URI] != 2 {
+		t.Fatalf("DuplicateURIs[%q] = %d, want 2", rec.URI, report.DuplicateURIs[rec.URI])
+	}
+}
+
+func TestDataQualitySinkReportMissingCreatedAt(t *testing.T) {
+	s := NewDataQualitySink(&nullSink{})
+	ctx := context.Background()
+
+	cases := []json.RawMessage{
+		jsonValue(t, map[string]any{"text": "hello"}),
+		jsonValue(t, map[string]any{"createdAt": nil}),
+		jsonValue(t, map[string]any{"createdAt": ""}),
+		json.RawMessage(`not json`),
+	}
+	for i, v := range cases {
+		if err := s.Write(ctx, "did:plc:alice", Record{URI: "uri", Value: v}); err != nil {
+			t.Fatalf("Write(%d) error = %v", i, err)
+		}
+	}
+
+	report := s.Report()
+	if report.MissingCreatedAt != len(cases) {
+		t.Fatalf("MissingCreatedAt = %d, want %d", report.MissingCreatedAt, len(cases))
+	}
+}
+
+func TestDataQualitySinkReportFutureDatedCreatedAt(t *testing.T) {
+	s := NewDataQualitySink(&nullSink{})
+	s.Now = func() time.Time { return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) }
+	ctx := context.Background()
+
+	if err := s.Write(ctx, "did:plc:alice", Record{Value: jsonValue(t, map[string]any{"createdAt": "2030-01-01T00:00:00Z"})}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := s.Write(ctx, "did:plc:alice", Record{Value: jsonValue(t, map[string]any{"createdAt": "2023-01-01T00:00:00Z"})}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	report := s.Report()
+	if report.FutureDatedCreatedAt != 1 {
+		t.Fatalf("FutureDatedCreatedAt = %d, want 1", report.FutureDatedCreatedAt)
+	}
+}
+
+func TestDataQualitySinkReportInvalidUTF8Text(t *testing.T) {
+	s := NewDataQualitySink(&nullSink{})
+	ctx := context.Background()
+
+	valid := jsonValue(t, map[string]any{"text": "hello"})
+	if err := s.Write(ctx, "did:plc:alice", Record{Value: valid}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	invalid := []byte(`{"text":"` + "\xff\xfe" + `"}`)
+	if err := s.Write(ctx, "did:plc:alice", Record{Value: invalid}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	report := s.Report()
+	if report.InvalidUTF8Text != 1 {
+		t.Fatalf("InvalidUTF8Text = %d, want 1", report.InvalidUTF8Text)
+	}
+}
+
+func TestDataQualitySinkReportFieldNullRatesHandleLateAppearingFields(t *testing.T) {
+	s := NewDataQualitySink(&nullSink{})
+	ctx := context.Background()
+
+	if err := s.Write(ctx, "did:plc:alice", Record{Value: jsonValue(t, map[string]any{"createdAt": "2024-01-01T00:00:00Z"})}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := s.Write(ctx, "did:plc:alice", Record{Value: jsonValue(t, map[string]any{"createdAt": "2024-01-01T00:00:00Z", "langs": []string{"en"}})}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	report := s.Report()
+	var langsRate, createdAtRate float64
+	found := 0
+	for _, fnr := range report.FieldNullRates {
+		switch fnr.Field {
+		case "langs":
+			langsRate = fnr.NullRate
+			found++
+		case "createdAt":
+			createdAtRate = fnr.NullRate
+			found++
+		}
+	}
+	if found != 2 {
+		t.Fatalf("FieldNullRates missing expected fields: %+v", report.FieldNullRates)
+	}
+	if langsRate != 0.5 {
+		t.Fatalf("langs null rate = %v, want 0.5 (present in only 1 of 2 records)", langsRate)
+	}
+	if createdAtRate != 0 {
+		t.Fatalf("createdAt null rate = %v, want 0 (present in both records)", createdAtRate)
+	}
+}
+
+func TestDataQualitySinkClosePropagatesToWrappedSinkIfCloser(t *testing.T) {
+	dir := t.TempDir()
+	fileSink, err := NewFileSink(dir)
+	if err != nil {
+		t.Fatalf("NewFileSink() error = %v", err)
+	}
+	s := NewDataQualitySink(fileSink)
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+}
+
+func TestDataQualitySinkWritePropagatesWrappedSinkError(t *testing.T) {
+	wantErr := errors.New("boom")
+	s := NewDataQualitySink(&nullSink{err: wantErr})
+	if err := s.Write(context.Background(), "did:plc:alice", Record{Value: jsonValue(t, map[string]any{})}); !errors.Is(err, wantErr) {
+		t.Fatalf("Write() error = %v, want %v", err, wantErr)
+	}
+}
@@ -0,0 +1,134 @@
+package backfill
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DIDSet is a thread-safe, hot-reloadable set of DIDs used to filter a
+// cohort: see Config.AllowDIDSet. Unlike Config.AllowDIDs (a plain slice,
+// fixed for the lifetime of a run), a DIDSet's membership can be swapped
+// out with Store while it's in active use, so a long-lived consumer (a
+// firehose/Jetstream subscription, not yet implemented by this package —
+// see Tombstone) can pick up cohort changes without reconnecting.
+//
+// Reads are lock-free: Contains loads an immutable map built by the most
+// recent Store, so concurrent filtering never blocks on a reload in
+// progress.
+type DIDSet struct {
+	members atomic.Pointer[map[string]struct{}]
+}
+
+// NewDIDSet builds a DIDSet containing dids.
+func NewDIDSet(dids []string) *DIDSet {
+	s := &DIDSet{}
+	s.Store(dids)
+	return s
+}
+
+// Store atomically replaces the set's membership with dids.
+func (s *DIDSet) Store(dids []string) {
+	m := make(map[string]struct{}, len(dids))
+	for _, did := range dids {
+		m[did] = struct{}{}
+	}
+	s.members.Store(&m)
+}
+
+// Contains reports whether did is currently a member.
+func (s *DIDSet) Contains(did string) bool {
+	m := s.members.Load()
+	if m == nil {
+		return false
+	}
+	_, ok := (*m)[did]
+	return ok
+}
+
+// Len returns the current membership count.
+func (s *DIDSet) Len() int {
+	m := s.members.Load()
+	if m == nil {
+		return 0
+	}
+	return len(*m)
+}
+
+// readDIDSetFile reads path's non-blank, trimmed lines as a DID list.
+func readDIDSetFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read did set %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var dids []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		dids = append(dids, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read did set %s: %w", path, err)
+	}
+	return dids, nil
+}
+
+// WatchDIDSetFile loads path as a newline-delimited DID list and returns a
+// DIDSet kept in sync with it: a background goroutine re-reads path every
+// interval and calls Store whenever its modification time has advanced,
+// so editing the file (e.g. to add or remove a DID from a cohort) takes
+// effect without restarting whatever's filtering against the set. The
+// returned stop func cancels the background goroutine; callers should
+// defer it the same way they would a Sink's Close.
+func WatchDIDSetFile(path string, interval time.Duration) (*DIDSet, func(), error) {
+	dids, err := readDIDSetFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("watch did set %s: %w", path, err)
+	}
+
+	set := NewDIDSet(dids)
+	lastMod := info.ModTime()
+
+	stopCh := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				info, err := os.Stat(path)
+				if err != nil || !info.ModTime().After(lastMod) {
+					continue
+				}
+				dids, err := readDIDSetFile(path)
+				if err != nil {
+					continue
+				}
+				set.Store(dids)
+				lastMod = info.ModTime()
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+
+	stop := func() {
+		close(stopCh)
+		<-stopped
+	}
+	return set, stop, nil
+}
@@ -0,0 +1,42 @@
+package backfill
+
+// RecordDiff is the result of comparing two snapshots of the same DID set's
+// records, keyed by AT-URI.
+type RecordDiff struct {
+	Added   []Record
+	Removed []Record
+	Updated []Record
+}
+
+// DiffRecords compares old and new sets of records (e.g. from two archived
+// sync runs for the same DID set) and reports which AT-URIs were added,
+// removed, or changed CID between the two, for churn and deletion studies.
+func DiffRecords(oldRecords, newRecords []Record) RecordDiff {
+	oldByURI := make(map[string]Record, len(oldRecords))
+	for _, r := range oldRecords {
+		oldByURI[r.URI] = r
+	}
+	newByURI := make(map[string]Record, len(newRecords))
+	for _, r := range newRecords {
+		newByURI[r.URI] = r
+	}
+
+	var diff RecordDiff
+	for _, r := range newRecords {
+		prev, existed := oldByURI[r.URI]
+		if !existed {
+			diff.Added = append(diff.Added, r)
+			continue
+		}
+		if prev.CID != r.CID {
+			diff.Updated = append(diff.Updated, r)
+		}
+	}
+	for _, r := range oldRecords {
+		if _, stillPresent := newByURI[r.URI]; !stillPresent {
+			diff.Removed = append(diff.Removed, r)
+		}
+	}
+
+	return diff
+}
@@ -0,0 +1,34 @@
+package backfill
+
+import "testing"
+
+func TestDiffRecords(t *testing.T) {
+	old := []Record{
+		{URI: "at://did:plc:a/app.bsky.feed.post/1", CID: "cid1"},
+		{URI: "at://did:plc:a/app.bsky.feed.post/2", CID: "cid2"},
+	}
+	new := []Record{
+		{URI: "at://did:plc:a/app.bsky.feed.post/2", CID: "cid2-updated"},
+		{URI: "at://did:plc:a/app.bsky.feed.post/3", CID: "cid3"},
+	}
+
+	diff := DiffRecords(old, new)
+
+	if len(diff.Added) != 1 || diff.Added[0].URI != "at://did:plc:a/app.bsky.feed.post/3" {
+		t.Errorf("Added = %v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].URI != "at://did:plc:a/app.bsky.feed.post/1" {
+		t.Errorf("Removed = %v", diff.Removed)
+	}
+	if len(diff.Updated) != 1 || diff.Updated[0].URI != "at://did:plc:a/app.bsky.feed.post/2" {
+		t.Errorf("Updated = %v", diff.Updated)
+	}
+}
+
+func TestDiffRecordsIdenticalHasNoDiff(t *testing.T) {
+	recs := []Record{{URI: "at://did:plc:a/app.bsky.feed.post/1", CID: "cid1"}}
+	diff := DiffRecords(recs, recs)
+	if len(diff.Added) != 0 || len(diff.Removed) != 0 || len(diff.Updated) != 0 {
+		t.Errorf("expected no diff, got %+v", diff)
+	}
+}
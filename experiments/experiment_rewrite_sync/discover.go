@@ -0,0 +1,64 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// RepoInfo is a single entry returned by com.atproto.sync.listRepos.
+type RepoInfo struct {
+	DID string `json:"did"`
+	Rev string `json:"rev"`
+}
+
+type listReposResponse struct {
+	Repos  []RepoInfo `json:"repos"`
+	Cursor string     `json:"cursor"`
+}
+
+// ListRepos fetches one page of com.atproto.sync.listRepos from host (a
+// relay or a specific PDS), starting at cursor ("" for the first page).
+// Callers page by threading the returned nextCursor back in until it comes
+// back empty, persisting it between calls if the crawl needs to survive a
+// restart.
+func ListRepos(ctx context.Context, httpClient *http.Client, host, cursor string, limit int) (repos []RepoInfo, nextCursor string, err error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if limit <= 0 {
+		limit = 1000
+	}
+
+	q := url.Values{}
+	q.Set("limit", strconv.Itoa(limit))
+	if cursor != "" {
+		q.Set("cursor", cursor)
+	}
+
+	reqURL := fmt.Sprintf("%s/xrpc/com.atproto.sync.listRepos?%s", host, q.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("listRepos %s: %w", host, err)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("listRepos %s: %w", host, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("listRepos %s: server returned %s", host, resp.Status)
+	}
+
+	var page listReposResponse
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, "", fmt.Errorf("listRepos %s: decode: %w", host, err)
+	}
+
+	return page.Repos, page.Cursor, nil
+}
@@ -0,0 +1,46 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestListReposPagesByCursor(t *testing.T) {
+	pages := map[string]listReposResponse{
+		"": {
+			Repos:  []RepoInfo{{DID: "did:plc:a"}, {DID: "did:plc:b"}},
+			Cursor: "page2",
+		},
+		"page2": {
+			Repos: []RepoInfo{{DID: "did:plc:c"}},
+		},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Query().Get("cursor")]
+		if !ok {
+			t.Fatalf("unexpected cursor %q", r.URL.Query().Get("cursor"))
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer srv.Close()
+
+	repos, cursor, err := ListRepos(context.Background(), srv.Client(), srv.URL, "", 0)
+	if err != nil {
+		t.Fatalf("ListRepos() error = %v", err)
+	}
+	if len(repos) != 2 || cursor != "page2" {
+		t.Fatalf("got repos=%v cursor=%q, want 2 repos and cursor %q", repos, cursor, "page2")
+	}
+
+	repos, cursor, err = ListRepos(context.Background(), srv.Client(), srv.URL, cursor, 0)
+	if err != nil {
+		t.Fatalf("ListRepos() error = %v", err)
+	}
+	if len(repos) != 1 || cursor != "" {
+		t.Fatalf("got repos=%v cursor=%q, want 1 repo and empty cursor", repos, cursor)
+	}
+}
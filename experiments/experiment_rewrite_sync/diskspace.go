@@ -0,0 +1,71 @@
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// DiskSpaceGuardSink wraps a Sink with a free-space check on dir before
+// writes, so a long backfill run stops cleanly once the output volume is
+// nearly full instead of failing mid-write and corrupting the shard in
+// progress. Shards already flushed to next before the guard trips are a
+// valid checkpoint: resuming the run (e.g. against a larger volume) just
+// means re-running the remaining DIDs.
+type DiskSpaceGuardSink struct {
+	dir          string
+	minFreeBytes int64
+	checkEvery   int
+	next         Sink
+
+	mu     sync.Mutex
+	writes int
+	low    bool
+}
+
+// NewDiskSpaceGuardSink creates a DiskSpaceGuardSink that checks dir's free
+// space every checkEvery writes (checkEvery < 1 is treated as 1, checking
+// every write) and refuses further writes to next once free space drops
+// below minFreeBytes.
+func NewDiskSpaceGuardSink(dir string, minFreeBytes int64, checkEvery int, next Sink) *DiskSpaceGuardSink {
+	if checkEvery < 1 {
+		checkEvery = 1
+	}
+	return &DiskSpaceGuardSink{dir: dir, minFreeBytes: minFreeBytes, checkEvery: checkEvery, next: next}
+}
+
+// Write checks dir's free space every checkEvery'th call and, once it's
+// seen free space drop below minFreeBytes, returns ErrLowDisk for every
+// subsequent call instead of checking again — the volume isn't going to
+// recover free space on its own mid-run.
+func (s *DiskSpaceGuardSink) Write(ctx context.Context, did string, rec Record) error {
+	s.mu.Lock()
+	s.writes++
+	due := s.writes%s.checkEvery == 0
+	low := s.low
+	s.mu.Unlock()
+
+	if low {
+		return ErrLowDisk
+	}
+
+	if due {
+		if free, ok := freeDiskBytes(s.dir); ok && free < uint64(s.minFreeBytes) {
+			s.mu.Lock()
+			s.low = true
+			s.mu.Unlock()
+			return fmt.Errorf("%w: %s has %d bytes free, want at least %d", ErrLowDisk, s.dir, free, s.minFreeBytes)
+		}
+	}
+
+	return s.next.Write(ctx, did, rec)
+}
+
+// Close closes next if it implements io.Closer.
+func (s *DiskSpaceGuardSink) Close() error {
+	closer, ok := s.next.(interface{ Close() error })
+	if !ok {
+		return nil
+	}
+	return closer.Close()
+}
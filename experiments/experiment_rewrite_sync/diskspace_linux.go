@@ -0,0 +1,16 @@
+//go:build linux
+
+package backfill
+
+import "syscall"
+
+// freeDiskBytes reports how much space is free for non-root users on the
+// filesystem containing dir. ok is false if the statfs call fails (e.g.
+// dir doesn't exist).
+func freeDiskBytes(dir string) (free uint64, ok bool) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, false
+	}
+	return stat.Bavail * uint64(stat.Bsize), true
+}
@@ -0,0 +1,104 @@
+package backfill
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFreeDiskBytesReportsNonZeroForATempDir(t *testing.T) {
+	free, ok := freeDiskBytes(t.TempDir())
+	if !ok {
+		t.Skip("freeDiskBytes not implemented on this platform")
+	}
+	if free == 0 {
+		t.Error("freeDiskBytes() = 0, want > 0 for a writable temp dir")
+	}
+}
+
+func TestDiskSpaceGuardSinkPassesThroughWhenSpaceIsPlentiful(t *testing.T) {
+	next := &recordingSink{}
+	sink := NewDiskSpaceGuardSink(t.TempDir(), 1, 1, next)
+	ctx := context.Background()
+
+	if err := sink.Write(ctx, "did:plc:alice", Record{URI: "at://did:plc:alice/x/1"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if len(next.writes) != 1 {
+		t.Fatalf("next got %d writes, want 1", len(next.writes))
+	}
+}
+
+func TestDiskSpaceGuardSinkTripsWhenFreeSpaceIsBelowMinimum(t *testing.T) {
+	next := &recordingSink{}
+	dir := t.TempDir()
+	if _, ok := freeDiskBytes(dir); !ok {
+		t.Skip("freeDiskBytes not implemented on this platform")
+	}
+
+	const impossiblyLarge = 1 << 62
+	sink := NewDiskSpaceGuardSink(dir, impossiblyLarge, 1, next)
+	ctx := context.Background()
+
+	err := sink.Write(ctx, "did:plc:alice", Record{URI: "at://did:plc:alice/x/1"})
+	if !errors.Is(err, ErrLowDisk) {
+		t.Fatalf("Write() error = %v, want ErrLowDisk", err)
+	}
+	if len(next.writes) != 0 {
+		t.Errorf("next got %d writes, want 0 once low-disk tripped", len(next.writes))
+	}
+}
+
+func TestDiskSpaceGuardSinkStaysTrippedWithoutRecheckingEveryWrite(t *testing.T) {
+	next := &recordingSink{}
+	dir := t.TempDir()
+	if _, ok := freeDiskBytes(dir); !ok {
+		t.Skip("freeDiskBytes not implemented on this platform")
+	}
+
+	const impossiblyLarge = 1 << 62
+	sink := NewDiskSpaceGuardSink(dir, impossiblyLarge, 1, next)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := sink.Write(ctx, "did:plc:alice", Record{URI: "at://did:plc:alice/x/1"}); !errors.Is(err, ErrLowDisk) {
+			t.Fatalf("Write() call %d error = %v, want ErrLowDisk", i, err)
+		}
+	}
+	if len(next.writes) != 0 {
+		t.Errorf("next got %d writes, want 0", len(next.writes))
+	}
+}
+
+func TestDiskSpaceGuardSinkOnlyChecksEveryNthWrite(t *testing.T) {
+	next := &recordingSink{}
+	dir := t.TempDir()
+	if _, ok := freeDiskBytes(dir); !ok {
+		t.Skip("freeDiskBytes not implemented on this platform")
+	}
+
+	const impossiblyLarge = 1 << 62
+	sink := NewDiskSpaceGuardSink(dir, impossiblyLarge, 3, next)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := sink.Write(ctx, "did:plc:alice", Record{URI: "at://did:plc:alice/x/1"}); err != nil {
+			t.Fatalf("Write() call %d error = %v, want nil (check not due yet)", i, err)
+		}
+	}
+	if len(next.writes) != 2 {
+		t.Fatalf("next got %d writes, want 2 before the 3rd write triggers a check", len(next.writes))
+	}
+
+	if err := sink.Write(ctx, "did:plc:alice", Record{URI: "at://did:plc:alice/x/1"}); !errors.Is(err, ErrLowDisk) {
+		t.Fatalf("Write() 3rd call error = %v, want ErrLowDisk", err)
+	}
+}
+
+func TestDiskSpaceGuardSinkCloseForwardsToNextIfCloser(t *testing.T) {
+	next := &recordingSink{}
+	sink := NewDiskSpaceGuardSink(t.TempDir(), 0, 1, next)
+	if err := sink.Close(); err != nil {
+		t.Errorf("Close() error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,105 @@
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// requestLimiter throttles outbound requests to some rate, either local
+// to this process (tokenBucketLimiter) or shared across processes
+// (redisRateLimiter). resolveWithRetry waits on one before every attempt.
+type requestLimiter interface {
+	wait(ctx context.Context) error
+}
+
+// RedisRateLimitConfig configures a rate limiter shared across multiple
+// backfill processes via Redis, so several shards collectively respect
+// one global requests-per-window budget toward a remote host (e.g.
+// plc.directory or bsky.network) instead of each enforcing its own local
+// limit independently, which would let N shards add up to N times the
+// intended rate.
+type RedisRateLimitConfig struct {
+	// Addr is the Redis server's host:port.
+	Addr string
+
+	// Key namespaces the shared counter, e.g. "bsky-backfill:plc". Every
+	// process sharing a budget must use the same Key.
+	Key string
+
+	// Limit is the maximum number of requests allowed per Window across
+	// every process sharing Key.
+	Limit int
+
+	// Window is the fixed window duration the counter resets after.
+	Window time.Duration
+
+	// DialTimeout bounds each Redis round trip. Zero means a 5s default.
+	DialTimeout time.Duration
+}
+
+// redisRateLimiter enforces RedisRateLimitConfig with a fixed-window
+// counter: each request increments Key, setting its expiry to Window the
+// first time it's created within a window; once the count exceeds Limit,
+// callers wait out the rest of the window (per PTTL) before retrying.
+type redisRateLimiter struct {
+	cfg    RedisRateLimitConfig
+	client *redisClient
+}
+
+func newRedisRateLimiter(cfg RedisRateLimitConfig) *redisRateLimiter {
+	return &redisRateLimiter{cfg: cfg, client: newRedisClient(cfg.Addr, cfg.DialTimeout)}
+}
+
+// wait blocks until the shared budget has room for one more request, or
+// ctx is canceled.
+func (l *redisRateLimiter) wait(ctx context.Context) error {
+	for {
+		n, retryAfter, err := l.tryAcquire()
+		if err != nil {
+			return err
+		}
+		if n <= int64(l.cfg.Limit) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retryAfter):
+		}
+	}
+}
+
+// tryAcquire increments the shared counter and returns its new value and,
+// if the budget is exhausted, how long to wait before trying again.
+func (l *redisRateLimiter) tryAcquire() (count int64, retryAfter time.Duration, err error) {
+	reply, err := l.client.do("INCR", l.cfg.Key)
+	if err != nil {
+		return 0, 0, err
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return 0, 0, fmt.Errorf("redis: INCR %s: unexpected reply %v", l.cfg.Key, reply)
+	}
+
+	if n == 1 {
+		if _, err := l.client.do("PEXPIRE", l.cfg.Key, strconv.FormatInt(l.cfg.Window.Milliseconds(), 10)); err != nil {
+			return 0, 0, err
+		}
+	}
+	if n <= int64(l.cfg.Limit) {
+		return n, 0, nil
+	}
+
+	ttlReply, err := l.client.do("PTTL", l.cfg.Key)
+	if err != nil {
+		return 0, 0, err
+	}
+	ttlMS, _ := ttlReply.(int64)
+	if ttlMS <= 0 {
+		ttlMS = l.cfg.Window.Milliseconds()
+	}
+	return n, time.Duration(ttlMS) * time.Millisecond, nil
+}
@@ -0,0 +1,62 @@
+package backfill
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedisRateLimiterWaitAllowsRequestsUnderLimit(t *testing.T) {
+	srv := newFakeRedisServer(t, map[string]string{
+		"INCR":    ":1\r\n",
+		"PEXPIRE": ":1\r\n",
+	})
+	limiter := newRedisRateLimiter(RedisRateLimitConfig{
+		Addr:   srv.ln.Addr().String(),
+		Key:    "test-key",
+		Limit:  5,
+		Window: time.Minute,
+	})
+
+	if err := limiter.wait(context.Background()); err != nil {
+		t.Fatalf("wait() error = %v, want nil when under the limit", err)
+	}
+}
+
+func TestRedisRateLimiterWaitBlocksUntilWindowExpiresWhenOverLimit(t *testing.T) {
+	srv := newFakeRedisServer(t, map[string]string{
+		"INCR": ":6\r\n", // already over Limit: 5
+		"PTTL": ":20\r\n",
+	})
+	limiter := newRedisRateLimiter(RedisRateLimitConfig{
+		Addr:   srv.ln.Addr().String(),
+		Key:    "test-key",
+		Limit:  5,
+		Window: time.Minute,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := limiter.wait(ctx); err == nil {
+		t.Fatal("wait() error = nil, want ctx.Err() since the count never drops below the limit within the deadline")
+	}
+}
+
+func TestRedisRateLimiterWaitPropagatesRedisError(t *testing.T) {
+	srv := newFakeRedisServer(t, map[string]string{
+		"INCR": "-ERR connection reset\r\n",
+	})
+	limiter := newRedisRateLimiter(RedisRateLimitConfig{
+		Addr:   srv.ln.Addr().String(),
+		Key:    "test-key",
+		Limit:  5,
+		Window: time.Minute,
+	})
+
+	err := limiter.wait(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "connection reset") {
+		t.Fatalf("wait() error = %v, want it to surface the redis error", err)
+	}
+}
@@ -0,0 +1,99 @@
+package backfill
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// dnsCacheEntry is one cached hostname-to-address lookup result.
+type dnsCacheEntry struct {
+	addrs     []string
+	expiresAt time.Time
+}
+
+// dnsCache caches LookupHost results for a configurable TTL, so a run
+// resolving many DIDs against the same handful of PDS hosts (e.g.
+// several bsky.social shards) doesn't repeatedly hit the system
+// resolver on every connection, which we've seen throttle or
+// intermittently fail under high concurrency.
+type dnsCache struct {
+	ttl    time.Duration
+	lookup func(ctx context.Context, host string) ([]string, error)
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]dnsCacheEntry
+}
+
+// newDNSCache builds a dnsCache with the given TTL. lookup defaults to
+// net.DefaultResolver.LookupHost; overridable in tests to avoid a real
+// DNS dependency and to count calls.
+func newDNSCache(ttl time.Duration, lookup func(ctx context.Context, host string) ([]string, error)) *dnsCache {
+	if lookup == nil {
+		lookup = net.DefaultResolver.LookupHost
+	}
+	return &dnsCache{ttl: ttl, lookup: lookup, now: time.Now, entries: make(map[string]dnsCacheEntry)}
+}
+
+// get returns host's cached addresses if still within TTL, otherwise
+// looks them up and caches the result.
+func (c *dnsCache) get(ctx context.Context, host string) ([]string, error) {
+	c.mu.Lock()
+	if e, ok := c.entries[host]; ok && c.now().Before(e.expiresAt) {
+		addrs := e.addrs
+		c.mu.Unlock()
+		return addrs, nil
+	}
+	c.mu.Unlock()
+
+	addrs, err := c.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[host] = dnsCacheEntry{addrs: addrs, expiresAt: c.now().Add(c.ttl)}
+	c.mu.Unlock()
+	return addrs, nil
+}
+
+// NewDNSCachingTransport returns an http.Transport that resolves each
+// dial's hostname through an in-process cache with the given TTL instead
+// of hitting the system resolver on every connection. base's DialContext
+// (or a plain net.Dialer if base is nil) performs the actual dial, once
+// a hostname has been resolved to an address; base is not modified.
+//
+// Only the first address a lookup returns is used — there's no
+// round-robin or failover across multiple A/AAAA records — which is an
+// acceptable simplification for the single-PDS-endpoint-per-DID shape
+// of this module's traffic.
+func NewDNSCachingTransport(base *http.Transport, ttl time.Duration) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if base != nil {
+		t = base.Clone()
+	}
+
+	cache := newDNSCache(ttl, nil)
+	dialer := &net.Dialer{}
+	baseDial := t.DialContext
+	if baseDial == nil {
+		baseDial = dialer.DialContext
+	}
+
+	t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || net.ParseIP(host) != nil {
+			return baseDial(ctx, network, addr)
+		}
+
+		addrs, err := cache.get(ctx, host)
+		if err != nil || len(addrs) == 0 {
+			return baseDial(ctx, network, addr)
+		}
+		return baseDial(ctx, network, net.JoinHostPort(addrs[0], port))
+	}
+	return t
+}
@@ -0,0 +1,94 @@
+package backfill
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDNSCacheReturnsCachedResultWithinTTL(t *testing.T) {
+	calls := 0
+	cache := newDNSCache(time.Minute, func(ctx context.Context, host string) ([]string, error) {
+		calls++
+		return []string{"127.0.0.1"}, nil
+	})
+
+	for i := 0; i < 3; i++ {
+		addrs, err := cache.get(context.Background(), "pds.example")
+		if err != nil {
+			t.Fatalf("get() error = %v", err)
+		}
+		if len(addrs) != 1 || addrs[0] != "127.0.0.1" {
+			t.Fatalf("addrs = %v, want [127.0.0.1]", addrs)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("underlying lookup called %d times, want 1", calls)
+	}
+}
+
+func TestDNSCacheRefreshesAfterTTLExpires(t *testing.T) {
+	calls := 0
+	cache := newDNSCache(time.Minute, func(ctx context.Context, host string) ([]string, error) {
+		calls++
+		return []string{"127.0.0.1"}, nil
+	})
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	if _, err := cache.get(context.Background(), "pds.example"); err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	now = now.Add(2 * time.Minute)
+	if _, err := cache.get(context.Background(), "pds.example"); err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("underlying lookup called %d times, want 2 (one per TTL window)", calls)
+	}
+}
+
+func TestDNSCacheCachesPerHost(t *testing.T) {
+	calls := 0
+	cache := newDNSCache(time.Minute, func(ctx context.Context, host string) ([]string, error) {
+		calls++
+		return []string{"127.0.0.1"}, nil
+	})
+
+	cache.get(context.Background(), "a.example")
+	cache.get(context.Background(), "b.example")
+	cache.get(context.Background(), "a.example")
+	if calls != 2 {
+		t.Errorf("underlying lookup called %d times, want 2 (one per distinct host)", calls)
+	}
+}
+
+func TestDNSCacheDoesNotCacheAFailedLookup(t *testing.T) {
+	calls := 0
+	cache := newDNSCache(time.Minute, func(ctx context.Context, host string) ([]string, error) {
+		calls++
+		return nil, errors.New("lookup failed")
+	})
+
+	cache.get(context.Background(), "pds.example")
+	cache.get(context.Background(), "pds.example")
+	if calls != 2 {
+		t.Errorf("underlying lookup called %d times, want 2 (failures aren't cached)", calls)
+	}
+}
+
+func TestNewDNSCachingTransportPassesBareIPStraightToBaseDialer(t *testing.T) {
+	transport := NewDNSCachingTransport(nil, time.Minute)
+	if transport.DialContext == nil {
+		t.Fatal("DialContext is nil, want a caching dialer")
+	}
+
+	// A bare IP address has nothing to resolve, so it should pass
+	// straight through to the base dialer rather than going through the
+	// cache.
+	_, err := transport.DialContext(context.Background(), "tcp", "127.0.0.1:1")
+	if err == nil {
+		t.Fatal("expected a dial error connecting to a closed port, got nil")
+	}
+}
@@ -0,0 +1,81 @@
+package backfill
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrorSampler decides which of a stream of per-DID errors to log in
+// full and which to count silently, so an error storm (thousands of
+// DIDs failing with the same cause) produces a sampled subset of
+// example lines plus an aggregate count per error class instead of one
+// line per failure drowning out everything else in the log.
+type ErrorSampler struct {
+	every int
+
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// NewErrorSampler returns an ErrorSampler that logs the first
+// occurrence of each error class and then every every'th occurrence of
+// that class after that. every <= 1 logs every occurrence (no
+// sampling).
+func NewErrorSampler(every int) *ErrorSampler {
+	return &ErrorSampler{every: every, counts: make(map[string]int)}
+}
+
+// ShouldLog records one occurrence of err's class (see classifyErr) and
+// reports whether this particular occurrence should be logged in full.
+func (s *ErrorSampler) ShouldLog(err error) bool {
+	class := classifyErr(err)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counts[class]++
+	if s.every <= 1 {
+		return true
+	}
+	return s.counts[class]%s.every == 1
+}
+
+// Counts returns the total number of occurrences seen per error class,
+// including ones ShouldLog sampled out.
+func (s *ErrorSampler) Counts() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]int, len(s.counts))
+	for k, v := range s.counts {
+		out[k] = v
+	}
+	return out
+}
+
+// Report formats the aggregate counts as a human-readable summary
+// ordered by count descending, or "" if nothing was recorded.
+func (s *ErrorSampler) Report() string {
+	counts := s.Counts()
+	if len(counts) == 0 {
+		return ""
+	}
+
+	classes := make([]string, 0, len(counts))
+	for c := range counts {
+		classes = append(classes, c)
+	}
+	sort.Slice(classes, func(i, j int) bool {
+		if counts[classes[i]] != counts[classes[j]] {
+			return counts[classes[i]] > counts[classes[j]]
+		}
+		return classes[i] < classes[j]
+	})
+
+	var b strings.Builder
+	b.WriteString("error counts by class:\n")
+	for _, c := range classes {
+		fmt.Fprintf(&b, "  %-16s %d\n", c, counts[c])
+	}
+	return b.String()
+}
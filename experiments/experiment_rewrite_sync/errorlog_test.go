@@ -0,0 +1,70 @@
+package backfill
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestErrorSamplerLogsFirstThenEveryNthPerClass(t *testing.T) {
+	s := NewErrorSampler(3)
+
+	var logged int
+	for i := 0; i < 7; i++ {
+		if s.ShouldLog(ErrRateLimited) {
+			logged++
+		}
+	}
+	// occurrences 1, 4, 7 should log; 2, 3, 5, 6 should not.
+	if logged != 3 {
+		t.Errorf("logged = %d, want 3", logged)
+	}
+	if got := s.Counts()[classifyErr(ErrRateLimited)]; got != 7 {
+		t.Errorf("Counts()[%q] = %d, want 7", classifyErr(ErrRateLimited), got)
+	}
+}
+
+func TestErrorSamplerSamplesEachClassIndependently(t *testing.T) {
+	s := NewErrorSampler(2)
+
+	if !s.ShouldLog(ErrRateLimited) {
+		t.Error("first ErrRateLimited should log")
+	}
+	if !s.ShouldLog(ErrDIDNotFound) {
+		t.Error("first ErrDIDNotFound should log, independent of the ErrRateLimited counter")
+	}
+}
+
+func TestErrorSamplerEveryAtMostOneLogsEveryOccurrence(t *testing.T) {
+	s := NewErrorSampler(0)
+	for i := 0; i < 5; i++ {
+		if !s.ShouldLog(ErrRateLimited) {
+			t.Fatalf("occurrence %d not logged, want every occurrence logged when every<=1", i)
+		}
+	}
+}
+
+func TestErrorSamplerReportOrdersByCountDescending(t *testing.T) {
+	s := NewErrorSampler(1)
+	for i := 0; i < 3; i++ {
+		s.ShouldLog(ErrRateLimited)
+	}
+	s.ShouldLog(ErrDIDNotFound)
+
+	report := s.Report()
+	rateLimitedClass := classifyErr(ErrRateLimited)
+	notFoundClass := classifyErr(ErrDIDNotFound)
+	if strings.Index(report, rateLimitedClass) > strings.Index(report, notFoundClass) {
+		t.Errorf("Report() = %q, want %q (3 occurrences) before %q (1 occurrence)", report, rateLimitedClass, notFoundClass)
+	}
+	if !strings.Contains(report, fmt.Sprintf("%d", 3)) {
+		t.Errorf("Report() = %q, missing count of 3", report)
+	}
+}
+
+func TestErrorSamplerReportEmptyWhenNothingRecorded(t *testing.T) {
+	s := NewErrorSampler(1)
+	if got := s.Report(); got != "" {
+		t.Errorf("Report() = %q, want empty string", got)
+	}
+}
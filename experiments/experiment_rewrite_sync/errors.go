@@ -0,0 +1,87 @@
+package backfill
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors classify the ways resolving or fetching a DID can fail,
+// so callers and the failure report can branch on errors.Is instead of
+// string-matching error messages.
+var (
+	// ErrDIDNotFound means the PLC directory has no document for the DID.
+	ErrDIDNotFound = errors.New("backfill: did not found")
+
+	// ErrNoService means a DID's document exists but declares no
+	// AtprotoPersonalDataServer service endpoint.
+	ErrNoService = errors.New("backfill: no pds service in did document")
+
+	// ErrRepoTooLarge means a repo exceeded MaxRepoSizeBytes and no
+	// Collections were configured to fall back to listRecords.
+	ErrRepoTooLarge = errors.New("backfill: repo too large")
+
+	// ErrPDSTimeout means a request to a PDS exceeded its deadline.
+	ErrPDSTimeout = errors.New("backfill: pds request timed out")
+
+	// ErrRateLimited means a PDS responded with 429 Too Many Requests.
+	ErrRateLimited = errors.New("backfill: rate limited")
+
+	// ErrTakenDown means the account has been taken down and its repo is
+	// unavailable.
+	ErrTakenDown = errors.New("backfill: account taken down")
+
+	// ErrPanic means a worker goroutine panicked while processing a DID
+	// (e.g. on malformed repo data); the panic was recovered so the rest
+	// of the run keeps going.
+	ErrPanic = errors.New("backfill: worker panicked")
+
+	// ErrLowDisk means DiskSpaceGuardSink found free space on the
+	// monitored volume below its configured minimum and stopped writing
+	// rather than risk failing mid-write on the shard in progress.
+	ErrLowDisk = errors.New("backfill: free disk space below minimum")
+)
+
+// errClasses pairs each sentinel error with the short, stable string used
+// to populate DIDResult.ErrClass.
+var errClasses = []struct {
+	err   error
+	class string
+}{
+	{ErrDIDNotFound, "not_found"},
+	{ErrNoService, "no_service"},
+	{ErrRepoTooLarge, "repo_too_large"},
+	{ErrPDSTimeout, "timeout"},
+	{ErrRateLimited, "rate_limited"},
+	{ErrTakenDown, "taken_down"},
+	{ErrPanic, "panic"},
+	{ErrLowDisk, "low_disk"},
+}
+
+// HTTPStatusError wraps an HTTP response status that didn't match any of
+// the specific sentinels above (e.g. a 500 from the PLC directory), so
+// callers can still branch on the exact status code — in particular, to
+// decide whether it's worth retrying — without string-matching resp.Status.
+type HTTPStatusError struct {
+	// Op names the request that failed, e.g. "resolve did:plc:... ".
+	Op         string
+	StatusCode int
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("%s: unexpected status %d", e.Op, e.StatusCode)
+}
+
+// classifyErr maps err to a short, stable class string for DIDResult.ErrClass
+// using errors.Is against the sentinel taxonomy above. It returns "unknown"
+// for any non-nil error that doesn't match a known sentinel, and "" for nil.
+func classifyErr(err error) string {
+	if err == nil {
+		return ""
+	}
+	for _, c := range errClasses {
+		if errors.Is(err, c.err) {
+			return c.class
+		}
+	}
+	return "unknown"
+}
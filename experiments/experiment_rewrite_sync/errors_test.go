@@ -0,0 +1,34 @@
+package backfill
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestSentinelErrorsAreWrappedConsistently(t *testing.T) {
+	wrapped := fmt.Errorf("resolve did:plc:abc: %w", ErrDIDNotFound)
+	if !errors.Is(wrapped, ErrDIDNotFound) {
+		t.Errorf("errors.Is(wrapped, ErrDIDNotFound) = false, want true")
+	}
+	if errors.Is(wrapped, ErrNoService) {
+		t.Errorf("errors.Is(wrapped, ErrNoService) = true, want false")
+	}
+}
+
+func TestClassifyErr(t *testing.T) {
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{ErrRateLimited, "rate_limited"},
+		{fmt.Errorf("resolve: %w", ErrTakenDown), "taken_down"},
+		{errors.New("boom"), "unknown"},
+	}
+	for _, c := range cases {
+		if got := classifyErr(c.err); got != c.want {
+			t.Errorf("classifyErr(%v) = %q, want %q", c.err, got, c.want)
+		}
+	}
+}
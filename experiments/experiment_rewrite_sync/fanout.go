@@ -0,0 +1,95 @@
+package backfill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// SinkFailurePolicy controls how FanoutSink reacts when one of its entries
+// fails to write a record.
+type SinkFailurePolicy int
+
+const (
+	// FailRun surfaces the sink's error from Write, failing the record (and,
+	// via the normal Run/RunStream error handling, the DID it belongs to).
+	FailRun SinkFailurePolicy = iota
+	// LogAndContinue reports the error to FanoutSink's error logger, if one
+	// is configured, and otherwise drops it so the remaining sinks still
+	// receive the record.
+	LogAndContinue
+)
+
+// FanoutEntry is one sink configured into a FanoutSink.
+type FanoutEntry struct {
+	// Name identifies this entry in errors and error-logger calls, e.g.
+	// "parquet-s3" or "kafka".
+	Name string
+	Sink Sink
+	// Policy controls what happens when Sink.Write fails. Defaults to
+	// FailRun (the zero value).
+	Policy SinkFailurePolicy
+}
+
+// FanoutSink writes every record to multiple sinks (e.g. Parquet on S3 and
+// Kafka) with an independent failure policy per sink, so a best-effort sink
+// can't take down a run that a required sink would otherwise complete.
+type FanoutSink struct {
+	entries     []FanoutEntry
+	onSinkError func(name, did string, err error)
+}
+
+// FanoutSinkOption configures a FanoutSink constructed with NewFanoutSink.
+type FanoutSinkOption func(*FanoutSink)
+
+// WithSinkErrorLogger sets the callback invoked whenever a LogAndContinue
+// entry's Write fails. Nil (the default) means such failures are dropped
+// silently.
+func WithSinkErrorLogger(f func(name, did string, err error)) FanoutSinkOption {
+	return func(s *FanoutSink) { s.onSinkError = f }
+}
+
+// NewFanoutSink creates a FanoutSink over entries, each written to in order
+// for every record.
+func NewFanoutSink(entries []FanoutEntry, opts ...FanoutSinkOption) *FanoutSink {
+	s := &FanoutSink{entries: entries}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Write sends rec to every entry. FailRun entries' errors are joined and
+// returned; LogAndContinue entries' errors go to the error logger (if any)
+// instead of aborting the write to the remaining entries.
+func (s *FanoutSink) Write(ctx context.Context, did string, rec Record) error {
+	var errs []error
+	for _, e := range s.entries {
+		if err := e.Sink.Write(ctx, did, rec); err != nil {
+			if e.Policy == LogAndContinue {
+				if s.onSinkError != nil {
+					s.onSinkError(e.Name, did, err)
+				}
+				continue
+			}
+			errs = append(errs, fmt.Errorf("sink %q: %w", e.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes every entry's Sink that implements io.Closer, joining any
+// errors.
+func (s *FanoutSink) Close() error {
+	var errs []error
+	for _, e := range s.entries {
+		closer, ok := e.Sink.(interface{ Close() error })
+		if !ok {
+			continue
+		}
+		if err := closer.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close sink %q: %w", e.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
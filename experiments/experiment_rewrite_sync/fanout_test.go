@@ -0,0 +1,74 @@
+package backfill
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type failingSink struct {
+	err error
+}
+
+func (s *failingSink) Write(ctx context.Context, did string, rec Record) error {
+	return s.err
+}
+
+func TestFanoutSinkWritesToEveryEntry(t *testing.T) {
+	a, b := &recordingSink{}, &recordingSink{}
+	sink := NewFanoutSink([]FanoutEntry{{Name: "a", Sink: a}, {Name: "b", Sink: b}})
+
+	rec := Record{URI: "at://did:plc:alice/app.bsky.feed.post/1"}
+	if err := sink.Write(context.Background(), "did:plc:alice", rec); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if len(a.writes) != 1 || len(b.writes) != 1 {
+		t.Fatalf("got %d/%d writes, want 1/1", len(a.writes), len(b.writes))
+	}
+}
+
+func TestFanoutSinkFailRunSurfacesError(t *testing.T) {
+	wantErr := errors.New("boom")
+	failing := &failingSink{err: wantErr}
+	ok := &recordingSink{}
+	sink := NewFanoutSink([]FanoutEntry{
+		{Name: "required", Sink: failing, Policy: FailRun},
+		{Name: "also-required", Sink: ok},
+	})
+
+	err := sink.Write(context.Background(), "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1"})
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("Write() error = %v, want it to wrap %v", err, wantErr)
+	}
+	// The other entry still received the record despite the failure.
+	if len(ok.writes) != 1 {
+		t.Fatalf("got %d writes to the other sink, want 1", len(ok.writes))
+	}
+}
+
+func TestFanoutSinkLogAndContinueSwallowsError(t *testing.T) {
+	wantErr := errors.New("best effort failed")
+	failing := &failingSink{err: wantErr}
+	ok := &recordingSink{}
+
+	var loggedName, loggedDID string
+	var loggedErr error
+	sink := NewFanoutSink(
+		[]FanoutEntry{
+			{Name: "best-effort", Sink: failing, Policy: LogAndContinue},
+			{Name: "required", Sink: ok},
+		},
+		WithSinkErrorLogger(func(name, did string, err error) {
+			loggedName, loggedDID, loggedErr = name, did, err
+		}),
+	)
+
+	err := sink.Write(context.Background(), "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1"})
+	if err != nil {
+		t.Fatalf("Write() error = %v, want nil (LogAndContinue should not fail the run)", err)
+	}
+	if loggedName != "best-effort" || loggedDID != "did:plc:alice" || !errors.Is(loggedErr, wantErr) {
+		t.Errorf("error logger called with (%q, %q, %v), want (%q, %q, %v)", loggedName, loggedDID, loggedErr, "best-effort", "did:plc:alice", wantErr)
+	}
+}
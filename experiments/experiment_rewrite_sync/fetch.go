@@ -0,0 +1,143 @@
+package backfill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+// fetchGroup coalesces concurrent FetchRepo calls writing to the same
+// destPath, so repeated or racing callers share one download instead of
+// each hitting the PDS and writing the same file independently.
+var fetchGroup singleflightGroup
+
+// FetchRepo downloads did's repo CAR file from pdsEndpoint's
+// com.atproto.sync.getRepo endpoint into destPath, resuming a partial
+// download if destPath already exists.
+func FetchRepo(ctx context.Context, httpClient *http.Client, pdsEndpoint, did, destPath string) (int64, error) {
+	key := pdsEndpoint + "|" + did + "|" + destPath
+	v, err, _ := fetchGroup.do(key, func() (interface{}, error) {
+		url := fmt.Sprintf("%s/xrpc/com.atproto.sync.getRepo?did=%s", pdsEndpoint, did)
+		return fetchToFile(ctx, httpClient, url, destPath)
+	})
+	return v.(int64), err
+}
+
+// repoSize returns the repo's size in bytes as reported by getRepo's
+// Content-Length header, without downloading the body. A negative result
+// means the server didn't report a size and the caller should just attempt
+// the full download.
+func repoSize(ctx context.Context, httpClient *http.Client, pdsEndpoint, did string) (int64, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	url := fmt.Sprintf("%s/xrpc/com.atproto.sync.getRepo?did=%s", pdsEndpoint, did)
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return -1, fmt.Errorf("repo size %s: %w", did, err)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return -1, fmt.Errorf("repo size %s: %w", did, err)
+	}
+	defer resp.Body.Close()
+
+	return resp.ContentLength, nil
+}
+
+// FetchRepoOrListRecords downloads did's repo as a single CAR file via
+// FetchRepo, unless the repo's reported size exceeds maxRepoSizeBytes (0
+// meaning no limit), in which case it falls back to paging
+// com.atproto.repo.listRecords for each of collections instead of skipping
+// the account entirely.
+func FetchRepoOrListRecords(ctx context.Context, httpClient *http.Client, pdsEndpoint, did, destPath string, maxRepoSizeBytes int64, collections []string) ([]Record, int64, error) {
+	if maxRepoSizeBytes > 0 {
+		size, err := repoSize(ctx, httpClient, pdsEndpoint, did)
+		if err == nil && size > maxRepoSizeBytes {
+			if len(collections) == 0 {
+				return nil, size, fmt.Errorf("fetch repo %s: %w", did, ErrRepoTooLarge)
+			}
+			var records []Record
+			for _, collection := range collections {
+				page, err := ListRecords(ctx, httpClient, pdsEndpoint, did, collection)
+				if err != nil {
+					return records, 0, err
+				}
+				records = append(records, page...)
+			}
+			return records, size, nil
+		}
+	}
+
+	written, err := FetchRepo(ctx, httpClient, pdsEndpoint, did, destPath)
+	return nil, written, err
+}
+
+// fetchToFile GETs url into destPath. If destPath already exists (e.g.
+// from a prior interrupted run), it resumes the download with an HTTP
+// Range request starting at the existing file's size, falling back to a
+// full restart if the server doesn't honor Range.
+func fetchToFile(ctx context.Context, httpClient *http.Client, url, destPath string) (int64, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	var resumeFrom int64
+	if info, err := os.Stat(destPath); err == nil {
+		resumeFrom = info.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("fetch %s: %w", url, err)
+	}
+	if resumeFrom > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", resumeFrom))
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return 0, fmt.Errorf("fetch %s: %w", url, ErrPDSTimeout)
+		}
+		return 0, fmt.Errorf("fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	openFlags := os.O_CREATE | os.O_WRONLY
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		openFlags |= os.O_APPEND
+	case http.StatusOK:
+		// Server ignored (or we didn't send) the Range request; restart
+		// from scratch so we don't duplicate the already-written bytes.
+		resumeFrom = 0
+		openFlags |= os.O_TRUNC
+	case http.StatusTooManyRequests:
+		return 0, fmt.Errorf("fetch %s: %w", url, ErrRateLimited)
+	case http.StatusGone:
+		return 0, fmt.Errorf("fetch %s: %w", url, ErrTakenDown)
+	case http.StatusGatewayTimeout, http.StatusRequestTimeout:
+		return 0, fmt.Errorf("fetch %s: %w", url, ErrPDSTimeout)
+	default:
+		return 0, fmt.Errorf("fetch %s: server returned %s", url, resp.Status)
+	}
+
+	f, err := os.OpenFile(destPath, openFlags, 0o644)
+	if err != nil {
+		return 0, fmt.Errorf("fetch %s: open %s: %w", url, destPath, err)
+	}
+	defer f.Close()
+
+	written, err := io.Copy(f, resp.Body)
+	if err != nil {
+		return resumeFrom + written, fmt.Errorf("fetch %s: %w", url, err)
+	}
+
+	return resumeFrom + written, nil
+}
@@ -0,0 +1,151 @@
+package backfill
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetchToFileResumesWithRange(t *testing.T) {
+	full := "this is the full repo car file contents"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rangeHeader := r.Header.Get("Range")
+		if rangeHeader == "" {
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(full))
+			return
+		}
+
+		offset, ok := parseRangeOffset(rangeHeader)
+		if !ok {
+			t.Fatalf("bad range header %q", rangeHeader)
+		}
+		w.WriteHeader(http.StatusPartialContent)
+		_, _ = w.Write([]byte(full[offset:]))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	destPath := filepath.Join(dir, "repo.car")
+	if err := os.WriteFile(destPath, []byte(full[:10]), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := fetchToFile(context.Background(), srv.Client(), srv.URL, destPath)
+	if err != nil {
+		t.Fatalf("fetchToFile() error = %v", err)
+	}
+	if n != int64(len(full)) {
+		t.Errorf("fetchToFile() bytes = %d, want %d", n, len(full))
+	}
+
+	got, err := os.ReadFile(destPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != full {
+		t.Errorf("resumed file = %q, want %q", got, full)
+	}
+}
+
+func TestFetchToFileFullRestartWhenNoRangeSupport(t *testing.T) {
+	full := "fresh content from an uncooperative server"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Always returns 200, ignoring any Range header.
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(full))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	destPath := filepath.Join(dir, "repo.car")
+	if err := os.WriteFile(destPath, []byte("stale partial data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := fetchToFile(context.Background(), srv.Client(), srv.URL, destPath)
+	if err != nil {
+		t.Fatalf("fetchToFile() error = %v", err)
+	}
+	if n != int64(len(full)) {
+		t.Errorf("fetchToFile() bytes = %d, want %d", n, len(full))
+	}
+
+	got, err := os.ReadFile(destPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != full {
+		t.Errorf("restarted file = %q, want %q", got, full)
+	}
+}
+
+func TestFetchRepoCoalescesConcurrentDuplicateCalls(t *testing.T) {
+	car := "pretend car file contents"
+	var calls int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(car))
+	}))
+	defer srv.Close()
+
+	destPath := filepath.Join(t.TempDir(), "repo.car")
+
+	const n = 5
+	var wg sync.WaitGroup
+	written := make([]int64, n)
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			written[i], errs[i] = FetchRepo(context.Background(), srv.Client(), srv.URL, "did:plc:alice", destPath)
+		}(i)
+	}
+
+	time.Sleep(20 * time.Millisecond) // give every goroutine a chance to join the in-flight fetch
+	close(release)
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("PDS got %d requests for %d concurrent duplicate FetchRepo calls, want 1", got, n)
+	}
+	for i := range written {
+		if errs[i] != nil {
+			t.Fatalf("FetchRepo()[%d] error = %v", i, errs[i])
+		}
+		if written[i] != int64(len(car)) {
+			t.Errorf("FetchRepo()[%d] bytes = %d, want %d", i, written[i], len(car))
+		}
+	}
+}
+
+// parseRangeOffset extracts the start offset from a "bytes=N-" Range header.
+func parseRangeOffset(header string) (int, bool) {
+	rest, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return 0, false
+	}
+	start, _, ok := strings.Cut(rest, "-")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(start)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,311 @@
+package backfill
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileSink writes records to JSON-lines shards under Dir, one shard per
+// partition key (by default, one per DID; see WithPartitionKey), optionally
+// encrypted at rest (see WithEncryptionKey). It's the simplest concrete
+// Sink; database and fanout sinks live in their own files (see
+// PostgresSink, SQLFileSink, FanoutSink).
+type FileSink struct {
+	dir           string
+	encryptionKey []byte
+	partitionKey  func(did string, rec Record) string
+	batchSize     int
+	flushInterval time.Duration
+	commitDelta   bool
+
+	stopCh  chan struct{}
+	stopped sync.WaitGroup
+
+	mu      sync.Mutex
+	files   map[string]*shardFile
+	records map[string]int
+}
+
+type shardFile struct {
+	enc       *json.Encoder
+	bw        *bufio.Writer
+	w         writeCloser
+	tmpPath   string
+	finalPath string
+	unflushed int
+}
+
+// writeCloser is the subset of os.File that both a plain file and an
+// encrypting wrapper around one satisfy.
+type writeCloser interface {
+	Write(p []byte) (int, error)
+	Close() error
+}
+
+// FileSinkOption configures a FileSink constructed with NewFileSink.
+type FileSinkOption func(*FileSink)
+
+// WithEncryptionKey encrypts every shard FileSink writes with AES-GCM under
+// key (which must be 16, 24, or 32 bytes, selecting AES-128/192/256), for
+// output that must be encrypted at rest before it reaches shared storage.
+func WithEncryptionKey(key []byte) FileSinkOption {
+	return func(s *FileSink) { s.encryptionKey = key }
+}
+
+// WithPartitionKey changes how FileSink groups records into shards. The
+// default (nil) partitions by did, one shard per DID; pass
+// CollectionPartitionKey to instead write one shard per AT Protocol
+// collection (posts, likes, follows, ...) across all DIDs.
+func WithPartitionKey(fn func(did string, rec Record) string) FileSinkOption {
+	return func(s *FileSink) { s.partitionKey = fn }
+}
+
+// WithFileSinkBatchSize buffers this many records per shard in memory
+// before flushing them to disk as a single write, instead of the default
+// of flushing every record as soon as it's written. Pair with
+// WithFileSinkFlushInterval so a slow trickle of records into an
+// otherwise-idle shard doesn't sit unflushed indefinitely.
+func WithFileSinkBatchSize(n int) FileSinkOption {
+	return func(s *FileSink) { s.batchSize = n }
+}
+
+// WithFileSinkFlushInterval makes FileSink flush every open shard's
+// buffered records at least this often even if WithFileSinkBatchSize's
+// threshold hasn't been reached. Zero (the default) disables the interval
+// flush; Close always flushes everything regardless.
+func WithFileSinkFlushInterval(d time.Duration) FileSinkOption {
+	return func(s *FileSink) { s.flushInterval = d }
+}
+
+// WithCommitDeltaFormat writes every record and tombstone as a
+// CommitDelta instead of a bare Record or Tombstone, preserving enough
+// commit structure (op, uri, cid, record) for a consumer to reconstruct
+// exact repo evolution instead of just a flattened current-state view.
+// Rev and Seq are left zero for every delta FileSink produces today,
+// since listRecords/CAR snapshots — FileSink's only input so far — carry
+// no commit sequence (see CommitDeltaFromRecord).
+func WithCommitDeltaFormat() FileSinkOption {
+	return func(s *FileSink) { s.commitDelta = true }
+}
+
+// CollectionPartitionKey is a FileSink partition key that groups records by
+// their AT Protocol collection (e.g. "app.bsky.feed.post") instead of by
+// DID. Records whose URI doesn't parse fall into an "unknown" shard rather
+// than erroring, since mis-shaped output shouldn't abort an otherwise
+// healthy run.
+func CollectionPartitionKey(did string, rec Record) string {
+	_, collection, _, err := ParseATURI(rec.URI)
+	if err != nil {
+		return "unknown"
+	}
+	return collection
+}
+
+// NewFileSink creates a FileSink that writes shards under dir, creating dir
+// if it doesn't exist.
+func NewFileSink(dir string, opts ...FileSinkOption) (*FileSink, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("new file sink: %w", err)
+	}
+
+	s := &FileSink{dir: dir, files: make(map[string]*shardFile), records: make(map[string]int)}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.flushInterval > 0 {
+		s.stopCh = make(chan struct{})
+		s.stopped.Add(1)
+		go s.flushLoop()
+	}
+	return s, nil
+}
+
+// flushLoop periodically flushes every open shard's buffered writer until
+// stopCh is closed, backing WithFileSinkFlushInterval. Flush errors here
+// are swallowed: the next Write to the affected shard will hit the same
+// underlying I/O error and report it to its caller.
+func (s *FileSink) flushLoop() {
+	defer s.stopped.Done()
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = s.Flush()
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// Flush flushes every open shard's buffered writer to its underlying file
+// without closing anything, so buffered-but-not-yet-written records (see
+// WithFileSinkBatchSize) become visible to anything reading a shard
+// mid-run.
+func (s *FileSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, f := range s.files {
+		if err := f.bw.Flush(); err != nil {
+			return fmt.Errorf("flush shard for %s: %w", key, err)
+		}
+		f.unflushed = 0
+	}
+	return nil
+}
+
+// Write appends rec to its shard (by default, did's shard; see
+// WithPartitionKey), opening the shard on first use.
+func (s *FileSink) Write(ctx context.Context, did string, rec Record) error {
+	key := did
+	if s.partitionKey != nil {
+		key = s.partitionKey(did, rec)
+	}
+	if s.commitDelta {
+		return s.writeToShard(key, CommitDeltaFromRecord(did, rec))
+	}
+	return s.writeToShard(key, rec)
+}
+
+// WriteTombstone appends t to its shard, interleaved with the records
+// already written there, so a consumer reading a shard back can apply
+// deletions in the same order they happened instead of needing a second
+// pass over a separate tombstone file. Partitioning matches Write: by did
+// by default, or by t.URI's collection when WithPartitionKey is set to
+// CollectionPartitionKey (a URI that fails to parse falls into the same
+// "unknown" shard CollectionPartitionKey itself uses).
+func (s *FileSink) WriteTombstone(ctx context.Context, did string, t Tombstone) error {
+	key := did
+	if s.partitionKey != nil {
+		if _, collection, _, err := ParseATURI(t.URI); err == nil {
+			key = collection
+		} else {
+			key = "unknown"
+		}
+	}
+	if s.commitDelta {
+		return s.writeToShard(key, CommitDeltaFromTombstone(did, t))
+	}
+	return s.writeToShard(key, t)
+}
+
+// writeToShard JSON-encodes v as the next line of key's shard, opening the
+// shard on first use, and backs both Write and WriteTombstone.
+func (s *FileSink) writeToShard(key string, v any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.files[key]
+	if !ok {
+		var err error
+		f, err = s.openShard(key)
+		if err != nil {
+			return err
+		}
+		s.files[key] = f
+	}
+
+	if err := f.enc.Encode(v); err != nil {
+		return fmt.Errorf("write shard for %s: %w", key, err)
+	}
+	s.records[key]++
+
+	f.unflushed++
+	if f.unflushed >= max(s.batchSize, 1) {
+		if err := f.bw.Flush(); err != nil {
+			return fmt.Errorf("flush shard for %s: %w", key, err)
+		}
+		f.unflushed = 0
+	}
+	return nil
+}
+
+func (s *FileSink) openShard(key string) (*shardFile, error) {
+	finalPath := filepath.Join(s.dir, shardFileName(key))
+	tmpPath := finalPath + ".tmp"
+
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open shard for %s: %w", key, err)
+	}
+
+	var w writeCloser = file
+	if s.encryptionKey != nil {
+		w, err = newEncryptingWriteCloser(file, s.encryptionKey)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("open shard for %s: %w", key, err)
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	return &shardFile{enc: json.NewEncoder(bw), bw: bw, w: w, tmpPath: tmpPath, finalPath: finalPath}, nil
+}
+
+// Close flushes every open shard, atomically renames each one from its temp
+// name to its final name, and writes a _SUCCESS marker for the run once
+// every shard has been renamed — so a poller watching Dir never observes a
+// half-written shard, and only proceeds once it sees _SUCCESS.
+func (s *FileSink) Close() error {
+	if s.stopCh != nil {
+		close(s.stopCh)
+		s.stopped.Wait()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var firstErr error
+	for did, f := range s.files {
+		if err := f.bw.Flush(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("flush shard for %s: %w", did, err)
+		}
+		if err := f.w.Close(); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("close shard for %s: %w", did, err)
+			}
+			continue
+		}
+		if err := os.Rename(f.tmpPath, f.finalPath); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("finalize shard for %s: %w", did, err)
+		}
+	}
+	s.files = make(map[string]*shardFile)
+	if firstErr != nil {
+		return firstErr
+	}
+
+	successPath := filepath.Join(s.dir, "_SUCCESS")
+	if err := os.WriteFile(successPath, nil, 0o644); err != nil {
+		return fmt.Errorf("write _SUCCESS marker: %w", err)
+	}
+	return nil
+}
+
+// FileSinkShardName returns the filesystem-safe shard file name a FileSink
+// using the default (by-did) partitioning writes key's records to, so a
+// tool outside this package (e.g. bsky-backfill's validate mode) can
+// locate a DID's previously-written shard without duplicating the naming
+// scheme.
+func FileSinkShardName(key string) string { return shardFileName(key) }
+
+// shardFileName derives a filesystem-safe shard name from a partition key,
+// e.g. the DID "did:plc:abc123" -> "did_plc_abc123.jsonl", or the
+// collection "app.bsky.feed.post" -> "app_bsky_feed_post.jsonl".
+func shardFileName(key string) string {
+	safe := make([]rune, 0, len(key))
+	for _, r := range key {
+		if r == ':' || r == '.' || r == '/' {
+			safe = append(safe, '_')
+			continue
+		}
+		safe = append(safe, r)
+	}
+	return string(safe) + ".jsonl"
+}
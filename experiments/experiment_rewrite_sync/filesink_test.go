@@ -0,0 +1,403 @@
+package backfill
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileSinkWritesOneShardPerDID(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(dir)
+	if err != nil {
+		t.Fatalf("NewFileSink() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := sink.Write(ctx, "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := sink.Write(ctx, "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/2"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	body, err := os.ReadFile(filepath.Join(dir, "did_plc_alice.jsonl"))
+	if err != nil {
+		t.Fatalf("read shard: %v", err)
+	}
+
+	var lines int
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("unmarshal line: %v", err)
+		}
+		lines++
+	}
+	if lines != 2 {
+		t.Errorf("got %d lines, want 2", lines)
+	}
+}
+
+func TestFileSinkWriteTombstoneInterleavesWithRecordsInDIDShard(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(dir)
+	if err != nil {
+		t.Fatalf("NewFileSink() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := sink.Write(ctx, "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	deletedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := sink.WriteTombstone(ctx, "did:plc:alice", Tombstone{
+		URI:    "at://did:plc:alice/app.bsky.feed.post/1",
+		Action: TombstoneActionDelete,
+		Time:   deletedAt,
+	}); err != nil {
+		t.Fatalf("WriteTombstone() error = %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	body, err := os.ReadFile(filepath.Join(dir, "did_plc_alice.jsonl"))
+	if err != nil {
+		t.Fatalf("read shard: %v", err)
+	}
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		lines = append(lines, append([]byte(nil), scanner.Bytes()...))
+	}
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+
+	var tomb Tombstone
+	if err := json.Unmarshal(lines[1], &tomb); err != nil {
+		t.Fatalf("unmarshal tombstone line: %v", err)
+	}
+	if tomb.Action != TombstoneActionDelete || !tomb.Time.Equal(deletedAt) {
+		t.Errorf("got tombstone %+v, want action %q at %v", tomb, TombstoneActionDelete, deletedAt)
+	}
+}
+
+func TestFileSinkWriteTombstonePartitionsByCollectionWhenConfigured(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(dir, WithPartitionKey(CollectionPartitionKey))
+	if err != nil {
+		t.Fatalf("NewFileSink() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := sink.WriteTombstone(ctx, "did:plc:alice", Tombstone{
+		URI:    "at://did:plc:alice/app.bsky.feed.post/1",
+		Action: TombstoneActionDelete,
+		Time:   time.Now().UTC(),
+	}); err != nil {
+		t.Fatalf("WriteTombstone() error = %v", err)
+	}
+	if err := sink.WriteTombstone(ctx, "did:plc:bob", Tombstone{
+		Action: TombstoneActionAccount,
+		Time:   time.Now().UTC(),
+	}); err != nil {
+		t.Fatalf("WriteTombstone() error = %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "app_bsky_feed_post.jsonl")); err != nil {
+		t.Errorf("expected collection shard for post tombstone: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "unknown.jsonl")); err != nil {
+		t.Errorf("expected unknown shard for tombstone with no parseable URI: %v", err)
+	}
+}
+
+func TestFileSinkWithCommitDeltaFormatWritesRecordsAndTombstonesAsDeltas(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(dir, WithCommitDeltaFormat())
+	if err != nil {
+		t.Fatalf("NewFileSink() error = %v", err)
+	}
+
+	ctx := context.Background()
+	rec := Record{URI: "at://did:plc:alice/app.bsky.feed.post/1", CID: "bafycid", Value: json.RawMessage(`{"text":"hi"}`)}
+	if err := sink.Write(ctx, "did:plc:alice", rec); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := sink.WriteTombstone(ctx, "did:plc:alice", Tombstone{
+		URI:    rec.URI,
+		Action: TombstoneActionDelete,
+		Time:   time.Now().UTC(),
+	}); err != nil {
+		t.Fatalf("WriteTombstone() error = %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	body, err := os.ReadFile(filepath.Join(dir, "did_plc_alice.jsonl"))
+	if err != nil {
+		t.Fatalf("read shard: %v", err)
+	}
+
+	var deltas []CommitDelta
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		var d CommitDelta
+		if err := json.Unmarshal(scanner.Bytes(), &d); err != nil {
+			t.Fatalf("unmarshal delta line: %v", err)
+		}
+		deltas = append(deltas, d)
+	}
+	if len(deltas) != 2 {
+		t.Fatalf("got %d deltas, want 2", len(deltas))
+	}
+	if deltas[0].Op != CommitDeltaOpCreate || deltas[0].CID != "bafycid" {
+		t.Errorf("got first delta %+v, want op %q with cid bafycid", deltas[0], CommitDeltaOpCreate)
+	}
+	if deltas[1].Op != CommitDeltaOpDelete || deltas[1].URI != rec.URI {
+		t.Errorf("got second delta %+v, want op %q for uri %s", deltas[1], CommitDeltaOpDelete, rec.URI)
+	}
+}
+
+func TestFileSinkShardNameMatchesWrittenShard(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(dir)
+	if err != nil {
+		t.Fatalf("NewFileSink() error = %v", err)
+	}
+	if err := sink.Write(context.Background(), "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, FileSinkShardName("did:plc:alice"))); err != nil {
+		t.Errorf("FileSinkShardName() didn't match the shard FileSink actually wrote: %v", err)
+	}
+}
+
+func TestFileSinkEncryptsShardsWithAESGCM(t *testing.T) {
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte("k"), 32)
+
+	sink, err := NewFileSink(dir, WithEncryptionKey(key))
+	if err != nil {
+		t.Fatalf("NewFileSink() error = %v", err)
+	}
+
+	rec := Record{URI: "at://did:plc:alice/app.bsky.feed.post/1", CID: "bafycid"}
+	if err := sink.Write(context.Background(), "did:plc:alice", rec); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	f, err := os.Open(filepath.Join(dir, "did_plc_alice.jsonl"))
+	if err != nil {
+		t.Fatalf("open shard: %v", err)
+	}
+	defer f.Close()
+
+	if bytes.Contains(mustReadAll(t, f), []byte("bafycid")) {
+		t.Fatal("shard contains plaintext CID, want it encrypted")
+	}
+
+	f.Seek(0, 0)
+	chunks, err := DecryptShard(f, key)
+	if err != nil {
+		t.Fatalf("DecryptShard() error = %v", err)
+	}
+	if len(chunks) != 1 {
+		t.Fatalf("got %d chunks, want 1", len(chunks))
+	}
+
+	var got Record
+	if err := json.Unmarshal(chunks[0], &got); err != nil {
+		t.Fatalf("unmarshal decrypted chunk: %v", err)
+	}
+	if got.CID != "bafycid" {
+		t.Errorf("CID = %q, want %q", got.CID, "bafycid")
+	}
+}
+
+func TestFileSinkWritesToTempNameUntilCloseAndEmitsSuccessMarker(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(dir)
+	if err != nil {
+		t.Fatalf("NewFileSink() error = %v", err)
+	}
+
+	if err := sink.Write(context.Background(), "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	finalPath := filepath.Join(dir, "did_plc_alice.jsonl")
+	if _, err := os.Stat(finalPath); err == nil {
+		t.Fatal("final shard path exists before Close, want only the temp file")
+	}
+	if _, err := os.Stat(finalPath + ".tmp"); err != nil {
+		t.Fatalf("temp shard path missing before Close: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "_SUCCESS")); err == nil {
+		t.Fatal("_SUCCESS marker exists before Close")
+	}
+
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if _, err := os.Stat(finalPath); err != nil {
+		t.Fatalf("final shard path missing after Close: %v", err)
+	}
+	if _, err := os.Stat(finalPath + ".tmp"); err == nil {
+		t.Fatal("temp shard path still exists after Close")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "_SUCCESS")); err != nil {
+		t.Fatalf("_SUCCESS marker missing after Close: %v", err)
+	}
+}
+
+func TestFileSinkPartitionsByCollectionWhenConfigured(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(dir, WithPartitionKey(CollectionPartitionKey))
+	if err != nil {
+		t.Fatalf("NewFileSink() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := sink.Write(ctx, "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := sink.Write(ctx, "did:plc:bob", Record{URI: "at://did:plc:bob/app.bsky.feed.post/1"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := sink.Write(ctx, "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.like/1"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "app_bsky_feed_post.jsonl")); err != nil {
+		t.Errorf("missing app_bsky_feed_post.jsonl shard: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app_bsky_feed_like.jsonl")); err != nil {
+		t.Errorf("missing app_bsky_feed_like.jsonl shard: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "did_plc_alice.jsonl")); err == nil {
+		t.Error("found a per-DID shard, want records partitioned by collection only")
+	}
+
+	m, err := sink.Manifest()
+	if err != nil {
+		t.Fatalf("Manifest() error = %v", err)
+	}
+	counts := make(map[string]int, len(m.Entries))
+	for _, e := range m.Entries {
+		counts[e.MinDID] = e.Records
+	}
+	if counts["app.bsky.feed.post"] != 2 {
+		t.Errorf("app.bsky.feed.post records = %d, want 2", counts["app.bsky.feed.post"])
+	}
+	if counts["app.bsky.feed.like"] != 1 {
+		t.Errorf("app.bsky.feed.like records = %d, want 1", counts["app.bsky.feed.like"])
+	}
+}
+
+func TestFileSinkWithBatchSizeDoesNotFlushUntilTheBatchFills(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(dir, WithFileSinkBatchSize(2))
+	if err != nil {
+		t.Fatalf("NewFileSink() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := sink.Write(ctx, "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	path := filepath.Join(dir, "did_plc_alice.jsonl.tmp")
+	if got := countLines(t, path); got != 0 {
+		t.Fatalf("after 1 of 2 batched writes, on-disk lines = %d, want 0 (still buffered)", got)
+	}
+
+	if err := sink.Write(ctx, "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/2"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if got := countLines(t, path); got != 2 {
+		t.Fatalf("after the batch filled, on-disk lines = %d, want 2", got)
+	}
+
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+}
+
+func TestFileSinkWithFlushIntervalFlushesAPartialBatch(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(dir, WithFileSinkBatchSize(1000), WithFileSinkFlushInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewFileSink() error = %v", err)
+	}
+	defer sink.Close()
+
+	if err := sink.Write(context.Background(), "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	path := filepath.Join(dir, "did_plc_alice.jsonl.tmp")
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if countLines(t, path) == 1 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("flush interval never flushed the buffered record to %s", path)
+}
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0
+		}
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	var lines int
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines++
+	}
+	return lines
+}
+
+func mustReadAll(t *testing.T, f *os.File) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(f); err != nil {
+		t.Fatalf("read shard: %v", err)
+	}
+	return buf.Bytes()
+}
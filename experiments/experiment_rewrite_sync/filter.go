@@ -0,0 +1,61 @@
+package backfill
+
+// FilterDecision records whether a resolved DID was kept or skipped for a
+// run, and why, so callers can audit exactly what a run did and didn't
+// touch.
+type FilterDecision struct {
+	Allowed bool
+	Reason  string
+}
+
+// applyFilters decides whether resolved should be processed under cfg.
+// Block lists always win over allow lists; an empty allow list means
+// "everything is allowed" for that dimension.
+func applyFilters(cfg Config, resolved ResolvedDID) FilterDecision {
+	if containsFold(cfg.BlockDIDs, resolved.DID) {
+		return FilterDecision{Allowed: false, Reason: "did blocklisted"}
+	}
+	if containsFold(cfg.BlockHosts, resolved.PDSHost) {
+		return FilterDecision{Allowed: false, Reason: "pds host blocklisted"}
+	}
+	if len(cfg.AllowDIDs) > 0 && !containsFold(cfg.AllowDIDs, resolved.DID) {
+		return FilterDecision{Allowed: false, Reason: "did not in allowlist"}
+	}
+	if cfg.AllowDIDSet != nil && cfg.AllowDIDSet.Len() > 0 && !cfg.AllowDIDSet.Contains(resolved.DID) {
+		return FilterDecision{Allowed: false, Reason: "did not in hot-reloadable allowlist"}
+	}
+	if len(cfg.AllowHosts) > 0 && !containsFold(cfg.AllowHosts, resolved.PDSHost) {
+		return FilterDecision{Allowed: false, Reason: "pds host not in allowlist"}
+	}
+	return FilterDecision{Allowed: true}
+}
+
+func containsFold(list []string, want string) bool {
+	for _, s := range list {
+		if equalFold(s, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// equalFold is a tiny case-insensitive ASCII compare; DIDs and hostnames in
+// practice are ASCII, so we avoid pulling in unicode-aware folding.
+func equalFold(a, b string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := 0; i < len(a); i++ {
+		ca, cb := a[i], b[i]
+		if 'A' <= ca && ca <= 'Z' {
+			ca += 'a' - 'A'
+		}
+		if 'A' <= cb && cb <= 'Z' {
+			cb += 'a' - 'A'
+		}
+		if ca != cb {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package backfill
+
+import "testing"
+
+func TestApplyFilters(t *testing.T) {
+	cases := []struct {
+		name    string
+		cfg     Config
+		did     ResolvedDID
+		allowed bool
+	}{
+		{
+			name:    "no filters allows everything",
+			cfg:     Config{},
+			did:     ResolvedDID{DID: "did:plc:abc", PDSHost: "bsky.social"},
+			allowed: true,
+		},
+		{
+			name:    "blocked did wins over everything",
+			cfg:     Config{BlockDIDs: []string{"did:plc:abc"}, AllowDIDs: []string{"did:plc:abc"}},
+			did:     ResolvedDID{DID: "did:plc:abc", PDSHost: "bsky.social"},
+			allowed: false,
+		},
+		{
+			name:    "blocked host",
+			cfg:     Config{BlockHosts: []string{"spam.example"}},
+			did:     ResolvedDID{DID: "did:plc:abc", PDSHost: "spam.example"},
+			allowed: false,
+		},
+		{
+			name:    "allowlist excludes did not listed",
+			cfg:     Config{AllowDIDs: []string{"did:plc:other"}},
+			did:     ResolvedDID{DID: "did:plc:abc", PDSHost: "bsky.social"},
+			allowed: false,
+		},
+		{
+			name:    "host allowlist is case-insensitive",
+			cfg:     Config{AllowHosts: []string{"BSKY.SOCIAL"}},
+			did:     ResolvedDID{DID: "did:plc:abc", PDSHost: "bsky.social"},
+			allowed: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := applyFilters(tc.cfg, tc.did)
+			if got.Allowed != tc.allowed {
+				t.Errorf("applyFilters() allowed = %v, want %v (reason: %q)", got.Allowed, tc.allowed, got.Reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,500 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FilterExpr is a compiled record filter expression, e.g.
+//
+//	collection == "app.bsky.feed.post" && record.text.contains("climate")
+//
+// Paths resolve against: did, uri, cid, collection, rkey (all strings
+// derived from the record), and record, a map of the record's decoded JSON
+// value for field access like record.text or record.langs. Supported
+// operators are ==, !=, &&, ||, and ! (negation); the only methods are
+// contains, startsWith, and endsWith on string operands. This is a small
+// hand-rolled subset, not a CEL or jq implementation — this module vendors
+// no expression-language dependency, and these operators cover the
+// "narrow an extraction without a post-processing pass" use case the
+// request is after.
+type FilterExpr struct {
+	root filterNode
+}
+
+// CompileFilter parses expr into a FilterExpr that can be evaluated
+// repeatedly against many records via Match.
+func CompileFilter(expr string) (*FilterExpr, error) {
+	p := &filterParser{tokens: tokenizeFilter(expr)}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("compile filter: %w", err)
+	}
+	if !p.atEnd() {
+		return nil, fmt.Errorf("compile filter: unexpected token %q", p.peek().text)
+	}
+	return &FilterExpr{root: node}, nil
+}
+
+// Match evaluates the expression against did's rec, returning whether it
+// matches.
+func (f *FilterExpr) Match(did string, rec Record) (bool, error) {
+	ctx := buildFilterContext(did, rec)
+	v, err := f.root.eval(ctx)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("filter expression evaluated to %T, want bool", v)
+	}
+	return b, nil
+}
+
+// FilterRecordSink wraps another Sink and only forwards records that match
+// expr, so a --filter expression can narrow a run's output without a
+// separate post-processing pass.
+type FilterRecordSink struct {
+	expr *FilterExpr
+	next Sink
+}
+
+// NewFilterRecordSink wraps next so only records matching expr are written.
+func NewFilterRecordSink(expr *FilterExpr, next Sink) *FilterRecordSink {
+	return &FilterRecordSink{expr: expr, next: next}
+}
+
+func (s *FilterRecordSink) Write(ctx context.Context, did string, rec Record) error {
+	ok, err := s.expr.Match(did, rec)
+	if err != nil {
+		return fmt.Errorf("evaluate filter for %s: %w", did, err)
+	}
+	if !ok {
+		return nil
+	}
+	return s.next.Write(ctx, did, rec)
+}
+
+type filterContext struct {
+	did        string
+	uri        string
+	cid        string
+	collection string
+	rkey       string
+	record     map[string]any
+}
+
+func buildFilterContext(did string, rec Record) filterContext {
+	var collection, rkey string
+	if _, c, r, err := ParseATURI(rec.URI); err == nil {
+		collection, rkey = c, r
+	}
+
+	var record map[string]any
+	_ = json.Unmarshal(rec.Value, &record) // best effort; nil map if not an object
+
+	return filterContext{did: did, uri: rec.URI, cid: rec.CID, collection: collection, rkey: rkey, record: record}
+}
+
+func (c filterContext) lookup(name string) (any, bool) {
+	switch name {
+	case "did":
+		return c.did, true
+	case "uri":
+		return c.uri, true
+	case "cid":
+		return c.cid, true
+	case "collection":
+		return c.collection, true
+	case "rkey":
+		return c.rkey, true
+	case "record":
+		return c.record, true
+	default:
+		return nil, false
+	}
+}
+
+// filterNode is one node of a compiled filter expression's AST.
+type filterNode interface {
+	eval(ctx filterContext) (any, error)
+}
+
+type litNode struct{ value any }
+
+func (n litNode) eval(ctx filterContext) (any, error) { return n.value, nil }
+
+type pathNode struct{ parts []string }
+
+func (n pathNode) eval(ctx filterContext) (any, error) {
+	v, ok := ctx.lookup(n.parts[0])
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q", n.parts[0])
+	}
+	for _, part := range n.parts[1:] {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("cannot access field %q on non-object value", part)
+		}
+		v, ok = m[part]
+		if !ok {
+			return nil, nil
+		}
+	}
+	return v, nil
+}
+
+type notNode struct{ operand filterNode }
+
+func (n notNode) eval(ctx filterContext) (any, error) {
+	v, err := n.operand.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return nil, fmt.Errorf("! requires a bool operand, got %T", v)
+	}
+	return !b, nil
+}
+
+type binaryNode struct {
+	op          string
+	left, right filterNode
+}
+
+func (n binaryNode) eval(ctx filterContext) (any, error) {
+	switch n.op {
+	case "&&", "||":
+		l, err := n.evalBool(ctx, n.left)
+		if err != nil {
+			return nil, err
+		}
+		if n.op == "&&" && !l {
+			return false, nil
+		}
+		if n.op == "||" && l {
+			return true, nil
+		}
+		return n.evalBool(ctx, n.right)
+	case "==", "!=":
+		l, err := n.left.eval(ctx)
+		if err != nil {
+			return nil, err
+		}
+		r, err := n.right.eval(ctx)
+		if err != nil {
+			return nil, err
+		}
+		eq := fmt.Sprint(l) == fmt.Sprint(r)
+		if n.op == "!=" {
+			return !eq, nil
+		}
+		return eq, nil
+	default:
+		return nil, fmt.Errorf("unknown operator %q", n.op)
+	}
+}
+
+func (n binaryNode) evalBool(ctx filterContext, node filterNode) (bool, error) {
+	v, err := node.eval(ctx)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("%s requires bool operands, got %T", n.op, v)
+	}
+	return b, nil
+}
+
+type methodCallNode struct {
+	receiver filterNode
+	method   string
+	args     []filterNode
+}
+
+func (n methodCallNode) eval(ctx filterContext) (any, error) {
+	recv, err := n.receiver.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := recv.(string)
+	if !ok {
+		return nil, fmt.Errorf("method %q requires a string receiver, got %T", n.method, recv)
+	}
+
+	if len(n.args) != 1 {
+		return nil, fmt.Errorf("method %q takes exactly one argument", n.method)
+	}
+	argVal, err := n.args[0].eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	arg, ok := argVal.(string)
+	if !ok {
+		return nil, fmt.Errorf("method %q requires a string argument, got %T", n.method, argVal)
+	}
+
+	switch n.method {
+	case "contains":
+		return strings.Contains(s, arg), nil
+	case "startsWith":
+		return strings.HasPrefix(s, arg), nil
+	case "endsWith":
+		return strings.HasSuffix(s, arg), nil
+	default:
+		return nil, fmt.Errorf("unknown method %q", n.method)
+	}
+}
+
+// -- tokenizer --
+
+type filterTokenKind int
+
+const (
+	tokEOF filterTokenKind = iota
+	tokIdent
+	tokString
+	tokLParen
+	tokRParen
+	tokComma
+	tokDot
+	tokAnd
+	tokOr
+	tokNot
+	tokEq
+	tokNeq
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+}
+
+func tokenizeFilter(expr string) []filterToken {
+	var tokens []filterToken
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n':
+			i++
+		case r == '(':
+			tokens = append(tokens, filterToken{tokLParen, "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, filterToken{tokRParen, ")"})
+			i++
+		case r == ',':
+			tokens = append(tokens, filterToken{tokComma, ","})
+			i++
+		case r == '.':
+			tokens = append(tokens, filterToken{tokDot, "."})
+			i++
+		case r == '&' && i+1 < len(runes) && runes[i+1] == '&':
+			tokens = append(tokens, filterToken{tokAnd, "&&"})
+			i += 2
+		case r == '|' && i+1 < len(runes) && runes[i+1] == '|':
+			tokens = append(tokens, filterToken{tokOr, "||"})
+			i += 2
+		case r == '=' && i+1 < len(runes) && runes[i+1] == '=':
+			tokens = append(tokens, filterToken{tokEq, "=="})
+			i += 2
+		case r == '!' && i+1 < len(runes) && runes[i+1] == '=':
+			tokens = append(tokens, filterToken{tokNeq, "!="})
+			i += 2
+		case r == '!':
+			tokens = append(tokens, filterToken{tokNot, "!"})
+			i++
+		case r == '"':
+			j := i + 1
+			for j < len(runes) && runes[j] != '"' {
+				j++
+			}
+			tokens = append(tokens, filterToken{tokString, string(runes[i+1 : j])})
+			i = j + 1
+		default:
+			j := i
+			for j < len(runes) && (isFilterIdentRune(runes[j])) {
+				j++
+			}
+			if j == i {
+				i++ // skip anything unrecognized rather than loop forever
+				continue
+			}
+			tokens = append(tokens, filterToken{tokIdent, string(runes[i:j])})
+			i = j
+		}
+	}
+	tokens = append(tokens, filterToken{tokEOF, ""})
+	return tokens
+}
+
+func isFilterIdentRune(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+// -- recursive-descent parser --
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) peek() filterToken { return p.tokens[p.pos] }
+func (p *filterParser) atEnd() bool       { return p.peek().kind == tokEOF }
+func (p *filterParser) next() filterToken {
+	t := p.tokens[p.pos]
+	p.pos++
+	return t
+}
+
+func (p *filterParser) expect(kind filterTokenKind, what string) (filterToken, error) {
+	if p.peek().kind != kind {
+		return filterToken{}, fmt.Errorf("expected %s, got %q", what, p.peek().text)
+	}
+	return p.next(), nil
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokAnd {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (filterNode, error) {
+	if p.peek().kind == tokNot {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand: operand}, nil
+	}
+	return p.parseEquality()
+}
+
+func (p *filterParser) parseEquality() (filterNode, error) {
+	left, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind == tokEq || p.peek().kind == tokNeq {
+		op := p.next()
+		right, err := p.parsePostfix()
+		if err != nil {
+			return nil, err
+		}
+		opText := "=="
+		if op.kind == tokNeq {
+			opText = "!="
+		}
+		return binaryNode{op: opText, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *filterParser) parsePostfix() (filterNode, error) {
+	node, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokDot {
+		p.next()
+		name, err := p.expect(tokIdent, "identifier")
+		if err != nil {
+			return nil, err
+		}
+
+		if p.peek().kind == tokLParen {
+			p.next()
+			var args []filterNode
+			for p.peek().kind != tokRParen {
+				arg, err := p.parseOr()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, arg)
+				if p.peek().kind == tokComma {
+					p.next()
+				}
+			}
+			if _, err := p.expect(tokRParen, ")"); err != nil {
+				return nil, err
+			}
+			node = methodCallNode{receiver: node, method: name.text, args: args}
+			continue
+		}
+
+		path, ok := node.(pathNode)
+		if !ok {
+			return nil, fmt.Errorf("cannot access field %q on a non-field expression", name.text)
+		}
+		node = pathNode{parts: append(append([]string{}, path.parts...), name.text)}
+	}
+	return node, nil
+}
+
+func (p *filterParser) parsePrimary() (filterNode, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case tokLParen:
+		p.next()
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokRParen, ")"); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case tokString:
+		p.next()
+		return litNode{value: tok.text}, nil
+	case tokIdent:
+		p.next()
+		switch tok.text {
+		case "true":
+			return litNode{value: true}, nil
+		case "false":
+			return litNode{value: false}, nil
+		}
+		if n, err := strconv.ParseFloat(tok.text, 64); err == nil {
+			return litNode{value: n}, nil
+		}
+		return pathNode{parts: []string{tok.text}}, nil
+	default:
+		return nil, fmt.Errorf("unexpected token %q", tok.text)
+	}
+}
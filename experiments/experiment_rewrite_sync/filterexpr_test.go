@@ -0,0 +1,89 @@
+package backfill
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCompileFilterEqualityAndContains(t *testing.T) {
+	expr, err := CompileFilter(`collection == "app.bsky.feed.post" && record.text.contains("climate")`)
+	if err != nil {
+		t.Fatalf("CompileFilter() error = %v", err)
+	}
+
+	match := Record{URI: "at://did:plc:alice/app.bsky.feed.post/1", Value: []byte(`{"text":"climate action now"}`)}
+	ok, err := expr.Match("did:plc:alice", match)
+	if err != nil {
+		t.Fatalf("Match() error = %v", err)
+	}
+	if !ok {
+		t.Error("Match() = false, want true")
+	}
+
+	noMatch := Record{URI: "at://did:plc:alice/app.bsky.feed.post/2", Value: []byte(`{"text":"lunch plans"}`)}
+	ok, err = expr.Match("did:plc:alice", noMatch)
+	if err != nil {
+		t.Fatalf("Match() error = %v", err)
+	}
+	if ok {
+		t.Error("Match() = true, want false")
+	}
+
+	wrongCollection := Record{URI: "at://did:plc:alice/app.bsky.feed.like/1", Value: []byte(`{"text":"climate"}`)}
+	ok, err = expr.Match("did:plc:alice", wrongCollection)
+	if err != nil {
+		t.Fatalf("Match() error = %v", err)
+	}
+	if ok {
+		t.Error("Match() = true, want false for a different collection")
+	}
+}
+
+func TestCompileFilterNegationAndOr(t *testing.T) {
+	expr, err := CompileFilter(`!(collection == "app.bsky.feed.like") || did == "did:plc:allow"`)
+	if err != nil {
+		t.Fatalf("CompileFilter() error = %v", err)
+	}
+
+	ok, err := expr.Match("did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1"})
+	if err != nil {
+		t.Fatalf("Match() error = %v", err)
+	}
+	if !ok {
+		t.Error("Match() = false, want true (not a like)")
+	}
+
+	ok, err = expr.Match("did:plc:allow", Record{URI: "at://did:plc:allow/app.bsky.feed.like/1"})
+	if err != nil {
+		t.Fatalf("Match() error = %v", err)
+	}
+	if !ok {
+		t.Error("Match() = false, want true (allowed DID overrides)")
+	}
+}
+
+func TestCompileFilterRejectsMalformedExpression(t *testing.T) {
+	if _, err := CompileFilter(`collection ==`); err == nil {
+		t.Fatal("CompileFilter() error = nil, want error for incomplete expression")
+	}
+}
+
+func TestFilterRecordSinkDropsNonMatches(t *testing.T) {
+	expr, err := CompileFilter(`collection == "app.bsky.feed.post"`)
+	if err != nil {
+		t.Fatalf("CompileFilter() error = %v", err)
+	}
+	next := &recordingSink{}
+	sink := NewFilterRecordSink(expr, next)
+
+	if err := sink.Write(context.Background(), "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := sink.Write(context.Background(), "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.like/1"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if len(next.writes) != 1 {
+		t.Fatalf("got %d writes, want 1", len(next.writes))
+	}
+}
@@ -0,0 +1,83 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ParseATURI splits an AT-URI of the form at://did/collection/rkey into its
+// parts. It returns an error if uri doesn't have the at:// scheme or is
+// missing the collection or rkey segment.
+func ParseATURI(uri string) (did, collection, rkey string, err error) {
+	rest, ok := strings.CutPrefix(uri, "at://")
+	if !ok {
+		return "", "", "", fmt.Errorf("parse AT-URI %q: missing at:// scheme", uri)
+	}
+
+	parts := strings.SplitN(rest, "/", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", "", "", fmt.Errorf("parse AT-URI %q: want at://did/collection/rkey", uri)
+	}
+
+	return parts[0], parts[1], parts[2], nil
+}
+
+// CanonicalATURI builds the at://did/collection/rkey URI for a record, so
+// callers constructing Records from listRecords/getRecord/getBlocks
+// responses don't each reimplement the format.
+func CanonicalATURI(did, collection, rkey string) string {
+	return fmt.Sprintf("at://%s/%s/%s", did, collection, rkey)
+}
+
+// GetRecord fetches a single record by AT-URI from pdsEndpoint via
+// com.atproto.repo.getRecord, for spot-checking or repairing individual rows
+// without re-syncing the whole repo.
+func GetRecord(ctx context.Context, httpClient *http.Client, pdsEndpoint, uri string) (Record, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	did, collection, rkey, err := ParseATURI(uri)
+	if err != nil {
+		return Record{}, err
+	}
+
+	q := url.Values{}
+	q.Set("repo", did)
+	q.Set("collection", collection)
+	q.Set("rkey", rkey)
+
+	reqURL := fmt.Sprintf("%s/xrpc/com.atproto.repo.getRecord?%s", pdsEndpoint, q.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return Record{}, fmt.Errorf("getRecord %s: %w", uri, err)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return Record{}, fmt.Errorf("getRecord %s: %w", uri, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Record{}, fmt.Errorf("getRecord %s: server returned %s", uri, resp.Status)
+	}
+
+	var rec Record
+	if err := json.NewDecoder(resp.Body).Decode(&rec); err != nil {
+		return Record{}, fmt.Errorf("getRecord %s: decode: %w", uri, err)
+	}
+	if rec.CID == "" {
+		return Record{}, fmt.Errorf("getRecord %s: server response has no cid", uri)
+	}
+	// Construct the canonical URI from what we asked for rather than trust
+	// whatever (if anything) the server echoed back, so every Record this
+	// tool emits carries a consistent identifier for downstream joins.
+	rec.URI = CanonicalATURI(did, collection, rkey)
+
+	return withTIDTimestamp(rec), nil
+}
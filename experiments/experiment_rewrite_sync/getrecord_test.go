@@ -0,0 +1,73 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseATURI(t *testing.T) {
+	did, collection, rkey, err := ParseATURI("at://did:plc:alice/app.bsky.feed.post/3k2x4q")
+	if err != nil {
+		t.Fatalf("ParseATURI() error = %v", err)
+	}
+	if did != "did:plc:alice" || collection != "app.bsky.feed.post" || rkey != "3k2x4q" {
+		t.Errorf("got (%q, %q, %q)", did, collection, rkey)
+	}
+}
+
+func TestParseATURIRejectsMalformed(t *testing.T) {
+	cases := []string{
+		"",
+		"did:plc:alice/app.bsky.feed.post/3k2x4q",
+		"at://did:plc:alice/app.bsky.feed.post",
+		"at://did:plc:alice",
+	}
+	for _, uri := range cases {
+		if _, _, _, err := ParseATURI(uri); err == nil {
+			t.Errorf("ParseATURI(%q) error = nil, want error", uri)
+		}
+	}
+}
+
+func TestGetRecordFetchesByATURI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("repo") != "did:plc:alice" || r.URL.Query().Get("rkey") != "3k2x4q" {
+			t.Fatalf("unexpected query: %s", r.URL.RawQuery)
+		}
+		json.NewEncoder(w).Encode(Record{
+			URI: "at://did:plc:alice/app.bsky.feed.post/3k2x4q",
+			CID: "bafycid",
+		})
+	}))
+	defer srv.Close()
+
+	rec, err := GetRecord(context.Background(), srv.Client(), srv.URL, "at://did:plc:alice/app.bsky.feed.post/3k2x4q")
+	if err != nil {
+		t.Fatalf("GetRecord() error = %v", err)
+	}
+	if rec.CID != "bafycid" {
+		t.Errorf("CID = %q, want %q", rec.CID, "bafycid")
+	}
+}
+
+func TestGetRecordRejectsMissingCID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Record{URI: "at://did:plc:alice/app.bsky.feed.post/3k2x4q"})
+	}))
+	defer srv.Close()
+
+	if _, err := GetRecord(context.Background(), srv.Client(), srv.URL, "at://did:plc:alice/app.bsky.feed.post/3k2x4q"); err == nil {
+		t.Fatal("GetRecord() error = nil, want error for missing cid")
+	}
+}
+
+func TestCanonicalATURI(t *testing.T) {
+	got := CanonicalATURI("did:plc:alice", "app.bsky.feed.post", "3k2x4q")
+	want := "at://did:plc:alice/app.bsky.feed.post/3k2x4q"
+	if got != want {
+		t.Errorf("CanonicalATURI() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,18 @@
+package backfill
+
+import "errors"
+
+// ErrGRPCUnsupported is returned by NewGRPCServer. A gRPC service needs
+// generated protobuf stubs and the google.golang.org/grpc runtime, neither
+// of which is vendored in this stdlib-only module. See NewJobServer (REST)
+// for the programmatic job-submission API this tool actually ships: it
+// covers the same "submit a job, poll progress, cancel it" shape using
+// only net/http, which needs no generated code or new dependency.
+var ErrGRPCUnsupported = errors.New("backfill: grpc service mode requires protobuf/grpc dependencies not vendored in this module; use the REST job API instead")
+
+// NewGRPCServer always returns ErrGRPCUnsupported. It exists so a --serve
+// grpc flag has somewhere to fail clearly instead of silently doing
+// nothing.
+func NewGRPCServer(addr string) (any, error) {
+	return nil, ErrGRPCUnsupported
+}
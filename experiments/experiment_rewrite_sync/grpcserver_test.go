@@ -0,0 +1,16 @@
+package backfill
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewGRPCServerReportsUnsupported(t *testing.T) {
+	srv, err := NewGRPCServer(":8080")
+	if srv != nil {
+		t.Errorf("got non-nil server, want nil")
+	}
+	if !errors.Is(err, ErrGRPCUnsupported) {
+		t.Errorf("NewGRPCServer() error = %v, want ErrGRPCUnsupported", err)
+	}
+}
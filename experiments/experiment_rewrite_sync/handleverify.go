@@ -0,0 +1,71 @@
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// VerifyHandle checks that handle's own DNS TXT record or .well-known
+// endpoint claims did, per the AT Protocol handle resolution spec: a TXT
+// record at "_atproto."+handle of the form "did=<did>", or a plain-text
+// <did> body at "https://"+handle+"/.well-known/atproto-did". DNS is
+// tried first, falling back to the well-known endpoint only if the DNS
+// lookup itself fails (e.g. no such record), matching how a client
+// resolving the handle forward would pick between the two methods.
+//
+// This is the reverse direction of what ResolveDID's alsoKnownAs field
+// gives you: alsoKnownAs is just a claim the DID document makes about
+// itself, but the PLC directory operator doesn't control handle's DNS
+// or web server, so only handle's own DNS/HTTP records can actually
+// confirm the handle belongs to did.
+func VerifyHandle(ctx context.Context, httpClient *http.Client, did, handle string) (bool, error) {
+	if ok, err := verifyHandleDNS(ctx, did, handle); err == nil {
+		return ok, nil
+	}
+	return verifyHandleWellKnown(ctx, httpClient, did, handle)
+}
+
+func verifyHandleDNS(ctx context.Context, did, handle string) (bool, error) {
+	records, err := net.DefaultResolver.LookupTXT(ctx, "_atproto."+handle)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range records {
+		if strings.TrimPrefix(r, "did=") == did {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func verifyHandleWellKnown(ctx context.Context, httpClient *http.Client, did, handle string) (bool, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	reqURL := "https://" + handle + "/.well-known/atproto-did"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return false, fmt.Errorf("verify handle %s: %w", handle, err)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("verify handle %s: %w", handle, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("verify handle %s: server returned %s", handle, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return false, fmt.Errorf("verify handle %s: %w", handle, err)
+	}
+	return strings.TrimSpace(string(body)) == did, nil
+}
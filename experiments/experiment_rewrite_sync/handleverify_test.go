@@ -0,0 +1,53 @@
+package backfill
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVerifyHandleWellKnownMatches(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/atproto-did" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("did:plc:alice\n"))
+	}))
+	defer srv.Close()
+
+	ok, err := verifyHandleWellKnown(context.Background(), srv.Client(), "did:plc:alice", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("verifyHandleWellKnown() error = %v", err)
+	}
+	if !ok {
+		t.Error("verifyHandleWellKnown() = false, want true for a matching well-known body")
+	}
+}
+
+func TestVerifyHandleWellKnownMismatch(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("did:plc:someone-else"))
+	}))
+	defer srv.Close()
+
+	ok, err := verifyHandleWellKnown(context.Background(), srv.Client(), "did:plc:alice", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("verifyHandleWellKnown() error = %v", err)
+	}
+	if ok {
+		t.Error("verifyHandleWellKnown() = true, want false when the well-known body claims a different DID")
+	}
+}
+
+func TestVerifyHandleWellKnownNotFound(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	if _, err := verifyHandleWellKnown(context.Background(), srv.Client(), "did:plc:alice", srv.Listener.Addr().String()); err == nil {
+		t.Fatal("verifyHandleWellKnown() error = nil, want error for a 404")
+	}
+}
@@ -0,0 +1,60 @@
+package backfill
+
+import "sync"
+
+// healthAwareScheduler hands out resolved DIDs to workers host-by-host,
+// continuously preferring whichever host with pending work currently has
+// the best hostHealthTracker score. An unhealthy host's remaining DIDs
+// aren't dropped or reordered among themselves, just deferred: once
+// every healthier host's queue is empty (or the unhealthy host recovers
+// and overtakes them), its queue gets picked again.
+type healthAwareScheduler struct {
+	health *hostHealthTracker
+
+	mu     sync.Mutex
+	hosts  []string
+	queues map[string][]ResolvedDID
+}
+
+// newHealthAwareScheduler groups resolved by PDS host, ready for next()
+// to pull from in health order. health is shared with the caller so it
+// can record outcomes as work completes and influence later next() calls
+// in the same run.
+func newHealthAwareScheduler(resolved []ResolvedDID, health *hostHealthTracker) *healthAwareScheduler {
+	s := &healthAwareScheduler{health: health, queues: make(map[string][]ResolvedDID)}
+	for _, r := range resolved {
+		if _, ok := s.queues[r.PDSHost]; !ok {
+			s.hosts = append(s.hosts, r.PDSHost)
+		}
+		s.queues[r.PDSHost] = append(s.queues[r.PDSHost], r)
+	}
+	return s
+}
+
+// next pops the next ResolvedDID to process, from whichever non-empty
+// host queue currently has the lowest health score (ties broken by the
+// host's position in the original input order, for a stable result when
+// every host is equally healthy). ok is false once every queue is empty.
+func (s *healthAwareScheduler) next() (r ResolvedDID, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bestHost := ""
+	bestScore := 0.0
+	for _, host := range s.hosts {
+		if len(s.queues[host]) == 0 {
+			continue
+		}
+		score := s.health.score(host)
+		if bestHost == "" || score < bestScore {
+			bestHost, bestScore = host, score
+		}
+	}
+	if bestHost == "" {
+		return ResolvedDID{}, false
+	}
+
+	queue := s.queues[bestHost]
+	r, s.queues[bestHost] = queue[0], queue[1:]
+	return r, true
+}
@@ -0,0 +1,75 @@
+package backfill
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHealthAwareSchedulerPrefersHealthierHost(t *testing.T) {
+	resolved := []ResolvedDID{
+		{DID: "did:plc:a", PDSHost: "sick.example"},
+		{DID: "did:plc:b", PDSHost: "healthy.example"},
+	}
+	health := newHostHealthTracker()
+	health.record("sick.example", errors.New("boom"), 0)
+
+	scheduler := newHealthAwareScheduler(resolved, health)
+	r, ok := scheduler.next()
+	if !ok {
+		t.Fatal("next() returned ok = false, want true")
+	}
+	if r.PDSHost != "healthy.example" {
+		t.Errorf("next() host = %q, want %q", r.PDSHost, "healthy.example")
+	}
+}
+
+func TestHealthAwareSchedulerFallsBackToOnlyHostWithWork(t *testing.T) {
+	resolved := []ResolvedDID{
+		{DID: "did:plc:a", PDSHost: "sick.example"},
+	}
+	health := newHostHealthTracker()
+	health.record("sick.example", errors.New("boom"), 0)
+
+	scheduler := newHealthAwareScheduler(resolved, health)
+	r, ok := scheduler.next()
+	if !ok || r.DID != "did:plc:a" {
+		t.Errorf("next() = %+v, %v, want did:plc:a, true", r, ok)
+	}
+}
+
+func TestHealthAwareSchedulerExhaustsAllItems(t *testing.T) {
+	resolved := []ResolvedDID{
+		{DID: "did:plc:a", PDSHost: "h1"},
+		{DID: "did:plc:b", PDSHost: "h1"},
+		{DID: "did:plc:c", PDSHost: "h2"},
+	}
+	scheduler := newHealthAwareScheduler(resolved, newHostHealthTracker())
+
+	seen := make(map[string]bool)
+	for i := 0; i < len(resolved); i++ {
+		r, ok := scheduler.next()
+		if !ok {
+			t.Fatalf("next() returned ok = false after %d items, want %d total", i, len(resolved))
+		}
+		seen[r.DID] = true
+	}
+	if _, ok := scheduler.next(); ok {
+		t.Error("next() after exhausting all items returned ok = true, want false")
+	}
+	if len(seen) != len(resolved) {
+		t.Errorf("saw %d distinct DIDs, want %d", len(seen), len(resolved))
+	}
+}
+
+func TestHealthAwareSchedulerStableOrderWhenAllHostsEquallyHealthy(t *testing.T) {
+	resolved := []ResolvedDID{
+		{DID: "did:plc:a", PDSHost: "h1"},
+		{DID: "did:plc:b", PDSHost: "h2"},
+	}
+	scheduler := newHealthAwareScheduler(resolved, newHostHealthTracker())
+
+	r, ok := scheduler.next()
+	if !ok || r.PDSHost != "h1" {
+		t.Errorf("next() = %+v, %v, want h1's DID first", r, ok)
+	}
+}
@@ -0,0 +1,72 @@
+package backfill
+
+import (
+	"sync"
+	"time"
+)
+
+// inFlightTracker records how many DIDs Run is currently processing and
+// since when, so a heartbeat can report progress during long quiet
+// periods between completions — the data needed to tell "slow but alive"
+// apart from "hung" without waiting for DIDs to finish.
+type inFlightTracker struct {
+	mu     sync.Mutex
+	starts map[int64]time.Time
+	nextID int64
+}
+
+func newInFlightTracker() *inFlightTracker {
+	return &inFlightTracker{starts: make(map[int64]time.Time)}
+}
+
+// start records a new in-flight entry and returns a token to pass to
+// finish once the work completes. A token, rather than the DID itself, is
+// used as the key so that the same DID appearing twice in cfg.DIDs (or
+// retried) doesn't collide with itself in the tracker.
+func (t *inFlightTracker) start() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.nextID++
+	id := t.nextID
+	t.starts[id] = time.Now()
+	return id
+}
+
+func (t *inFlightTracker) finish(id int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.starts, id)
+}
+
+// snapshot returns the current in-flight count and how long the oldest
+// entry has been running. Both are zero if nothing is in flight.
+func (t *inFlightTracker) snapshot() (inFlight int, oldestAge time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.starts) == 0 {
+		return 0, 0
+	}
+	oldest := time.Now()
+	for _, start := range t.starts {
+		if start.Before(oldest) {
+			oldest = start
+		}
+	}
+	return len(t.starts), time.Since(oldest)
+}
+
+// runHeartbeat calls hooks.OnHeartbeat with tracker's snapshot every
+// interval until stop is closed. Run this in its own goroutine.
+func runHeartbeat(interval time.Duration, tracker *inFlightTracker, hooks Hooks, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			inFlight, oldestAge := tracker.snapshot()
+			hooks.OnHeartbeat(inFlight, oldestAge)
+		case <-stop:
+			return
+		}
+	}
+}
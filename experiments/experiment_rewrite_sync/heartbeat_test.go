@@ -0,0 +1,85 @@
+package backfill
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInFlightTrackerSnapshotEmpty(t *testing.T) {
+	tracker := newInFlightTracker()
+
+	inFlight, oldestAge := tracker.snapshot()
+	if inFlight != 0 || oldestAge != 0 {
+		t.Errorf("snapshot() = (%d, %v), want (0, 0)", inFlight, oldestAge)
+	}
+}
+
+func TestInFlightTrackerTracksOldestEntry(t *testing.T) {
+	tracker := newInFlightTracker()
+
+	first := tracker.start()
+	time.Sleep(5 * time.Millisecond)
+	second := tracker.start()
+
+	inFlight, oldestAge := tracker.snapshot()
+	if inFlight != 2 {
+		t.Fatalf("inFlight = %d, want 2", inFlight)
+	}
+	if oldestAge < 5*time.Millisecond {
+		t.Errorf("oldestAge = %v, want >= 5ms", oldestAge)
+	}
+
+	tracker.finish(first)
+	inFlight, _ = tracker.snapshot()
+	if inFlight != 1 {
+		t.Errorf("inFlight after finishing the oldest = %d, want 1", inFlight)
+	}
+
+	tracker.finish(second)
+	inFlight, oldestAge = tracker.snapshot()
+	if inFlight != 0 || oldestAge != 0 {
+		t.Errorf("snapshot() after finishing everything = (%d, %v), want (0, 0)", inFlight, oldestAge)
+	}
+}
+
+func TestRunHeartbeatFiresOnInterval(t *testing.T) {
+	tracker := newInFlightTracker()
+	id := tracker.start()
+	defer tracker.finish(id)
+
+	hooks := &heartbeatRecordingHooks{}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		runHeartbeat(5*time.Millisecond, tracker, hooks, stop)
+		close(done)
+	}()
+
+	time.Sleep(30 * time.Millisecond)
+	close(stop)
+	<-done
+
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+	if len(hooks.inFlight) == 0 {
+		t.Fatal("OnHeartbeat was never called")
+	}
+	for _, n := range hooks.inFlight {
+		if n != 1 {
+			t.Errorf("OnHeartbeat inFlight = %d, want 1", n)
+		}
+	}
+}
+
+type heartbeatRecordingHooks struct {
+	NoopHooks
+	mu       sync.Mutex
+	inFlight []int
+}
+
+func (h *heartbeatRecordingHooks) OnHeartbeat(inFlight int, oldestAge time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.inFlight = append(h.inFlight, inFlight)
+}
@@ -0,0 +1,79 @@
+package backfill
+
+import "time"
+
+// Hooks lets callers observe a run's progress — for custom metrics,
+// additional filtering, or other side effects — without forking the Run
+// loop. All methods are called synchronously from worker goroutines, so
+// implementations must be safe for concurrent use and should not block.
+type Hooks interface {
+	// OnDIDStart is called right before a DID begins processing.
+	OnDIDStart(did string)
+
+	// OnDIDComplete is called once a DID has finished, successfully or
+	// not.
+	OnDIDComplete(result DIDResult)
+
+	// OnRecord is called for each record produced while processing a DID.
+	OnRecord(did string, rec Record)
+
+	// OnError is called whenever an operation for a DID fails, in
+	// addition to the error being attached to its DIDResult.
+	OnError(did string, err error)
+
+	// OnRateLimited is called when a request had to back off due to
+	// rate limiting.
+	OnRateLimited(host string)
+
+	// OnDIDStuck is called when a DID hasn't finished within
+	// Config.StuckDIDTimeout, with a stack dump of every goroutine at
+	// the moment it fired. The stuck request is canceled and retried
+	// once; OnDIDStuck fires at most once per retry.
+	OnDIDStuck(did string, elapsed time.Duration, stack []byte)
+
+	// OnRetry is called before each retry of a resolution attempt that
+	// failed with a transient error (rate limiting or a 5xx from the PLC
+	// directory), after the failed attempt and before the backoff sleep.
+	// statusCode is 0 if the attempt never got a response at all.
+	OnRetry(did, host string, statusCode, attempt int)
+
+	// OnQueueDepth is called whenever an item is about to be placed onto
+	// an inter-stage queue (e.g. RunStream's results channel), reporting
+	// how full the queue is right before that send. depth at or near
+	// capacity means the next stage is the bottleneck; a capacity of 0
+	// means the queue is unbuffered and every send already blocks until
+	// the next stage is ready for it.
+	OnQueueDepth(stage string, depth, capacity int)
+
+	// OnHeartbeat is called every Config.HeartbeatInterval for the
+	// duration of the run, independent of how many DIDs complete in that
+	// window, so a long stretch with few or no completions still
+	// produces a log line an operator can check a hung run against.
+	// inFlight is how many DIDs are currently being processed, and
+	// oldestAge is how long the oldest of them has been running; both
+	// are zero if nothing is in flight at that moment.
+	OnHeartbeat(inFlight int, oldestAge time.Duration)
+}
+
+// NoopHooks implements Hooks with methods that do nothing. Embed it to
+// implement only the callbacks you care about.
+type NoopHooks struct{}
+
+func (NoopHooks) OnDIDStart(did string)                                      {}
+func (NoopHooks) OnDIDComplete(result DIDResult)                             {}
+func (NoopHooks) OnRecord(did string, rec Record)                            {}
+func (NoopHooks) OnError(did string, err error)                              {}
+func (NoopHooks) OnRateLimited(host string)                                  {}
+func (NoopHooks) OnDIDStuck(did string, elapsed time.Duration, stack []byte) {}
+func (NoopHooks) OnRetry(did, host string, statusCode, attempt int)          {}
+func (NoopHooks) OnQueueDepth(stage string, depth, capacity int)             {}
+func (NoopHooks) OnHeartbeat(inFlight int, oldestAge time.Duration)          {}
+
+// hooksOrNoop returns h, or NoopHooks{} if h is nil, so call sites don't
+// need to nil-check before every call.
+func hooksOrNoop(h Hooks) Hooks {
+	if h == nil {
+		return NoopHooks{}
+	}
+	return h
+}
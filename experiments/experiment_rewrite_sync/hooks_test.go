@@ -0,0 +1,45 @@
+package backfill
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+type recordingHooks struct {
+	NoopHooks
+	mu      sync.Mutex
+	started []string
+	errored []string
+}
+
+func (h *recordingHooks) OnDIDStart(did string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.started = append(h.started, did)
+}
+
+func (h *recordingHooks) OnError(did string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.errored = append(h.errored, did)
+}
+
+func TestRunNotifiesHooksOnResolveError(t *testing.T) {
+	hooks := &recordingHooks{}
+	cfg := Config{
+		DIDs:  []WorkItem{{DID: "not-a-valid-did"}},
+		Hooks: hooks,
+	}
+
+	if _, err := Run(context.Background(), cfg); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(hooks.started) != 1 || hooks.started[0] != "not-a-valid-did" {
+		t.Errorf("started = %v, want [not-a-valid-did]", hooks.started)
+	}
+	if len(hooks.errored) != 1 || hooks.errored[0] != "not-a-valid-did" {
+		t.Errorf("errored = %v, want [not-a-valid-did]", hooks.errored)
+	}
+}
@@ -0,0 +1,78 @@
+package backfill
+
+import (
+	"sync"
+	"time"
+)
+
+// hostHealthStats is an exponentially-weighted view of how a PDS host has
+// been behaving recently: a host that was slow or erroring five minutes
+// ago shouldn't still be penalized once it's recovered.
+type hostHealthStats struct {
+	avgLatency time.Duration
+	errorRate  float64
+	seen       bool
+}
+
+// healthDecay weights the most recent observation against the running
+// average; higher favors recent behavior more strongly. Chosen so a
+// single bad request nudges the score without a single good one
+// immediately erasing a real problem.
+const healthDecay = 0.2
+
+// hostHealthTracker records recent latency and error rate per PDS host,
+// so a scheduler can deprioritize hosts that are currently unhealthy
+// instead of interleaving doomed requests with traffic to hosts that are
+// working fine.
+type hostHealthTracker struct {
+	mu    sync.Mutex
+	stats map[string]hostHealthStats
+}
+
+func newHostHealthTracker() *hostHealthTracker {
+	return &hostHealthTracker{stats: make(map[string]hostHealthStats)}
+}
+
+// record updates host's running latency/error-rate average with the
+// outcome of one request.
+func (t *hostHealthTracker) record(host string, err error, latency time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := t.stats[host]
+	errObserved := 0.0
+	if err != nil {
+		errObserved = 1.0
+	}
+	if !s.seen {
+		s.avgLatency = latency
+		s.errorRate = errObserved
+		s.seen = true
+	} else {
+		s.avgLatency = time.Duration((1-healthDecay)*float64(s.avgLatency) + healthDecay*float64(latency))
+		s.errorRate = (1-healthDecay)*s.errorRate + healthDecay*errObserved
+	}
+	t.stats[host] = s
+}
+
+// score returns host's current unhealthiness: higher means worse. A host
+// never recorded scores 0 (assumed healthy until proven otherwise, so a
+// fresh host isn't penalized for hosts we do have data on).
+//
+// Error rate dominates the score (a host erroring outright is worse than
+// one that's merely slow), with latency as a tie-breaker between hosts
+// that are both currently healthy.
+func (t *hostHealthTracker) score(host string) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.stats[host]
+	if !ok {
+		return 0
+	}
+	// errorWeight must outscore any realistic avgLatency (a wedged host
+	// can sit at tens of seconds) so that an erroring host always scores
+	// worse than a merely slow one, regardless of how slow.
+	const errorWeight = 1e15
+	return s.errorRate*errorWeight + float64(s.avgLatency)
+}
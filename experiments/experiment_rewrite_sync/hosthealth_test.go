@@ -0,0 +1,52 @@
+package backfill
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHostHealthTrackerUnknownHostScoresZero(t *testing.T) {
+	tracker := newHostHealthTracker()
+	if got := tracker.score("never-seen.example"); got != 0 {
+		t.Errorf("score(unseen host) = %v, want 0", got)
+	}
+}
+
+func TestHostHealthTrackerFirstObservationSetsScoreDirectly(t *testing.T) {
+	tracker := newHostHealthTracker()
+	tracker.record("bsky.social", nil, 50*time.Millisecond)
+
+	if got, want := tracker.score("bsky.social"), float64(50*time.Millisecond); got != want {
+		t.Errorf("score after first observation = %v, want %v", got, want)
+	}
+}
+
+func TestHostHealthTrackerErrorsScoreWorseThanSlowSuccesses(t *testing.T) {
+	tracker := newHostHealthTracker()
+	tracker.record("slow.example", nil, 5*time.Second)
+	tracker.record("erroring.example", errors.New("boom"), time.Millisecond)
+
+	slow := tracker.score("slow.example")
+	erroring := tracker.score("erroring.example")
+	if erroring <= slow {
+		t.Errorf("erroring host score %v should exceed slow host score %v", erroring, slow)
+	}
+}
+
+func TestHostHealthTrackerDecaysTowardRecentObservations(t *testing.T) {
+	tracker := newHostHealthTracker()
+	for i := 0; i < 20; i++ {
+		tracker.record("recovering.example", errors.New("boom"), time.Millisecond)
+	}
+	before := tracker.score("recovering.example")
+
+	for i := 0; i < 50; i++ {
+		tracker.record("recovering.example", nil, time.Millisecond)
+	}
+	after := tracker.score("recovering.example")
+
+	if after >= before {
+		t.Errorf("score after recovery = %v, want less than pre-recovery score %v", after, before)
+	}
+}
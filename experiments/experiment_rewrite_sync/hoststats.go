@@ -0,0 +1,73 @@
+package backfill
+
+import (
+	"sort"
+	"time"
+)
+
+// HostStats summarizes a run's outcomes for a single PDS host, for
+// debugging skewed hosts and for describing data provenance (which
+// hosts a dataset actually came from, and how reliable each one was).
+type HostStats struct {
+	DIDs    int   `json:"dids"`
+	Records int   `json:"records"`
+	Bytes   int64 `json:"bytes"`
+	Errors  int   `json:"errors"`
+
+	// ErrorRate is Errors / DIDs, or 0 if DIDs is 0.
+	ErrorRate float64 `json:"error_rate"`
+
+	// P95Latency is the 95th percentile of DIDResult.Duration across
+	// every DID resolved against this host.
+	P95Latency time.Duration `json:"p95_latency"`
+}
+
+// ComputeHostStats aggregates HostStats per PDS host over results,
+// skipping DIDs that never resolved to a host (PDSHost is empty for a
+// DID that failed before resolution completed).
+func ComputeHostStats(results []DIDResult) map[string]HostStats {
+	latencies := make(map[string][]time.Duration)
+	stats := make(map[string]HostStats)
+
+	for _, d := range results {
+		if d.PDSHost == "" {
+			continue
+		}
+		s := stats[d.PDSHost]
+		s.DIDs++
+		s.Records += d.Records
+		s.Bytes += d.Bytes
+		if d.Err != nil {
+			s.Errors++
+		}
+		stats[d.PDSHost] = s
+		latencies[d.PDSHost] = append(latencies[d.PDSHost], d.Duration)
+	}
+
+	for host, s := range stats {
+		if s.DIDs > 0 {
+			s.ErrorRate = float64(s.Errors) / float64(s.DIDs)
+		}
+		s.P95Latency = percentile(latencies[host], 0.95)
+		stats[host] = s
+	}
+	return stats
+}
+
+// percentile returns the p-th percentile (0 <= p <= 1) of durations
+// using nearest-rank, or 0 if durations is empty. durations is sorted
+// in place.
+func percentile(durations []time.Duration, p float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	rank := int(p*float64(len(durations))+0.999999) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(durations) {
+		rank = len(durations) - 1
+	}
+	return durations[rank]
+}
@@ -0,0 +1,62 @@
+package backfill
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestComputeHostStatsAggregatesByHost(t *testing.T) {
+	results := []DIDResult{
+		{DID: "did:plc:a", PDSHost: "bsky.social", Records: 10, Bytes: 100, Duration: 10 * time.Millisecond},
+		{DID: "did:plc:b", PDSHost: "bsky.social", Records: 5, Bytes: 50, Duration: 20 * time.Millisecond},
+		{DID: "did:plc:c", PDSHost: "bsky.social", Err: errors.New("boom"), Duration: 30 * time.Millisecond},
+		{DID: "did:plc:d", PDSHost: "other.example", Records: 1, Bytes: 1, Duration: 5 * time.Millisecond},
+		{DID: "did:plc:e"}, // never resolved to a host; excluded
+	}
+
+	stats := ComputeHostStats(results)
+	if len(stats) != 2 {
+		t.Fatalf("got %d hosts, want 2", len(stats))
+	}
+
+	bsky := stats["bsky.social"]
+	if bsky.DIDs != 3 {
+		t.Errorf("bsky.social DIDs = %d, want 3", bsky.DIDs)
+	}
+	if bsky.Records != 15 {
+		t.Errorf("bsky.social Records = %d, want 15", bsky.Records)
+	}
+	if bsky.Bytes != 150 {
+		t.Errorf("bsky.social Bytes = %d, want 150", bsky.Bytes)
+	}
+	if bsky.Errors != 1 {
+		t.Errorf("bsky.social Errors = %d, want 1", bsky.Errors)
+	}
+	if got, want := bsky.ErrorRate, 1.0/3.0; got != want {
+		t.Errorf("bsky.social ErrorRate = %v, want %v", got, want)
+	}
+
+	other := stats["other.example"]
+	if other.DIDs != 1 || other.Records != 1 {
+		t.Errorf("other.example = %+v", other)
+	}
+}
+
+func TestComputeHostStatsP95Latency(t *testing.T) {
+	var results []DIDResult
+	for i := 1; i <= 100; i++ {
+		results = append(results, DIDResult{DID: "did", PDSHost: "h", Duration: time.Duration(i) * time.Millisecond})
+	}
+
+	stats := ComputeHostStats(results)
+	if got, want := stats["h"].P95Latency, 95*time.Millisecond; got != want {
+		t.Errorf("P95Latency = %v, want %v", got, want)
+	}
+}
+
+func TestPercentileEmpty(t *testing.T) {
+	if got := percentile(nil, 0.95); got != 0 {
+		t.Errorf("percentile(nil) = %v, want 0", got)
+	}
+}
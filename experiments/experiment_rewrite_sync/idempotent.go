@@ -0,0 +1,63 @@
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// UpsertKey identifies a record for idempotent storage. Database sinks
+// (Postgres, SQLite, ...) upsert on this key within a transaction so that
+// re-running or resuming a backfill never creates duplicate rows; file-based
+// sinks don't need it since each run writes its own shard.
+type UpsertKey struct {
+	DID        string
+	Collection string
+	Rkey       string
+	CID        string
+}
+
+// RecordUpsertKey derives rec's UpsertKey from its AT-URI and CID.
+func RecordUpsertKey(did string, rec Record) (UpsertKey, error) {
+	_, collection, rkey, err := ParseATURI(rec.URI)
+	if err != nil {
+		return UpsertKey{}, fmt.Errorf("derive upsert key: %w", err)
+	}
+	return UpsertKey{DID: did, Collection: collection, Rkey: rkey, CID: rec.CID}, nil
+}
+
+// IdempotentSink wraps another Sink and drops writes whose UpsertKey it has
+// already seen, so a sink that doesn't do its own upsert (e.g. FileSink)
+// still gets exactly-once semantics when a backfill is resumed or re-run
+// against the same output.
+type IdempotentSink struct {
+	next Sink
+
+	mu   sync.Mutex
+	seen map[UpsertKey]bool
+}
+
+// NewIdempotentSink wraps next with UpsertKey-based deduplication.
+func NewIdempotentSink(next Sink) *IdempotentSink {
+	return &IdempotentSink{next: next, seen: make(map[UpsertKey]bool)}
+}
+
+// Write derives rec's UpsertKey and forwards to next only the first time
+// that key is seen; later writes of the same (did, collection, rkey, cid)
+// are silently dropped.
+func (s *IdempotentSink) Write(ctx context.Context, did string, rec Record) error {
+	key, err := RecordUpsertKey(did, rec)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if s.seen[key] {
+		s.mu.Unlock()
+		return nil
+	}
+	s.seen[key] = true
+	s.mu.Unlock()
+
+	return s.next.Write(ctx, did, rec)
+}
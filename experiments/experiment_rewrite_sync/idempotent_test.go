@@ -0,0 +1,56 @@
+package backfill
+
+import (
+	"context"
+	"testing"
+)
+
+type recordingSink struct {
+	writes []Record
+}
+
+func (s *recordingSink) Write(ctx context.Context, did string, rec Record) error {
+	s.writes = append(s.writes, rec)
+	return nil
+}
+
+func TestIdempotentSinkDropsRepeatedUpsertKey(t *testing.T) {
+	next := &recordingSink{}
+	sink := NewIdempotentSink(next)
+	ctx := context.Background()
+
+	rec := Record{URI: "at://did:plc:alice/app.bsky.feed.post/1", CID: "bafy1"}
+	if err := sink.Write(ctx, "did:plc:alice", rec); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := sink.Write(ctx, "did:plc:alice", rec); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if len(next.writes) != 1 {
+		t.Fatalf("got %d writes, want 1", len(next.writes))
+	}
+}
+
+func TestIdempotentSinkForwardsDifferentCIDAsNewRow(t *testing.T) {
+	next := &recordingSink{}
+	sink := NewIdempotentSink(next)
+	ctx := context.Background()
+
+	if err := sink.Write(ctx, "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1", CID: "bafy1"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := sink.Write(ctx, "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1", CID: "bafy2"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if len(next.writes) != 2 {
+		t.Fatalf("got %d writes, want 2 (different CID is a new revision)", len(next.writes))
+	}
+}
+
+func TestRecordUpsertKeyRejectsMalformedURI(t *testing.T) {
+	if _, err := RecordUpsertKey("did:plc:alice", Record{URI: "not-a-uri"}); err == nil {
+		t.Fatal("RecordUpsertKey() error = nil, want error for malformed URI")
+	}
+}
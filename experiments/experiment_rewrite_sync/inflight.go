@@ -0,0 +1,37 @@
+package backfill
+
+import "sync"
+
+// hostLimiter caps how many operations may be in flight against a single
+// PDS host at once, independent of any global concurrency limit. A zero
+// maxPerHost means unlimited.
+type hostLimiter struct {
+	maxPerHost int
+
+	mu   sync.Mutex
+	sems map[string]chan struct{}
+}
+
+func newHostLimiter(maxPerHost int) *hostLimiter {
+	return &hostLimiter{maxPerHost: maxPerHost, sems: make(map[string]chan struct{})}
+}
+
+// acquire blocks until a slot for host is free, then returns a release
+// func. If the limiter is unlimited, acquire returns immediately with a
+// no-op release.
+func (l *hostLimiter) acquire(host string) (release func()) {
+	if l.maxPerHost <= 0 {
+		return func() {}
+	}
+
+	l.mu.Lock()
+	sem, ok := l.sems[host]
+	if !ok {
+		sem = make(chan struct{}, l.maxPerHost)
+		l.sems[host] = sem
+	}
+	l.mu.Unlock()
+
+	sem <- struct{}{}
+	return func() { <-sem }
+}
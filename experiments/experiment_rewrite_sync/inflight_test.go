@@ -0,0 +1,47 @@
+package backfill
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHostLimiterCapsConcurrency(t *testing.T) {
+	limiter := newHostLimiter(2)
+
+	var current, max int32
+	done := make(chan struct{})
+
+	for i := 0; i < 10; i++ {
+		go func() {
+			release := limiter.acquire("bsky.social")
+			n := atomic.AddInt32(&current, 1)
+			for {
+				m := atomic.LoadInt32(&max)
+				if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&current, -1)
+			release()
+			done <- struct{}{}
+		}()
+	}
+
+	for i := 0; i < 10; i++ {
+		<-done
+	}
+
+	if max > 2 {
+		t.Errorf("observed %d concurrent acquisitions, want <= 2", max)
+	}
+}
+
+func TestHostLimiterUnlimitedWhenZero(t *testing.T) {
+	limiter := newHostLimiter(0)
+	release := limiter.acquire("bsky.social")
+	release2 := limiter.acquire("bsky.social")
+	release()
+	release2()
+}
@@ -0,0 +1,435 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JobStatus is a Job's lifecycle state.
+type JobStatus string
+
+const (
+	JobQueued   JobStatus = "queued"
+	JobRunning  JobStatus = "running"
+	JobDone     JobStatus = "done"
+	JobFailed   JobStatus = "failed"
+	JobCanceled JobStatus = "canceled"
+)
+
+// JobRequest is the JSON body of POST /jobs: the serializable subset of
+// Config a caller can submit over HTTP (Sink, Hooks, and HTTPClient aren't
+// JSON values, so a job always writes JSON-lines shards to OutputDir).
+type JobRequest struct {
+	DIDs       []string `json:"dids"`
+	AllowDIDs  []string `json:"allow_dids,omitempty"`
+	BlockDIDs  []string `json:"block_dids,omitempty"`
+	AllowHosts []string `json:"allow_hosts,omitempty"`
+	BlockHosts []string `json:"block_hosts,omitempty"`
+	OutputDir  string   `json:"output_dir,omitempty"`
+
+	// Priority orders the queue: higher values run first. Jobs with equal
+	// priority run in submission order. The default is 0, so an urgent
+	// cohort sync submitted with a positive Priority jumps ahead of an
+	// already-queued background crawl.
+	Priority int `json:"priority,omitempty"`
+
+	// ConcurrencyClass, if set, is checked against the limit configured
+	// via WithConcurrencyClassLimit: no more than that many jobs sharing
+	// a class run at once, independent of the overall
+	// WithMaxConcurrentJobs cap. Jobs with no class are only bounded by
+	// the overall cap.
+	ConcurrencyClass string `json:"concurrency_class,omitempty"`
+
+	// Tenant identifies who submitted this job for the purposes of
+	// WithTenantQuota. Jobs with no Tenant aren't charged against any
+	// quota.
+	Tenant string `json:"tenant,omitempty"`
+
+	// ManifestPath, if set alongside OutputDir, writes a manifest JSON
+	// (per-shard size, sha256, record count) to this path once the job
+	// finishes — the same manifest the CLI's --manifest flag produces,
+	// so a scheduled run leaves the same provenance trail as a one-off.
+	ManifestPath string `json:"manifest_path,omitempty"`
+}
+
+// Job is one submitted backfill run and its current state.
+type Job struct {
+	ID        string     `json:"id"`
+	Request   JobRequest `json:"request"`
+	Status    JobStatus  `json:"status"`
+	Error     string     `json:"error,omitempty"`
+	Result    *Result    `json:"result,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// JobServer exposes a small REST API (POST /jobs, GET /jobs/{id},
+// DELETE /jobs/{id}) for submitting backfill jobs, polling their progress,
+// and canceling them, for teams that can't adopt a gRPC client (see
+// NewGRPCServer). Job state is persisted to a local JSON file after every
+// change, so a restart doesn't lose the job list — though any job still
+// running when the process exits is not resumed, only left in whatever
+// state it was last persisted in.
+//
+// Canceling a job cancels the context passed to Run, which Run checks
+// between DID resolutions; a DID resolution already in flight still
+// completes. Run's current implementation always finishes its per-host
+// fetch phase regardless of cancellation (it doesn't fetch repos yet), so
+// cancellation is most effective early in large runs and has no effect
+// once resolution has finished.
+//
+// Submitted jobs queue rather than starting immediately: at most
+// maxConcurrent run at once, and a job's ConcurrencyClass (if it has one)
+// is checked against its own limit on top of that. When a slot frees up,
+// the highest-Priority eligible queued job runs next, so a small urgent
+// cohort sync submitted after a large background crawl still runs first.
+type JobServer struct {
+	statePath string
+
+	mu            sync.Mutex
+	jobs          map[string]*Job
+	cancels       map[string]context.CancelFunc
+	nextID        int
+	queue         []string
+	running       int
+	maxConcurrent int
+	classLimits   map[string]int
+	classRunning  map[string]int
+	tenantQuotas  map[string]TenantQuota
+	tenantUsage   map[string]*TenantUsage
+}
+
+// JobServerOption configures optional JobServer behavior.
+type JobServerOption func(*JobServer)
+
+// WithMaxConcurrentJobs caps how many jobs run at once across all
+// concurrency classes. The default is 4.
+func WithMaxConcurrentJobs(n int) JobServerOption {
+	return func(s *JobServer) { s.maxConcurrent = n }
+}
+
+// WithConcurrencyClassLimit caps how many jobs submitted with the given
+// JobRequest.ConcurrencyClass run at once, independent of
+// WithMaxConcurrentJobs. A class with no configured limit is only bounded
+// by the overall cap.
+func WithConcurrencyClassLimit(class string, n int) JobServerOption {
+	return func(s *JobServer) { s.classLimits[class] = n }
+}
+
+// NewJobServer creates a JobServer, loading any jobs already persisted at
+// statePath.
+func NewJobServer(statePath string, opts ...JobServerOption) (*JobServer, error) {
+	s := &JobServer{
+		statePath:     statePath,
+		jobs:          make(map[string]*Job),
+		cancels:       make(map[string]context.CancelFunc),
+		maxConcurrent: 4,
+		classLimits:   make(map[string]int),
+		classRunning:  make(map[string]int),
+		tenantQuotas:  make(map[string]TenantQuota),
+		tenantUsage:   make(map[string]*TenantUsage),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	body, err := os.ReadFile(statePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("new job server: %w", err)
+	}
+
+	var jobs []*Job
+	if err := json.Unmarshal(body, &jobs); err != nil {
+		return nil, fmt.Errorf("new job server: %w", err)
+	}
+	for _, job := range jobs {
+		s.jobs[job.ID] = job
+		if n, err := strconv.Atoi(strings.TrimPrefix(job.ID, "job-")); err == nil && n >= s.nextID {
+			s.nextID = n + 1
+		}
+	}
+	return s, nil
+}
+
+// Handler returns the http.Handler serving the job API.
+func (s *JobServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jobs", s.handleCollection)
+	mux.HandleFunc("/jobs/", s.handleItem)
+	mux.HandleFunc("/tenants", s.handleTenants)
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	return mux
+}
+
+func (s *JobServer) handleCollection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req JobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("decode request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	job, err := s.createJob(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	}
+	writeJobJSON(w, http.StatusCreated, job)
+}
+
+func (s *JobServer) handleItem(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/jobs/")
+	if id == "" {
+		http.Error(w, "missing job id", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		job, ok := s.getJob(id)
+		if !ok {
+			http.Error(w, "job not found", http.StatusNotFound)
+			return
+		}
+		writeJobJSON(w, http.StatusOK, job)
+	case http.MethodDelete:
+		if !s.cancelJob(id) {
+			http.Error(w, "job not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *JobServer) createJob(req JobRequest) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkTenantQuotaLocked(req.Tenant); err != nil {
+		return nil, err
+	}
+
+	id := fmt.Sprintf("job-%d", s.nextID)
+	s.nextID++
+	job := &Job{ID: id, Request: req, Status: JobQueued, CreatedAt: time.Now()}
+	s.jobs[id] = job
+	if req.Tenant != "" {
+		usage := s.tenantUsage[req.Tenant]
+		if usage == nil {
+			usage = &TenantUsage{}
+			s.tenantUsage[req.Tenant] = usage
+		}
+		usage.Calls++
+	}
+	s.enqueueLocked(id)
+	s.dispatchLocked()
+	s.persistLocked()
+	return job, nil
+}
+
+// enqueueLocked inserts id into the queue ordered by descending
+// JobRequest.Priority, breaking ties by keeping submission order. Callers
+// must hold s.mu.
+func (s *JobServer) enqueueLocked(id string) {
+	priority := s.jobs[id].Request.Priority
+	i := 0
+	for i < len(s.queue) && s.jobs[s.queue[i]].Request.Priority >= priority {
+		i++
+	}
+	s.queue = append(s.queue, "")
+	copy(s.queue[i+1:], s.queue[i:])
+	s.queue[i] = id
+}
+
+// dispatchLocked starts as many queued jobs as the overall and per-class
+// concurrency limits allow, highest priority first. Callers must hold
+// s.mu.
+func (s *JobServer) dispatchLocked() {
+	for {
+		if s.maxConcurrent > 0 && s.running >= s.maxConcurrent {
+			return
+		}
+
+		idx := -1
+		for i, id := range s.queue {
+			class := s.jobs[id].Request.ConcurrencyClass
+			if limit, ok := s.classLimits[class]; ok && s.classRunning[class] >= limit {
+				continue
+			}
+			idx = i
+			break
+		}
+		if idx == -1 {
+			return
+		}
+
+		id := s.queue[idx]
+		s.queue = append(s.queue[:idx], s.queue[idx+1:]...)
+		class := s.jobs[id].Request.ConcurrencyClass
+		s.running++
+		s.classRunning[class]++
+		go s.run(id)
+	}
+}
+
+func (s *JobServer) getJob(id string) (*Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	return job, ok
+}
+
+// GetJob returns a copy of the job with the given ID, for callers that
+// have no HTTP round-trip to make, such as Daemon.
+func (s *JobServer) GetJob(id string) (Job, bool) {
+	job, ok := s.getJob(id)
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// SubmitJob enqueues req directly, bypassing the HTTP layer — the
+// programmatic equivalent of POST /jobs, used by Daemon for its own
+// scheduled submissions.
+func (s *JobServer) SubmitJob(req JobRequest) (Job, error) {
+	job, err := s.createJob(req)
+	if err != nil {
+		return Job{}, err
+	}
+	return *job, nil
+}
+
+func (s *JobServer) cancelJob(id string) bool {
+	s.mu.Lock()
+	job, ok := s.jobs[id]
+	if !ok {
+		s.mu.Unlock()
+		return false
+	}
+	if cancel, running := s.cancels[id]; running {
+		s.mu.Unlock()
+		cancel()
+		return true
+	}
+	if job.Status == JobQueued {
+		for i, qid := range s.queue {
+			if qid == id {
+				s.queue = append(s.queue[:i], s.queue[i+1:]...)
+				break
+			}
+		}
+		job.Status = JobCanceled
+		s.persistLocked()
+	}
+	s.mu.Unlock()
+	return true
+}
+
+func (s *JobServer) run(id string) {
+	s.mu.Lock()
+	job := s.jobs[id]
+	if job.Status == JobCanceled {
+		s.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancels[id] = cancel
+	job.Status = JobRunning
+	s.persistLocked()
+	s.mu.Unlock()
+	defer cancel()
+
+	cfg := Config{DIDs: toWorkItems(job.Request.DIDs), AllowDIDs: job.Request.AllowDIDs, BlockDIDs: job.Request.BlockDIDs, AllowHosts: job.Request.AllowHosts, BlockHosts: job.Request.BlockHosts}
+
+	var sink *FileSink
+	if job.Request.OutputDir != "" {
+		var err error
+		sink, err = NewFileSink(job.Request.OutputDir)
+		if err != nil {
+			s.finish(id, nil, err)
+			return
+		}
+		cfg.Sink = sink
+	}
+
+	result, err := Run(ctx, cfg)
+	if sink != nil {
+		if closeErr := sink.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+		if err == nil && job.Request.ManifestPath != "" {
+			err = sink.WriteManifest(job.Request.ManifestPath)
+		}
+	}
+	s.finish(id, result, err)
+}
+
+func (s *JobServer) finish(id string, result *Result, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.cancels, id)
+	job := s.jobs[id]
+	s.running--
+	s.classRunning[job.Request.ConcurrencyClass]--
+	s.recordTenantUsageLocked(job.Request.Tenant, result)
+	switch {
+	case err == context.Canceled:
+		job.Status = JobCanceled
+	case err != nil:
+		job.Status = JobFailed
+		job.Error = err.Error()
+	default:
+		job.Status = JobDone
+		job.Result = result
+	}
+	s.persistLocked()
+	s.dispatchLocked()
+}
+
+// persistLocked writes every job to statePath as JSON. Callers must hold
+// s.mu.
+func (s *JobServer) persistLocked() {
+	if s.statePath == "" {
+		return
+	}
+	jobs := make([]*Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	body, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(s.statePath, body, 0o644)
+}
+
+func toWorkItems(dids []string) []WorkItem {
+	items := make([]WorkItem, 0, len(dids))
+	for _, did := range dids {
+		items = append(items, WorkItem{DID: did})
+	}
+	return items
+}
+
+func writeJobJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
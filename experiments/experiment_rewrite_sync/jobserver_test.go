@@ -0,0 +1,188 @@
+package backfill
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func postJob(t *testing.T, srv *JobServer, req JobRequest) Job {
+	t.Helper()
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("marshal request: %v", err)
+	}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/jobs", bytes.NewReader(body))
+	srv.Handler().ServeHTTP(w, r)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST /jobs status = %d, body = %s", w.Code, w.Body.String())
+	}
+	var job Job
+	if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	return job
+}
+
+func getJob(t *testing.T, srv *JobServer, id string) (int, Job) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/jobs/"+id, nil)
+	srv.Handler().ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		return w.Code, Job{}
+	}
+	var job Job
+	if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	return w.Code, job
+}
+
+func TestJobServerCreateAndGetJob(t *testing.T) {
+	dir := t.TempDir()
+	srv, err := NewJobServer(filepath.Join(dir, "jobs.json"))
+	if err != nil {
+		t.Fatalf("NewJobServer() error = %v", err)
+	}
+
+	job := postJob(t, srv, JobRequest{DIDs: []string{"did:plc:alice"}})
+	if job.ID == "" {
+		t.Fatalf("job.ID is empty")
+	}
+
+	var status JobStatus
+	for i := 0; i < 50; i++ {
+		code, got := getJob(t, srv, job.ID)
+		if code != http.StatusOK {
+			t.Fatalf("GET /jobs/%s status = %d", job.ID, code)
+		}
+		status = got.Status
+		if status == JobDone || status == JobFailed {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if status != JobDone && status != JobFailed {
+		t.Fatalf("job never finished, last status = %q", status)
+	}
+}
+
+func TestJobServerGetUnknownJobReturnsNotFound(t *testing.T) {
+	srv, err := NewJobServer(filepath.Join(t.TempDir(), "jobs.json"))
+	if err != nil {
+		t.Fatalf("NewJobServer() error = %v", err)
+	}
+
+	code, _ := getJob(t, srv, "job-999")
+	if code != http.StatusNotFound {
+		t.Errorf("GET unknown job status = %d, want %d", code, http.StatusNotFound)
+	}
+}
+
+func TestJobServerDeleteCancelsQueuedJob(t *testing.T) {
+	srv, err := NewJobServer(filepath.Join(t.TempDir(), "jobs.json"))
+	if err != nil {
+		t.Fatalf("NewJobServer() error = %v", err)
+	}
+
+	job := &Job{ID: "job-0", Request: JobRequest{DIDs: []string{"did:plc:alice"}}, Status: JobQueued}
+	srv.mu.Lock()
+	srv.jobs[job.ID] = job
+	srv.mu.Unlock()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodDelete, "/jobs/"+job.ID, nil)
+	srv.Handler().ServeHTTP(w, r)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+
+	_, got := getJob(t, srv, job.ID)
+	if got.Status != JobCanceled {
+		t.Errorf("job.Status = %q, want %q", got.Status, JobCanceled)
+	}
+}
+
+func TestJobServerPersistsAndReloadsState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	srv, err := NewJobServer(path)
+	if err != nil {
+		t.Fatalf("NewJobServer() error = %v", err)
+	}
+	job := postJob(t, srv, JobRequest{DIDs: []string{"did:plc:alice"}})
+
+	for i := 0; i < 50; i++ {
+		_, got := getJob(t, srv, job.ID)
+		if got.Status == JobDone || got.Status == JobFailed {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	reloaded, err := NewJobServer(path)
+	if err != nil {
+		t.Fatalf("NewJobServer() reload error = %v", err)
+	}
+	if _, ok := reloaded.jobs[job.ID]; !ok {
+		t.Errorf("reloaded server is missing job %s", job.ID)
+	}
+}
+
+func TestJobServerCapsConcurrentJobsAndQueuesTheRest(t *testing.T) {
+	srv, err := NewJobServer(filepath.Join(t.TempDir(), "jobs.json"), WithMaxConcurrentJobs(1))
+	if err != nil {
+		t.Fatalf("NewJobServer() error = %v", err)
+	}
+
+	srv.mu.Lock()
+	srv.running = 1 // simulate a slow job already occupying the only slot
+	srv.mu.Unlock()
+
+	job := postJob(t, srv, JobRequest{DIDs: []string{"did:plc:alice"}})
+
+	_, got := getJob(t, srv, job.ID)
+	if got.Status != JobQueued {
+		t.Errorf("job.Status = %q, want %q (slot should be occupied)", got.Status, JobQueued)
+	}
+
+	srv.mu.Lock()
+	inQueue := len(srv.queue) == 1 && srv.queue[0] == job.ID
+	srv.mu.Unlock()
+	if !inQueue {
+		t.Errorf("job %s was not left in the queue", job.ID)
+	}
+}
+
+func TestJobServerEnqueueLockedOrdersByPriorityThenSubmissionOrder(t *testing.T) {
+	srv, err := NewJobServer(filepath.Join(t.TempDir(), "jobs.json"))
+	if err != nil {
+		t.Fatalf("NewJobServer() error = %v", err)
+	}
+
+	srv.mu.Lock()
+	srv.jobs["low"] = &Job{ID: "low", Request: JobRequest{Priority: 0}}
+	srv.enqueueLocked("low")
+	srv.jobs["high"] = &Job{ID: "high", Request: JobRequest{Priority: 10}}
+	srv.enqueueLocked("high")
+	srv.jobs["mid"] = &Job{ID: "mid", Request: JobRequest{Priority: 5}}
+	srv.enqueueLocked("mid")
+	got := append([]string{}, srv.queue...)
+	srv.mu.Unlock()
+
+	want := []string{"high", "mid", "low"}
+	if len(got) != len(want) {
+		t.Fatalf("queue = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("queue = %v, want %v", got, want)
+			break
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// KafkaProducer is the subset of a Kafka client KafkaSink needs. Like
+// PostgresSink, KafkaSink takes an already-configured producer rather than
+// importing a client library itself, since this module has no vendored
+// dependencies — the caller wires up whichever driver (confluent-kafka-go,
+// franz-go, segmentio/kafka-go, ...) it's already standardized on.
+type KafkaProducer interface {
+	// Produce sends value, keyed by key, to topic. It must not return
+	// until the broker has acknowledged the message (or ctx is canceled),
+	// so KafkaSink's at-least-once guarantee is "this call blocked until
+	// the broker had it", not "the client's internal buffer has it".
+	Produce(ctx context.Context, topic string, key, value []byte) error
+}
+
+// KafkaSink relays records and tombstones into a Kafka topic, intended to
+// replace a firehose-to-Kafka bridge that otherwise tracks its cursor
+// out-of-band: with WithKafkaCheckpoint configured, it also produces a
+// checkpoint message to a dedicated topic every N records, so a consumer
+// resuming after a crash can recover its position from Kafka itself
+// instead of a side file that can drift out of sync with what was
+// actually delivered.
+//
+// Delivery is at-least-once, not exactly-once: Write only returns once
+// Produce has acknowledged the message, but a crash between a successful
+// Produce and KafkaSink returning control to its caller can still cause
+// the same record to be relayed again after a restart. Consumers are
+// expected to dedupe on (did, uri, cid), the same key RecordUpsertKey
+// already derives for PostgresSink.
+//
+// KafkaSink relays whatever Write/WriteTombstone are called with; it has
+// no firehose/Jetstream subscription of its own (not yet implemented by
+// this package — see DIDSet), so feeding it live events is the caller's
+// responsibility.
+type KafkaSink struct {
+	producer KafkaProducer
+	topic    string
+
+	checkpointTopic string
+	checkpointEvery int
+	commitDelta     bool
+
+	mu      sync.Mutex
+	written int
+}
+
+// KafkaSinkOption configures a KafkaSink constructed with NewKafkaSink.
+type KafkaSinkOption func(*KafkaSink)
+
+// WithKafkaCheckpoint makes KafkaSink produce a checkpoint message to
+// checkpointTopic, keyed by the triggering record's did, every n records
+// relayed in total, recording the last DID/URI successfully delivered.
+// The checkpoint's Seq field is never filled in, with or without
+// WithKafkaCommitDeltaFormat. n <= 0 disables checkpointing (the default).
+func WithKafkaCheckpoint(checkpointTopic string, n int) KafkaSinkOption {
+	return func(s *KafkaSink) {
+		s.checkpointTopic = checkpointTopic
+		s.checkpointEvery = n
+	}
+}
+
+// WithKafkaCommitDeltaFormat relays records and tombstones as CommitDelta
+// messages instead of bare Record/Tombstone ones, matching FileSink's
+// WithCommitDeltaFormat for a consumer that needs commit structure (op,
+// uri, cid) rather than a flattened record stream.
+func WithKafkaCommitDeltaFormat() KafkaSinkOption {
+	return func(s *KafkaSink) { s.commitDelta = true }
+}
+
+// NewKafkaSink creates a KafkaSink that produces to topic via producer.
+func NewKafkaSink(producer KafkaProducer, topic string, opts ...KafkaSinkOption) *KafkaSink {
+	s := &KafkaSink{producer: producer, topic: topic}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// kafkaCheckpoint is the payload of a checkpoint message produced to
+// KafkaSink's checkpoint topic.
+type kafkaCheckpoint struct {
+	DID string `json:"did"`
+	URI string `json:"uri"`
+	Seq int64  `json:"seq,omitempty"`
+}
+
+// Write produces rec (or, with WithKafkaCommitDeltaFormat, its
+// CommitDelta) to s.topic keyed by did, so every message for the same
+// repo lands on the same partition and a consumer sees them in send
+// order. It returns once the broker has acknowledged the message.
+func (s *KafkaSink) Write(ctx context.Context, did string, rec Record) error {
+	var value any = rec
+	if s.commitDelta {
+		value = CommitDeltaFromRecord(did, rec)
+	}
+	if err := s.produce(ctx, did, value); err != nil {
+		return err
+	}
+	return s.maybeCheckpoint(ctx, did, rec.URI)
+}
+
+// WriteTombstone produces t (or its CommitDelta) the same way Write does,
+// implementing TombstoneSink.
+func (s *KafkaSink) WriteTombstone(ctx context.Context, did string, t Tombstone) error {
+	var value any = t
+	if s.commitDelta {
+		value = CommitDeltaFromTombstone(did, t)
+	}
+	if err := s.produce(ctx, did, value); err != nil {
+		return err
+	}
+	return s.maybeCheckpoint(ctx, did, t.URI)
+}
+
+func (s *KafkaSink) produce(ctx context.Context, did string, value any) error {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("kafka sink: marshal: %w", err)
+	}
+	if err := s.producer.Produce(ctx, s.topic, []byte(did), payload); err != nil {
+		return fmt.Errorf("kafka sink: produce to %s: %w", s.topic, err)
+	}
+	return nil
+}
+
+// maybeCheckpoint produces a checkpoint message once every
+// checkpointEvery calls, if WithKafkaCheckpoint is set.
+func (s *KafkaSink) maybeCheckpoint(ctx context.Context, did, uri string) error {
+	if s.checkpointTopic == "" || s.checkpointEvery <= 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	s.written++
+	due := s.written%s.checkpointEvery == 0
+	s.mu.Unlock()
+	if !due {
+		return nil
+	}
+
+	payload, err := json.Marshal(kafkaCheckpoint{DID: did, URI: uri})
+	if err != nil {
+		return fmt.Errorf("kafka sink: marshal checkpoint: %w", err)
+	}
+	if err := s.producer.Produce(ctx, s.checkpointTopic, []byte(did), payload); err != nil {
+		return fmt.Errorf("kafka sink: checkpoint to %s: %w", s.checkpointTopic, err)
+	}
+	return nil
+}
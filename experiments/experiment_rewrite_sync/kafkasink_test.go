@@ -0,0 +1,120 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+)
+
+// fakeKafkaProducer records every message instead of talking to a real
+// broker, so KafkaSink can be tested without a vendored client dependency.
+type fakeKafkaProducer struct {
+	mu       sync.Mutex
+	messages []fakeKafkaMessage
+	err      error
+}
+
+type fakeKafkaMessage struct {
+	topic string
+	key   string
+	value []byte
+}
+
+func (p *fakeKafkaProducer) Produce(ctx context.Context, topic string, key, value []byte) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.messages = append(p.messages, fakeKafkaMessage{topic: topic, key: string(key), value: append([]byte(nil), value...)})
+	return nil
+}
+
+func TestKafkaSinkWriteProducesKeyedByDID(t *testing.T) {
+	producer := &fakeKafkaProducer{}
+	sink := NewKafkaSink(producer, "records")
+
+	rec := Record{URI: "at://did:plc:alice/app.bsky.feed.post/1", CID: "bafycid"}
+	if err := sink.Write(context.Background(), "did:plc:alice", rec); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if len(producer.messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(producer.messages))
+	}
+	msg := producer.messages[0]
+	if msg.topic != "records" || msg.key != "did:plc:alice" {
+		t.Errorf("got topic=%q key=%q, want topic=records key=did:plc:alice", msg.topic, msg.key)
+	}
+
+	var got Record
+	if err := json.Unmarshal(msg.value, &got); err != nil {
+		t.Fatalf("unmarshal message value: %v", err)
+	}
+	if got.URI != rec.URI || got.CID != rec.CID {
+		t.Errorf("got record %+v, want %+v", got, rec)
+	}
+}
+
+func TestKafkaSinkWithCommitDeltaFormatWritesDeltas(t *testing.T) {
+	producer := &fakeKafkaProducer{}
+	sink := NewKafkaSink(producer, "records", WithKafkaCommitDeltaFormat())
+
+	if err := sink.Write(context.Background(), "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1", CID: "bafycid"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := sink.WriteTombstone(context.Background(), "did:plc:alice", Tombstone{URI: "at://did:plc:alice/app.bsky.feed.post/1", Action: TombstoneActionDelete}); err != nil {
+		t.Fatalf("WriteTombstone() error = %v", err)
+	}
+
+	if len(producer.messages) != 2 {
+		t.Fatalf("got %d messages, want 2", len(producer.messages))
+	}
+
+	var created CommitDelta
+	if err := json.Unmarshal(producer.messages[0].value, &created); err != nil {
+		t.Fatalf("unmarshal first message: %v", err)
+	}
+	if created.Op != CommitDeltaOpCreate {
+		t.Errorf("got op %q, want %q", created.Op, CommitDeltaOpCreate)
+	}
+
+	var deleted CommitDelta
+	if err := json.Unmarshal(producer.messages[1].value, &deleted); err != nil {
+		t.Fatalf("unmarshal second message: %v", err)
+	}
+	if deleted.Op != CommitDeltaOpDelete {
+		t.Errorf("got op %q, want %q", deleted.Op, CommitDeltaOpDelete)
+	}
+}
+
+func TestKafkaSinkWithCheckpointProducesEveryNRecords(t *testing.T) {
+	producer := &fakeKafkaProducer{}
+	sink := NewKafkaSink(producer, "records", WithKafkaCheckpoint("checkpoints", 2))
+
+	for i := 0; i < 5; i++ {
+		if err := sink.Write(context.Background(), "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1"}); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	var checkpoints int
+	for _, msg := range producer.messages {
+		if msg.topic == "checkpoints" {
+			checkpoints++
+		}
+	}
+	if checkpoints != 2 {
+		t.Errorf("got %d checkpoint messages for 5 writes every 2, want 2", checkpoints)
+	}
+}
+
+func TestKafkaSinkWritePropagatesProducerError(t *testing.T) {
+	producer := &fakeKafkaProducer{err: context.DeadlineExceeded}
+	sink := NewKafkaSink(producer, "records")
+
+	if err := sink.Write(context.Background(), "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1"}); err == nil {
+		t.Error("Write() error = nil, want propagated producer error")
+	}
+}
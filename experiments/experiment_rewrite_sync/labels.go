@@ -0,0 +1,136 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Label is a moderation label as defined by com.atproto.label.defs#label.
+// Subject (URI) can point at either a post URI or a bare DID, since
+// labelers can label either accounts or individual records.
+type Label struct {
+	Src string `json:"src"`
+	URI string `json:"uri"`
+	CID string `json:"cid,omitempty"`
+	Val string `json:"val"`
+	Neg bool   `json:"neg,omitempty"`
+	Cts string `json:"cts"`
+}
+
+type queryLabelsResponse struct {
+	Labels []Label `json:"labels"`
+	Cursor string  `json:"cursor"`
+}
+
+// QueryLabels pages through com.atproto.label.queryLabels on labelerEndpoint,
+// returning every label whose subject matches one of uriPatterns (bare DIDs
+// or post URIs, "*" suffix allowed per the lexicon) from any of sources (all
+// sources on the labeler if empty).
+func QueryLabels(ctx context.Context, httpClient *http.Client, labelerEndpoint string, uriPatterns, sources []string) ([]Label, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	var labels []Label
+	cursor := ""
+	for {
+		q := url.Values{}
+		for _, p := range uriPatterns {
+			q.Add("uriPatterns", p)
+		}
+		for _, s := range sources {
+			q.Add("sources", s)
+		}
+		q.Set("limit", "250")
+		if cursor != "" {
+			q.Set("cursor", cursor)
+		}
+
+		reqURL := fmt.Sprintf("%s/xrpc/com.atproto.label.queryLabels?%s", labelerEndpoint, q.Encode())
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+		if err != nil {
+			return labels, fmt.Errorf("queryLabels %s: %w", labelerEndpoint, err)
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return labels, fmt.Errorf("queryLabels %s: %w", labelerEndpoint, err)
+		}
+
+		var page queryLabelsResponse
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return labels, fmt.Errorf("queryLabels %s: decode: %w", labelerEndpoint, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return labels, fmt.Errorf("queryLabels %s: server returned %s", labelerEndpoint, resp.Status)
+		}
+
+		labels = append(labels, page.Labels...)
+		if page.Cursor == "" {
+			break
+		}
+		cursor = page.Cursor
+	}
+
+	return labels, nil
+}
+
+// AttachLabels returns a copy of records with each one's Labels field set to
+// the labels whose subject URI matches that record's URI, so moderation
+// research has a label source to join against without a separate labels
+// table.
+func AttachLabels(records []Record, labels []Label) []Record {
+	byURI := make(map[string][]Label, len(labels))
+	for _, l := range labels {
+		byURI[l.URI] = append(byURI[l.URI], l)
+	}
+
+	out := make([]Record, len(records))
+	for i, r := range records {
+		r.Labels = byURI[r.URI]
+		out[i] = r
+	}
+	return out
+}
+
+// FilterByLabels drops records by their attached labels before they reach a
+// sink, so downstream annotators aren't exposed to flagged content. If
+// onlyLabels is non-empty, a record must carry at least one of those label
+// values to survive; excludeLabels is applied after that and always wins, so
+// a record carrying both an only- and an exclude-listed label is dropped.
+func FilterByLabels(records []Record, onlyLabels, excludeLabels []string) []Record {
+	only := make(map[string]bool, len(onlyLabels))
+	for _, v := range onlyLabels {
+		only[v] = true
+	}
+	exclude := make(map[string]bool, len(excludeLabels))
+	for _, v := range excludeLabels {
+		exclude[v] = true
+	}
+
+	var out []Record
+	for _, r := range records {
+		if len(only) > 0 && !hasAnyLabel(r.Labels, only) {
+			continue
+		}
+		if hasAnyLabel(r.Labels, exclude) {
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+func hasAnyLabel(labels []Label, set map[string]bool) bool {
+	for _, l := range labels {
+		if set[l.Val] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQueryLabelsPagesByCursor(t *testing.T) {
+	pages := map[string]queryLabelsResponse{
+		"": {
+			Labels: []Label{{Src: "did:plc:labeler", URI: "at://did:plc:a/app.bsky.feed.post/1", Val: "porn"}},
+			Cursor: "page2",
+		},
+		"page2": {
+			Labels: []Label{{Src: "did:plc:labeler", URI: "at://did:plc:a/app.bsky.feed.post/2", Val: "gore"}},
+		},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Query().Get("cursor")]
+		if !ok {
+			t.Fatalf("unexpected cursor %q", r.URL.Query().Get("cursor"))
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer srv.Close()
+
+	labels, err := QueryLabels(context.Background(), srv.Client(), srv.URL, []string{"at://did:plc:a/app.bsky.feed.post/*"}, nil)
+	if err != nil {
+		t.Fatalf("QueryLabels() error = %v", err)
+	}
+	if len(labels) != 2 {
+		t.Fatalf("got %d labels, want 2", len(labels))
+	}
+}
+
+func TestAttachLabelsJoinsByURI(t *testing.T) {
+	records := []Record{
+		{URI: "at://did:plc:a/app.bsky.feed.post/1"},
+		{URI: "at://did:plc:a/app.bsky.feed.post/2"},
+	}
+	labels := []Label{
+		{URI: "at://did:plc:a/app.bsky.feed.post/1", Val: "porn"},
+		{URI: "at://did:plc:a/app.bsky.feed.post/1", Val: "gore"},
+	}
+
+	got := AttachLabels(records, labels)
+
+	if len(got[0].Labels) != 2 {
+		t.Errorf("record 1 Labels = %v, want 2", got[0].Labels)
+	}
+	if len(got[1].Labels) != 0 {
+		t.Errorf("record 2 Labels = %v, want 0", got[1].Labels)
+	}
+}
+
+func TestFilterByLabelsExcludeWinsOverOnly(t *testing.T) {
+	records := []Record{
+		{URI: "at://did:plc:a/app.bsky.feed.post/1", Labels: []Label{{Val: "porn"}, {Val: "spam"}}},
+		{URI: "at://did:plc:a/app.bsky.feed.post/2", Labels: []Label{{Val: "spam"}}},
+		{URI: "at://did:plc:a/app.bsky.feed.post/3", Labels: nil},
+	}
+
+	got := FilterByLabels(records, []string{"spam"}, []string{"porn"})
+
+	if len(got) != 1 || got[0].URI != "at://did:plc:a/app.bsky.feed.post/2" {
+		t.Errorf("got %v, want only record 2", got)
+	}
+}
+
+func TestFilterByLabelsNoFiltersKeepsEverything(t *testing.T) {
+	records := []Record{{URI: "at://did:plc:a/app.bsky.feed.post/1"}}
+	got := FilterByLabels(records, nil, nil)
+	if len(got) != 1 {
+		t.Errorf("got %d records, want 1", len(got))
+	}
+}
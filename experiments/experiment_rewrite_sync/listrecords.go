@@ -0,0 +1,124 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Record is a single record returned by com.atproto.repo.listRecords.
+type Record struct {
+	URI   string          `json:"uri"`
+	CID   string          `json:"cid"`
+	Value json.RawMessage `json:"value"`
+
+	// Timestamp is the time derived from the record key, for record keys
+	// that are TIDs (see ParseTID). Nil when the rkey isn't a valid TID, so
+	// callers can tell "no timestamp" from "epoch" and rely on omitempty.
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+
+	// Labels holds moderation labels whose subject matches this record's
+	// URI, attached by AttachLabels. Empty unless the caller fetched and
+	// joined labels.
+	Labels []Label `json:"labels,omitempty"`
+}
+
+// withTIDTimestamp parses rec.URI's rkey as a TID and sets rec.Timestamp if
+// it's valid, leaving rec unchanged otherwise (e.g. custom, non-TID rkeys).
+func withTIDTimestamp(rec Record) Record {
+	idx := strings.LastIndex(rec.URI, "/")
+	if idx < 0 {
+		return rec
+	}
+	rkey := rec.URI[idx+1:]
+
+	ts, err := ParseTID(rkey)
+	if err != nil {
+		return rec
+	}
+	rec.Timestamp = &ts
+	return rec
+}
+
+// canonicalizeListedRecord validates that a listRecords entry has a CID and
+// rewrites its URI to the canonical at://did/collection/rkey form built from
+// the collection we requested, rather than trusting the server's uri field
+// verbatim, so every Record this tool emits carries a consistent identifier.
+func canonicalizeListedRecord(did, collection string, rec Record) (Record, error) {
+	if rec.CID == "" {
+		return Record{}, fmt.Errorf("record has no cid: %s", rec.URI)
+	}
+	idx := strings.LastIndex(rec.URI, "/")
+	if idx < 0 {
+		return Record{}, fmt.Errorf("record has no rkey in uri %q", rec.URI)
+	}
+	rkey := rec.URI[idx+1:]
+
+	rec.URI = CanonicalATURI(did, collection, rkey)
+	return rec, nil
+}
+
+type listRecordsResponse struct {
+	Records []Record `json:"records"`
+	Cursor  string   `json:"cursor"`
+}
+
+// ListRecords pages through every record in did's collection on pdsEndpoint
+// via com.atproto.repo.listRecords, returning the full set. It's the
+// fallback path for repos too large to pull down as a single CAR file.
+func ListRecords(ctx context.Context, httpClient *http.Client, pdsEndpoint, did, collection string) ([]Record, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	var records []Record
+	cursor := ""
+	for {
+		q := url.Values{}
+		q.Set("repo", did)
+		q.Set("collection", collection)
+		q.Set("limit", "100")
+		if cursor != "" {
+			q.Set("cursor", cursor)
+		}
+
+		reqURL := fmt.Sprintf("%s/xrpc/com.atproto.repo.listRecords?%s", pdsEndpoint, q.Encode())
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+		if err != nil {
+			return records, fmt.Errorf("listRecords %s/%s: %w", did, collection, err)
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return records, fmt.Errorf("listRecords %s/%s: %w", did, collection, err)
+		}
+
+		var page listRecordsResponse
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return records, fmt.Errorf("listRecords %s/%s: decode: %w", did, collection, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return records, fmt.Errorf("listRecords %s/%s: pds returned %s", did, collection, resp.Status)
+		}
+
+		for _, rec := range page.Records {
+			rec, err := canonicalizeListedRecord(did, collection, rec)
+			if err != nil {
+				return records, fmt.Errorf("listRecords %s/%s: %w", did, collection, err)
+			}
+			records = append(records, withTIDTimestamp(rec))
+		}
+		if page.Cursor == "" {
+			break
+		}
+		cursor = page.Cursor
+	}
+
+	return records, nil
+}
@@ -0,0 +1,47 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestListRecordsCanonicalizesURI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(listRecordsResponse{
+			Records: []Record{
+				// A server might report the uri using a handle instead of
+				// the DID we requested with; the rkey is what matters.
+				{URI: "at://alice.bsky.social/app.bsky.feed.post/3k2x4q", CID: "bafycid"},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	records, err := ListRecords(context.Background(), srv.Client(), srv.URL, "did:plc:alice", "app.bsky.feed.post")
+	if err != nil {
+		t.Fatalf("ListRecords() error = %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+	want := "at://did:plc:alice/app.bsky.feed.post/3k2x4q"
+	if records[0].URI != want {
+		t.Errorf("URI = %q, want %q", records[0].URI, want)
+	}
+}
+
+func TestListRecordsRejectsMissingCID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(listRecordsResponse{
+			Records: []Record{{URI: "at://did:plc:alice/app.bsky.feed.post/3k2x4q"}},
+		})
+	}))
+	defer srv.Close()
+
+	if _, err := ListRecords(context.Background(), srv.Client(), srv.URL, "did:plc:alice", "app.bsky.feed.post"); err == nil {
+		t.Fatal("ListRecords() error = nil, want error for missing cid")
+	}
+}
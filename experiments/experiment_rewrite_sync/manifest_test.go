@@ -0,0 +1,62 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileSinkManifestReportsSizeChecksumAndRecords(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(dir)
+	if err != nil {
+		t.Fatalf("NewFileSink() error = %v", err)
+	}
+
+	ctx := context.Background()
+	sink.Write(ctx, "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1"})
+	sink.Write(ctx, "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/2"})
+	sink.Write(ctx, "did:plc:bob", Record{URI: "at://did:plc:bob/app.bsky.feed.post/1"})
+
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	manifestPath := filepath.Join(dir, "manifest.json")
+	if err := sink.WriteManifest(manifestPath); err != nil {
+		t.Fatalf("WriteManifest() error = %v", err)
+	}
+
+	body, err := os.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatalf("read manifest: %v", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(body, &m); err != nil {
+		t.Fatalf("unmarshal manifest: %v", err)
+	}
+
+	if len(m.Entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(m.Entries))
+	}
+
+	byDID := make(map[string]ManifestEntry, len(m.Entries))
+	for _, e := range m.Entries {
+		byDID[e.MinDID] = e
+	}
+
+	alice := byDID["did:plc:alice"]
+	if alice.Records != 2 {
+		t.Errorf("alice Records = %d, want 2", alice.Records)
+	}
+	if alice.SizeBytes == 0 || alice.SHA256 == "" {
+		t.Errorf("alice entry missing size/checksum: %+v", alice)
+	}
+
+	bob := byDID["did:plc:bob"]
+	if bob.Records != 1 {
+		t.Errorf("bob Records = %d, want 1", bob.Records)
+	}
+}
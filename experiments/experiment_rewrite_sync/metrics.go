@@ -0,0 +1,67 @@
+package backfill
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// FormatPrometheusMetrics renders result as Prometheus text-exposition
+// format (see
+// https://prometheus.io/docs/instrumenting/exposition_formats/), with no
+// client library vendored: per-collection record counts are broken out
+// as their own series, since RecordCount/Records alone says nothing
+// about a dataset's composition (posts vs. likes vs. follows).
+func FormatPrometheusMetrics(result *Result) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# HELP bsky_backfill_dids_total DIDs processed.\n")
+	fmt.Fprintf(&b, "# TYPE bsky_backfill_dids_total counter\n")
+	fmt.Fprintf(&b, "bsky_backfill_dids_total %d\n", len(result.PerDID))
+
+	fmt.Fprintf(&b, "# HELP bsky_backfill_errors_total DIDs that failed.\n")
+	fmt.Fprintf(&b, "# TYPE bsky_backfill_errors_total counter\n")
+	fmt.Fprintf(&b, "bsky_backfill_errors_total %d\n", len(result.Failed()))
+
+	fmt.Fprintf(&b, "# HELP bsky_backfill_bytes_total Bytes downloaded.\n")
+	fmt.Fprintf(&b, "# TYPE bsky_backfill_bytes_total counter\n")
+	fmt.Fprintf(&b, "bsky_backfill_bytes_total %d\n", result.TotalBytes())
+
+	counts := result.CollectionCounts()
+	collections := make([]string, 0, len(counts))
+	for collection := range counts {
+		collections = append(collections, collection)
+	}
+	sort.Strings(collections)
+
+	fmt.Fprintf(&b, "# HELP bsky_backfill_records_total Records written, by collection.\n")
+	fmt.Fprintf(&b, "# TYPE bsky_backfill_records_total counter\n")
+	for _, collection := range collections {
+		fmt.Fprintf(&b, "bsky_backfill_records_total{collection=%q} %d\n", collection, counts[collection])
+	}
+
+	return b.String()
+}
+
+// handleMetrics serves GET /metrics as Prometheus text-exposition format,
+// aggregated across every job's Result the server currently knows about
+// (queued and running jobs have no Result yet and contribute nothing).
+func (s *JobServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.mu.Lock()
+	var perDID []DIDResult
+	for _, job := range s.jobs {
+		if job.Result != nil {
+			perDID = append(perDID, job.Result.PerDID...)
+		}
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	fmt.Fprint(w, FormatPrometheusMetrics(&Result{PerDID: perDID}))
+}
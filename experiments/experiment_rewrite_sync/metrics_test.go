@@ -0,0 +1,46 @@
+package backfill
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestResultCollectionCountsSumsAcrossDIDs(t *testing.T) {
+	result := &Result{
+		PerDID: []DIDResult{
+			{DID: "did:plc:alice", Collections: map[string]int{"app.bsky.feed.post": 3, "app.bsky.feed.like": 1}},
+			{DID: "did:plc:bob", Collections: map[string]int{"app.bsky.feed.post": 2}},
+			{DID: "did:plc:carol"},
+		},
+	}
+
+	counts := result.CollectionCounts()
+	if counts["app.bsky.feed.post"] != 5 {
+		t.Errorf("app.bsky.feed.post = %d, want 5", counts["app.bsky.feed.post"])
+	}
+	if counts["app.bsky.feed.like"] != 1 {
+		t.Errorf("app.bsky.feed.like = %d, want 1", counts["app.bsky.feed.like"])
+	}
+}
+
+func TestFormatPrometheusMetricsIncludesPerCollectionSeries(t *testing.T) {
+	result := &Result{
+		PerDID: []DIDResult{
+			{DID: "did:plc:alice", Bytes: 100, Collections: map[string]int{"app.bsky.feed.post": 3}},
+			{DID: "did:plc:bob", Err: errors.New("boom")},
+		},
+	}
+
+	out := FormatPrometheusMetrics(result)
+	for _, want := range []string{
+		`bsky_backfill_records_total{collection="app.bsky.feed.post"} 3`,
+		"bsky_backfill_dids_total 2",
+		"bsky_backfill_errors_total 1",
+		"bsky_backfill_bytes_total 100",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// MLTextRecord is the fixed shape MLTextSink emits: exactly the fields a
+// batch inference worker needs for post-text classification, nothing else.
+type MLTextRecord struct {
+	URI       string   `json:"uri"`
+	DID       string   `json:"did"`
+	CreatedAt string   `json:"created_at"`
+	Text      string   `json:"text"`
+	Langs     []string `json:"langs,omitempty"`
+	ReplyRoot string   `json:"reply_root,omitempty"`
+}
+
+// ExtractMLText pulls an MLTextRecord out of an app.bsky.feed.post record.
+// ok is false (with a nil error) for non-post records and posts with no
+// text, so callers can skip them without treating it as a failure.
+func ExtractMLText(did string, rec Record) (out MLTextRecord, ok bool, err error) {
+	_, collection, _, err := ParseATURI(rec.URI)
+	if err != nil || collection != "app.bsky.feed.post" {
+		return MLTextRecord{}, false, nil
+	}
+
+	var post struct {
+		Text      string   `json:"text"`
+		CreatedAt string   `json:"createdAt"`
+		Langs     []string `json:"langs"`
+		Reply     *struct {
+			Root struct {
+				URI string `json:"uri"`
+			} `json:"root"`
+		} `json:"reply"`
+	}
+	if err := json.Unmarshal(rec.Value, &post); err != nil {
+		return MLTextRecord{}, false, fmt.Errorf("extract ml text: %w", err)
+	}
+	if post.Text == "" {
+		return MLTextRecord{}, false, nil
+	}
+
+	out = MLTextRecord{
+		URI:       rec.URI,
+		DID:       did,
+		CreatedAt: post.CreatedAt,
+		Text:      strings.ToValidUTF8(post.Text, "�"),
+		Langs:     post.Langs,
+	}
+	if post.Reply != nil {
+		out.ReplyRoot = post.Reply.Root.URI
+	}
+	return out, true, nil
+}
+
+// MLTextSink writes MLTextRecord shards sized for batch inference workers,
+// deduplicated by URI, rotating to a new shard every maxPerShard records.
+//
+// "UTF-8 normalized" here means ExtractMLText guarantees well-formed UTF-8
+// (invalid byte sequences, e.g. from a truncated fetch, are replaced) via
+// strings.ToValidUTF8. It does not perform Unicode canonical-equivalence
+// normalization (NFC/NFKC) — that needs golang.org/x/text/unicode/norm,
+// which isn't vendored in this stdlib-only module.
+type MLTextSink struct {
+	dir         string
+	maxPerShard int
+
+	mu       sync.Mutex
+	seen     map[string]bool
+	shardIdx int
+	curCount int
+	curFile  *os.File
+	curTmp   string
+	curFinal string
+	curEnc   *json.Encoder
+}
+
+// NewMLTextSink creates an MLTextSink writing shards under dir, rotating
+// every maxPerShard records (10000 if maxPerShard <= 0).
+func NewMLTextSink(dir string, maxPerShard int) (*MLTextSink, error) {
+	if maxPerShard <= 0 {
+		maxPerShard = 10000
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("new ml text sink: %w", err)
+	}
+	return &MLTextSink{dir: dir, maxPerShard: maxPerShard, seen: make(map[string]bool)}, nil
+}
+
+// Write extracts an MLTextRecord from rec and appends it to the current
+// shard, skipping non-post records and URIs already written.
+func (s *MLTextSink) Write(ctx context.Context, did string, rec Record) error {
+	out, ok, err := ExtractMLText(did, rec)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.seen[out.URI] {
+		return nil
+	}
+	s.seen[out.URI] = true
+
+	if s.curFile == nil || s.curCount >= s.maxPerShard {
+		if err := s.rotateLocked(); err != nil {
+			return err
+		}
+	}
+
+	if err := s.curEnc.Encode(out); err != nil {
+		return fmt.Errorf("write ml text shard: %w", err)
+	}
+	s.curCount++
+	return nil
+}
+
+func (s *MLTextSink) rotateLocked() error {
+	if s.curFile != nil {
+		if err := s.closeCurrentLocked(); err != nil {
+			return err
+		}
+	}
+
+	finalPath := filepath.Join(s.dir, fmt.Sprintf("shard-%05d.jsonl", s.shardIdx))
+	s.shardIdx++
+	tmpPath := finalPath + ".tmp"
+
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("open ml text shard: %w", err)
+	}
+
+	s.curFile = f
+	s.curTmp = tmpPath
+	s.curFinal = finalPath
+	s.curEnc = json.NewEncoder(f)
+	s.curCount = 0
+	return nil
+}
+
+func (s *MLTextSink) closeCurrentLocked() error {
+	if err := s.curFile.Close(); err != nil {
+		return fmt.Errorf("close ml text shard: %w", err)
+	}
+	if err := os.Rename(s.curTmp, s.curFinal); err != nil {
+		return fmt.Errorf("finalize ml text shard: %w", err)
+	}
+	s.curFile = nil
+	return nil
+}
+
+// Close flushes and renames the current shard, if any.
+func (s *MLTextSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.curFile == nil {
+		return nil
+	}
+	return s.closeCurrentLocked()
+}
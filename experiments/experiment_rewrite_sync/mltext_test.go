@@ -0,0 +1,119 @@
+package backfill
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExtractMLTextParsesPostFields(t *testing.T) {
+	rec := Record{
+		URI:   "at://did:plc:alice/app.bsky.feed.post/1",
+		Value: []byte(`{"text":"hello world","createdAt":"2023-06-15T12:00:00Z","langs":["en"],"reply":{"root":{"uri":"at://did:plc:alice/app.bsky.feed.post/0"}}}`),
+	}
+
+	out, ok, err := ExtractMLText("did:plc:alice", rec)
+	if err != nil {
+		t.Fatalf("ExtractMLText() error = %v", err)
+	}
+	if !ok {
+		t.Fatal("ok = false, want true for a post with text")
+	}
+	if out.Text != "hello world" || out.CreatedAt != "2023-06-15T12:00:00Z" || len(out.Langs) != 1 || out.Langs[0] != "en" {
+		t.Errorf("got %+v", out)
+	}
+	if out.ReplyRoot != "at://did:plc:alice/app.bsky.feed.post/0" {
+		t.Errorf("ReplyRoot = %q, want the reply root uri", out.ReplyRoot)
+	}
+}
+
+func TestExtractMLTextSkipsNonPostsAndEmptyText(t *testing.T) {
+	_, ok, err := ExtractMLText("did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.like/1", Value: []byte(`{}`)})
+	if err != nil || ok {
+		t.Errorf("non-post record: ok = %v, err = %v, want ok=false, err=nil", ok, err)
+	}
+
+	_, ok, err = ExtractMLText("did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1", Value: []byte(`{"text":""}`)})
+	if err != nil || ok {
+		t.Errorf("empty text: ok = %v, err = %v, want ok=false, err=nil", ok, err)
+	}
+}
+
+func TestMLTextSinkDedupesByURI(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewMLTextSink(dir, 0)
+	if err != nil {
+		t.Fatalf("NewMLTextSink() error = %v", err)
+	}
+	ctx := context.Background()
+	rec := Record{URI: "at://did:plc:alice/app.bsky.feed.post/1", Value: []byte(`{"text":"hi"}`)}
+
+	if err := sink.Write(ctx, "did:plc:alice", rec); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := sink.Write(ctx, "did:plc:alice", rec); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	lines := readShardLines(t, filepath.Join(dir, "shard-00000.jsonl"))
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1 (deduplicated)", len(lines))
+	}
+}
+
+func TestMLTextSinkRotatesAtMaxPerShard(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewMLTextSink(dir, 2)
+	if err != nil {
+		t.Fatalf("NewMLTextSink() error = %v", err)
+	}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		uri := fmt.Sprintf("at://did:plc:alice/app.bsky.feed.post/%d", i+1)
+		rec := Record{URI: uri, Value: []byte(`{"text":"hi"}`)}
+		if err := sink.Write(ctx, "did:plc:alice", rec); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if len(readShardLines(t, filepath.Join(dir, "shard-00000.jsonl"))) != 2 {
+		t.Error("want 2 records in the first shard")
+	}
+	if len(readShardLines(t, filepath.Join(dir, "shard-00001.jsonl"))) != 1 {
+		t.Error("want 1 record in the second shard")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "shard-00001.jsonl.tmp")); err == nil {
+		t.Error("found leftover .tmp file, want it renamed on Close")
+	}
+}
+
+func readShardLines(t *testing.T, path string) []MLTextRecord {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	var out []MLTextRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec MLTextRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("unmarshal line: %v", err)
+		}
+		out = append(out, rec)
+	}
+	return out
+}
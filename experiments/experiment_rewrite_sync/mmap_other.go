@@ -0,0 +1,17 @@
+//go:build !unix
+
+package backfill
+
+import (
+	"io"
+	"os"
+)
+
+// openCARReaderAt falls back to plain file reads on platforms without
+// mmap support. *os.File already implements io.ReaderAt via pread, so
+// this is still lazy — only a block's own bytes are ever copied into
+// memory — just without the shared, kernel-managed page cache mmap gives
+// on unix.
+func openCARReaderAt(f *os.File, size int64) (io.ReaderAt, io.Closer, error) {
+	return f, f, nil
+}
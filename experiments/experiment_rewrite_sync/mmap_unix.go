@@ -0,0 +1,42 @@
+//go:build unix
+
+package backfill
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+)
+
+// openCARReaderAt memory-maps f read-only and hands back a ReaderAt over
+// the mapping, so CARFile never needs to hold more of the archive in the
+// Go heap than the block it's currently decoding — the kernel pages
+// blocks in (and can evict them under memory pressure) as the mapping is
+// touched. f is closed once mmap holds its own reference to the
+// underlying file; the returned Closer unmaps instead.
+func openCARReaderAt(f *os.File, size int64) (io.ReaderAt, io.Closer, error) {
+	defer f.Close()
+
+	if size == 0 {
+		return bytes.NewReader(nil), noopCloser{}, nil
+	}
+
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, fmt.Errorf("mmap: %w", err)
+	}
+	return bytes.NewReader(data), mmapCloser(data), nil
+}
+
+// mmapCloser releases a mapping obtained from openCARReaderAt.
+type mmapCloser []byte
+
+func (m mmapCloser) Close() error {
+	return syscall.Munmap(m)
+}
+
+type noopCloser struct{}
+
+func (noopCloser) Close() error { return nil }
@@ -0,0 +1,125 @@
+package backfill
+
+import "fmt"
+
+// MSTEntry is one leaf of a repo's Merkle Search Tree: the record's full
+// key ("collection/rkey") and the CID of the record block it points to.
+type MSTEntry struct {
+	Key string
+	CID []byte
+}
+
+// MSTStats summarizes the tree structure WalkMST traversed, for debugging
+// an archived repo without dumping every record.
+type MSTStats struct {
+	Nodes   int
+	Entries int
+
+	// Depth is the number of internal nodes on the longest root-to-entry
+	// path seen, starting at 1 for a single-node tree.
+	Depth int
+}
+
+// WalkMST reconstructs every record key in a repo by walking its Merkle
+// Search Tree (see https://atproto.com/specs/repository#merkle-search-tree)
+// starting at rootData, the "data" CID from the repo's commit object (see
+// CommitMetadata.Data). blocks must include every MST node and record
+// block the tree references — typically every block DecodeCARFile decoded
+// from the same CAR file the commit came from.
+func WalkMST(blocks []DecodedBlock, rootData CIDLink) ([]MSTEntry, MSTStats, error) {
+	byCID := make(map[string]any, len(blocks))
+	for _, b := range blocks {
+		if b.Err == nil {
+			byCID[string(b.CID)] = b.Value
+		}
+	}
+
+	var entries []MSTEntry
+	var stats MSTStats
+	if err := walkMSTNode(byCID, []byte(rootData), "", &entries, &stats, 1); err != nil {
+		return nil, MSTStats{}, err
+	}
+	return entries, stats, nil
+}
+
+// walkMSTNode decodes the MST node block at cid and visits it in key
+// order: its leftmost ("l") subtree, then each entry in turn
+// (reconstructing its full key from prevKey plus the entry's prefix
+// length and key suffix), followed by that entry's own right ("t")
+// subtree.
+func walkMSTNode(byCID map[string]any, cid []byte, prevKey string, entries *[]MSTEntry, stats *MSTStats, depth int) error {
+	if cid == nil {
+		return nil
+	}
+	v, ok := byCID[string(cid)]
+	if !ok {
+		return fmt.Errorf("mst: node %x not found among decoded blocks", cid)
+	}
+	node, ok := v.(map[string]any)
+	if !ok {
+		return fmt.Errorf("mst: node %x is not a map (got %T)", cid, v)
+	}
+
+	stats.Nodes++
+	if depth > stats.Depth {
+		stats.Depth = depth
+	}
+
+	if left, ok := node["l"].(CIDLink); ok {
+		if err := walkMSTNode(byCID, []byte(left), prevKey, entries, stats, depth+1); err != nil {
+			return err
+		}
+	}
+
+	rawEntries, _ := node["e"].([]any)
+	for i, re := range rawEntries {
+		entry, ok := re.(map[string]any)
+		if !ok {
+			return fmt.Errorf("mst: node %x entry %d is not a map (got %T)", cid, i, re)
+		}
+
+		prefixLen, err := mstUint(entry, "p")
+		if err != nil {
+			return fmt.Errorf("mst: node %x entry %d: %w", cid, i, err)
+		}
+		suffix, ok := entry["k"].([]byte)
+		if !ok {
+			return fmt.Errorf("mst: node %x entry %d: missing key suffix", cid, i)
+		}
+		if int(prefixLen) > len(prevKey) {
+			return fmt.Errorf("mst: node %x entry %d: prefix length %d exceeds previous key %q", cid, i, prefixLen, prevKey)
+		}
+		key := prevKey[:prefixLen] + string(suffix)
+
+		value, ok := entry["v"].(CIDLink)
+		if !ok {
+			return fmt.Errorf("mst: node %x entry %d: missing value CID", cid, i)
+		}
+		*entries = append(*entries, MSTEntry{Key: key, CID: []byte(value)})
+		stats.Entries++
+
+		prevKey = key
+		if right, ok := entry["t"].(CIDLink); ok {
+			if err := walkMSTNode(byCID, []byte(right), key, entries, stats, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// mstUint extracts an unsigned integer field from a decoded MST entry map,
+// where DecodeDAGCBORValue represents a non-negative CBOR integer as
+// uint64.
+func mstUint(m map[string]any, key string) (uint64, error) {
+	v, ok := m[key]
+	if !ok {
+		return 0, fmt.Errorf("missing %q", key)
+	}
+	n, ok := v.(uint64)
+	if !ok {
+		return 0, fmt.Errorf("%q is not an unsigned integer (got %T)", key, v)
+	}
+	return n, nil
+}
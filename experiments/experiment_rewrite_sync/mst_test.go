@@ -0,0 +1,119 @@
+package backfill
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWalkMSTReconstructsKeysFromPrefixCompression(t *testing.T) {
+	cidA := []byte{0xa1}
+	cidB := []byte{0xb2}
+	rootCID := []byte{0x00}
+
+	root := map[string]any{
+		"e": []any{
+			map[string]any{"p": uint64(0), "k": []byte("app.bsky.feed.post/1"), "v": CIDLink(cidA)},
+			map[string]any{"p": uint64(19), "k": []byte("2"), "v": CIDLink(cidB)},
+		},
+	}
+
+	blocks := []DecodedBlock{
+		{CID: rootCID, Value: root},
+		{CID: cidA, Value: map[string]any{"$type": "app.bsky.feed.post", "text": "hello"}},
+		{CID: cidB, Value: map[string]any{"$type": "app.bsky.feed.post", "text": "world"}},
+	}
+
+	entries, stats, err := WalkMST(blocks, CIDLink(rootCID))
+	if err != nil {
+		t.Fatalf("WalkMST() error = %v", err)
+	}
+
+	want := []MSTEntry{
+		{Key: "app.bsky.feed.post/1", CID: cidA},
+		{Key: "app.bsky.feed.post/2", CID: cidB},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("entries = %+v, want %+v", entries, want)
+	}
+	if stats.Nodes != 1 || stats.Entries != 2 || stats.Depth != 1 {
+		t.Errorf("stats = %+v, want {Nodes:1 Entries:2 Depth:1}", stats)
+	}
+}
+
+func TestWalkMSTVisitsLeftAndRightSubtrees(t *testing.T) {
+	leftCID := []byte{0x01}
+	rightCID := []byte{0x02}
+	rootCID := []byte{0x00}
+	recA := []byte{0xaa}
+	recB := []byte{0xbb}
+	recC := []byte{0xcc}
+
+	left := map[string]any{
+		"e": []any{
+			map[string]any{"p": uint64(0), "k": []byte("app.bsky.feed.post/1"), "v": CIDLink(recA)},
+		},
+	}
+	right := map[string]any{
+		"e": []any{
+			map[string]any{"p": uint64(0), "k": []byte("app.bsky.feed.post/3"), "v": CIDLink(recC)},
+		},
+	}
+	root := map[string]any{
+		"l": CIDLink(leftCID),
+		"e": []any{
+			map[string]any{"p": uint64(0), "k": []byte("app.bsky.feed.post/2"), "v": CIDLink(recB), "t": CIDLink(rightCID)},
+		},
+	}
+
+	blocks := []DecodedBlock{
+		{CID: rootCID, Value: root},
+		{CID: leftCID, Value: left},
+		{CID: rightCID, Value: right},
+		{CID: recA, Value: map[string]any{"$type": "app.bsky.feed.post"}},
+		{CID: recB, Value: map[string]any{"$type": "app.bsky.feed.post"}},
+		{CID: recC, Value: map[string]any{"$type": "app.bsky.feed.post"}},
+	}
+
+	entries, stats, err := WalkMST(blocks, CIDLink(rootCID))
+	if err != nil {
+		t.Fatalf("WalkMST() error = %v", err)
+	}
+
+	wantKeys := []string{"app.bsky.feed.post/1", "app.bsky.feed.post/2", "app.bsky.feed.post/3"}
+	var gotKeys []string
+	for _, e := range entries {
+		gotKeys = append(gotKeys, e.Key)
+	}
+	if !reflect.DeepEqual(gotKeys, wantKeys) {
+		t.Errorf("keys in traversal order = %v, want %v", gotKeys, wantKeys)
+	}
+	if stats.Nodes != 3 {
+		t.Errorf("stats.Nodes = %d, want 3", stats.Nodes)
+	}
+	if stats.Depth != 2 {
+		t.Errorf("stats.Depth = %d, want 2", stats.Depth)
+	}
+}
+
+func TestWalkMSTErrorsWhenNodeBlockIsMissing(t *testing.T) {
+	rootCID := []byte{0x00}
+	_, _, err := WalkMST(nil, CIDLink(rootCID))
+	if err == nil {
+		t.Fatal("WalkMST() error = nil, want an error for a missing root block")
+	}
+}
+
+func TestWalkMSTErrorsWhenPrefixLengthExceedsPreviousKey(t *testing.T) {
+	rootCID := []byte{0x00}
+	root := map[string]any{
+		"e": []any{
+			map[string]any{"p": uint64(5), "k": []byte("x"), "v": CIDLink([]byte{0xaa})},
+		},
+	}
+	blocks := []DecodedBlock{{CID: rootCID, Value: root}}
+
+	_, _, err := WalkMST(blocks, CIDLink(rootCID))
+	if err == nil {
+		t.Fatal("WalkMST() error = nil, want an error for an out-of-range prefix length")
+	}
+}
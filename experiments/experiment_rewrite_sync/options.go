@@ -0,0 +1,88 @@
+package backfill
+
+import "fmt"
+
+// Option configures a Config built with New. Options are applied in order
+// and may return an error to reject an invalid combination.
+type Option func(*Config) error
+
+// New builds a Config by applying opts in order, so new fields can be
+// added to Config over time without breaking existing callers who only
+// set the options they care about.
+func New(opts ...Option) (Config, error) {
+	var cfg Config
+	for _, opt := range opts {
+		if err := opt(&cfg); err != nil {
+			return Config{}, err
+		}
+	}
+	return cfg, nil
+}
+
+// WithDIDs sets the input work items for the run.
+func WithDIDs(dids ...WorkItem) Option {
+	return func(cfg *Config) error {
+		cfg.DIDs = dids
+		return nil
+	}
+}
+
+// WithConcurrency bounds how many DIDs are processed at once. n must be
+// positive.
+func WithConcurrency(n int) Option {
+	return func(cfg *Config) error {
+		if n <= 0 {
+			return fmt.Errorf("WithConcurrency: n must be positive, got %d", n)
+		}
+		cfg.Concurrency = n
+		return nil
+	}
+}
+
+// WithMaxInFlightPerHost caps concurrent requests to any single PDS host.
+// n must be positive.
+func WithMaxInFlightPerHost(n int) Option {
+	return func(cfg *Config) error {
+		if n <= 0 {
+			return fmt.Errorf("WithMaxInFlightPerHost: n must be positive, got %d", n)
+		}
+		cfg.MaxInFlightPerHost = n
+		return nil
+	}
+}
+
+// WithRateLimit caps outbound requests to rps per second across the whole
+// run. rps must be positive.
+func WithRateLimit(rps float64) Option {
+	return func(cfg *Config) error {
+		if rps <= 0 {
+			return fmt.Errorf("WithRateLimit: rps must be positive, got %v", rps)
+		}
+		cfg.RateLimit = rps
+		return nil
+	}
+}
+
+// WithSink registers the sink that receives records produced during the
+// run. sink must not be nil.
+func WithSink(sink Sink) Option {
+	return func(cfg *Config) error {
+		if sink == nil {
+			return fmt.Errorf("WithSink: sink must not be nil")
+		}
+		cfg.Sink = sink
+		return nil
+	}
+}
+
+// WithHooks registers the lifecycle hooks notified of run progress. hooks
+// must not be nil.
+func WithHooks(hooks Hooks) Option {
+	return func(cfg *Config) error {
+		if hooks == nil {
+			return fmt.Errorf("WithHooks: hooks must not be nil")
+		}
+		cfg.Hooks = hooks
+		return nil
+	}
+}
@@ -0,0 +1,36 @@
+package backfill
+
+import "testing"
+
+func TestNewAppliesOptions(t *testing.T) {
+	cfg, err := New(
+		WithDIDs(WorkItem{DID: "did:plc:abc"}),
+		WithConcurrency(4),
+		WithRateLimit(2.5),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if cfg.Concurrency != 4 {
+		t.Errorf("Concurrency = %d, want 4", cfg.Concurrency)
+	}
+	if cfg.RateLimit != 2.5 {
+		t.Errorf("RateLimit = %v, want 2.5", cfg.RateLimit)
+	}
+	if len(cfg.DIDs) != 1 || cfg.DIDs[0].DID != "did:plc:abc" {
+		t.Errorf("DIDs = %+v, want one did:plc:abc", cfg.DIDs)
+	}
+}
+
+func TestNewRejectsInvalidOptions(t *testing.T) {
+	cases := []Option{
+		WithConcurrency(0),
+		WithRateLimit(-1),
+		WithSink(nil),
+	}
+	for _, opt := range cases {
+		if _, err := New(opt); err == nil {
+			t.Errorf("New(%v) error = nil, want an error", opt)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package backfill
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecoverDIDResultReturnsWorkResultWhenNoPanic(t *testing.T) {
+	want := DIDResult{DID: "did:plc:alice", Handle: "alice.bsky.social"}
+	got := recoverDIDResult(ResolvedDID{DID: "did:plc:alice"}, time.Now(), func() DIDResult {
+		return want
+	})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("recoverDIDResult() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRecoverDIDResultTurnsPanicIntoFailedResult(t *testing.T) {
+	got := recoverDIDResult(ResolvedDID{DID: "did:plc:alice", PDSHost: "bsky.social"}, time.Now(), func() DIDResult {
+		var records []Record
+		return DIDResult{Records: len(records[5].URI)} // index out of range
+	})
+
+	if got.DID != "did:plc:alice" || got.PDSHost != "bsky.social" {
+		t.Errorf("got %+v, want DID/PDSHost carried over from the resolved DID", got)
+	}
+	if got.Err == nil || !errors.Is(got.Err, ErrPanic) {
+		t.Errorf("Err = %v, want ErrPanic", got.Err)
+	}
+	if got.ErrClass != "panic" {
+		t.Errorf("ErrClass = %q, want %q", got.ErrClass, "panic")
+	}
+}
@@ -0,0 +1,20 @@
+package backfill
+
+import "errors"
+
+// ErrParquetUnsupported is returned by NewParquetSink. Writing Parquet
+// needs a columnar encoder (e.g. apache/arrow-go's parquet package or
+// segmentio/parquet-go); this module vendors no dependencies, stdlib-only,
+// so that encoder isn't available here. Until this module is allowed a
+// vendored dependency, get Parquet output by writing JSON-lines via
+// FileSink and converting with `pyarrow.json.read_json` followed by
+// `pyarrow.parquet.write_table`, or a DuckDB `COPY ... TO ... (FORMAT
+// PARQUET)` step.
+var ErrParquetUnsupported = errors.New("backfill: parquet output requires a columnar-encoder dependency not vendored in this module")
+
+// NewParquetSink always returns ErrParquetUnsupported. It exists so a
+// --format parquet flag has somewhere to fail clearly instead of silently
+// falling back to another format.
+func NewParquetSink(path string) (Sink, error) {
+	return nil, ErrParquetUnsupported
+}
@@ -0,0 +1,16 @@
+package backfill
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewParquetSinkReportsUnsupported(t *testing.T) {
+	sink, err := NewParquetSink("out.parquet")
+	if sink != nil {
+		t.Errorf("got non-nil sink, want nil")
+	}
+	if !errors.Is(err, ErrParquetUnsupported) {
+		t.Errorf("NewParquetSink() error = %v, want ErrParquetUnsupported", err)
+	}
+}
@@ -0,0 +1,49 @@
+package backfill
+
+import "sync"
+
+// plcCacheEntry is a cached PLC document lookup: the validators needed to
+// issue a conditional GET on the next refresh, plus the result that
+// lookup produced, to reuse when the directory reports the document
+// hasn't changed.
+type plcCacheEntry struct {
+	etag         string
+	lastModified string
+	resolved     ResolvedDID
+}
+
+// plcDocumentCache stores the most recent ETag/Last-Modified validators
+// and resolved result for each PLC document ResolveDID has looked up, so
+// a later lookup for the same (plcBaseURL, did) can issue a conditional
+// GET and reuse the cached result on a 304 instead of a full document
+// fetch and re-parse. Entries are never evicted, so a long-running
+// process resolving an unbounded set of distinct DIDs will grow this
+// cache unboundedly; that's an acceptable tradeoff for the batch and
+// daemon workloads this module targets today.
+type plcDocumentCache struct {
+	mu      sync.Mutex
+	entries map[string]plcCacheEntry
+}
+
+func newPLCDocumentCache() *plcDocumentCache {
+	return &plcDocumentCache{entries: make(map[string]plcCacheEntry)}
+}
+
+func (c *plcDocumentCache) get(key string) (plcCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	return e, ok
+}
+
+func (c *plcDocumentCache) set(key string, e plcCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = e
+}
+
+// plcCache is the process-wide cache ResolveDID consults, analogous to
+// resolveGroup: every caller in the process should see the same view of
+// what the PLC directory most recently told us, so it's a package-level
+// instance rather than something threaded through every call site.
+var plcCache = newPLCDocumentCache()
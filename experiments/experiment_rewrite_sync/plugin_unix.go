@@ -0,0 +1,42 @@
+//go:build linux || darwin || freebsd
+
+package backfill
+
+import (
+	"fmt"
+	"plugin"
+)
+
+// LoadTransformPlugin opens a Go plugin built with
+// `go build -buildmode=plugin` and looks up symbol, which must be a package
+// level variable of type Transform, e.g.:
+//
+//	package main
+//	var Redact backfill.Transform = func(did string, rec backfill.Record) (backfill.Record, bool, error) { ... }
+//
+// WASM modules aren't supported as transforms: that needs a WASM runtime
+// (wazero, wasmtime-go, ...), which isn't vendored in this stdlib-only
+// module. Go's native plugin package, used here, has no such dependency,
+// at the cost of only working on the platforms Go's plugin package
+// supports (linux, darwin, freebsd) and requiring the plugin be built
+// with the exact same Go toolchain version as this binary.
+func LoadTransformPlugin(path, symbol string) (Transform, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("load transform plugin %s: %w", path, err)
+	}
+
+	sym, err := p.Lookup(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("load transform plugin %s: %w", path, err)
+	}
+
+	switch fn := sym.(type) {
+	case Transform:
+		return fn, nil
+	case *Transform:
+		return *fn, nil
+	default:
+		return nil, fmt.Errorf("load transform plugin %s: symbol %q is %T, want backfill.Transform", path, symbol, sym)
+	}
+}
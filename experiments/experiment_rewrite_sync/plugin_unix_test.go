@@ -0,0 +1,11 @@
+//go:build linux || darwin || freebsd
+
+package backfill
+
+import "testing"
+
+func TestLoadTransformPluginMissingFile(t *testing.T) {
+	if _, err := LoadTransformPlugin("/nonexistent/transform.so", "Redact"); err == nil {
+		t.Fatal("LoadTransformPlugin() error = nil, want error for a missing plugin file")
+	}
+}
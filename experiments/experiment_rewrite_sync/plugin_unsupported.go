@@ -0,0 +1,15 @@
+//go:build !(linux || darwin || freebsd)
+
+package backfill
+
+import "errors"
+
+// ErrPluginsUnsupported is returned by LoadTransformPlugin on platforms
+// Go's plugin package doesn't support (e.g. windows).
+var ErrPluginsUnsupported = errors.New("backfill: go plugins aren't supported on this platform")
+
+// LoadTransformPlugin always returns ErrPluginsUnsupported on this
+// platform. See plugin_unix.go for the supported platforms.
+func LoadTransformPlugin(path, symbol string) (Transform, error) {
+	return nil, ErrPluginsUnsupported
+}
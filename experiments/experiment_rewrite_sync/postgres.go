@@ -0,0 +1,199 @@
+package backfill
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PostgresSink writes records into a Postgres table, batching rows into a
+// single multi-row upsert per flush so resuming or re-running a backfill
+// against the same table never creates duplicate rows (see UpsertKey).
+//
+// It takes an already-opened *sql.DB rather than importing a driver itself:
+// this module has no vendored dependencies, and database/sql drivers
+// (lib/pq, pgx, ...) are registered by the caller's main package via their
+// own blank import. That also rules out Postgres's COPY protocol, which
+// needs a driver-specific extension (e.g. lib/pq's pq.CopyIn) — bulk
+// loading here is batched multi-row INSERT ... ON CONFLICT statements
+// instead, which every database/sql driver supports.
+type PostgresSink struct {
+	db            *sql.DB
+	schema        string
+	table         string
+	batchSize     int
+	flushInterval time.Duration
+
+	stopCh  chan struct{}
+	stopped sync.WaitGroup
+
+	mu       sync.Mutex
+	pending  []pendingRow
+	flushErr error
+}
+
+type pendingRow struct {
+	key   UpsertKey
+	value json.RawMessage
+}
+
+// PostgresSinkOption configures a PostgresSink constructed with
+// NewPostgresSink.
+type PostgresSinkOption func(*PostgresSink)
+
+// WithSchema sets the Postgres schema the sink writes into. Defaults to
+// "public".
+func WithSchema(schema string) PostgresSinkOption {
+	return func(s *PostgresSink) { s.schema = schema }
+}
+
+// WithTable sets the Postgres table the sink writes into. Defaults to
+// "bluesky_records".
+func WithTable(table string) PostgresSinkOption {
+	return func(s *PostgresSink) { s.table = table }
+}
+
+// WithBatchSize sets how many rows PostgresSink buffers before flushing
+// them as a single upsert statement. Defaults to 500.
+func WithBatchSize(n int) PostgresSinkOption {
+	return func(s *PostgresSink) { s.batchSize = n }
+}
+
+// WithFlushInterval makes PostgresSink flush its buffered rows at least
+// this often even if WithBatchSize's threshold hasn't been reached, so a
+// slow trickle of writes against an otherwise-quiet table doesn't sit
+// unflushed indefinitely. Zero (the default) disables the interval
+// flush; rows still flush once the batch fills, and Close always flushes
+// whatever remains.
+func WithFlushInterval(d time.Duration) PostgresSinkOption {
+	return func(s *PostgresSink) { s.flushInterval = d }
+}
+
+// NewPostgresSink creates a PostgresSink that writes through db, which the
+// caller must have already opened with a registered Postgres driver.
+func NewPostgresSink(db *sql.DB, opts ...PostgresSinkOption) *PostgresSink {
+	s := &PostgresSink{db: db, schema: "public", table: "bluesky_records", batchSize: 500}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.flushInterval > 0 {
+		s.stopCh = make(chan struct{})
+		s.stopped.Add(1)
+		go s.flushLoop()
+	}
+	return s
+}
+
+// flushLoop periodically flushes buffered rows until stopCh is closed, so
+// WithFlushInterval keeps its promise independent of how often Write is
+// called. A flush error is recorded rather than returned from here (there's
+// no caller waiting on this goroutine to report it to); Close surfaces it
+// instead.
+func (s *PostgresSink) flushLoop() {
+	defer s.stopped.Done()
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Flush(context.Background()); err != nil {
+				s.mu.Lock()
+				s.flushErr = err
+				s.mu.Unlock()
+			}
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// Write buffers rec and flushes the batch once it reaches the configured
+// batch size.
+func (s *PostgresSink) Write(ctx context.Context, did string, rec Record) error {
+	key, err := RecordUpsertKey(did, rec)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.pending = append(s.pending, pendingRow{key: key, value: rec.Value})
+	flush := len(s.pending) >= s.batchSize
+	s.mu.Unlock()
+
+	if flush {
+		return s.Flush(ctx)
+	}
+	return nil
+}
+
+// Flush upserts every buffered row in a single transaction and clears the
+// buffer. Safe to call with an empty buffer.
+func (s *PostgresSink) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	rows := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+
+	if len(rows) == 0 {
+		return nil
+	}
+
+	query, args := s.buildUpsert(rows)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("postgres sink flush: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("postgres sink flush: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("postgres sink flush: %w", err)
+	}
+	return nil
+}
+
+// buildUpsert renders a single INSERT ... ON CONFLICT DO UPDATE statement
+// covering every row, using $N placeholders ($1, $2, ...) as Postgres
+// expects.
+func (s *PostgresSink) buildUpsert(rows []pendingRow) (string, []any) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "INSERT INTO %s.%s (did, collection, rkey, cid, value) VALUES ", s.schema, s.table)
+
+	args := make([]any, 0, len(rows)*5)
+	for i, row := range rows {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		base := i * 5
+		fmt.Fprintf(&sb, "($%d, $%d, $%d, $%d, $%d)", base+1, base+2, base+3, base+4, base+5)
+		args = append(args, row.key.DID, row.key.Collection, row.key.Rkey, row.key.CID, []byte(row.value))
+	}
+
+	sb.WriteString(" ON CONFLICT (did, collection, rkey) DO UPDATE SET cid = excluded.cid, value = excluded.value")
+	return sb.String(), args
+}
+
+// Close stops the background interval flush (if any), flushes any buffered
+// rows, and reports whichever error, if any, a prior interval flush hit.
+// It does not close the underlying *sql.DB, which the caller owns.
+func (s *PostgresSink) Close() error {
+	if s.stopCh != nil {
+		close(s.stopCh)
+		s.stopped.Wait()
+	}
+
+	err := s.Flush(context.Background())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return s.flushErr
+}
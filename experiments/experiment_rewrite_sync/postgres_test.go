@@ -0,0 +1,173 @@
+package backfill
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakePGDriver is a minimal database/sql driver that records every query
+// and its arguments instead of talking to a real Postgres server, so
+// PostgresSink can be tested without a vendored driver dependency.
+type fakePGDriver struct {
+	mu      sync.Mutex
+	queries []string
+	args    [][]driver.Value
+}
+
+func (d *fakePGDriver) Open(name string) (driver.Conn, error) {
+	return &fakePGConn{d: d}, nil
+}
+
+type fakePGConn struct{ d *fakePGDriver }
+
+func (c *fakePGConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakePGStmt{d: c.d, query: query}, nil
+}
+func (c *fakePGConn) Close() error              { return nil }
+func (c *fakePGConn) Begin() (driver.Tx, error) { return fakePGTx{}, nil }
+
+type fakePGTx struct{}
+
+func (fakePGTx) Commit() error   { return nil }
+func (fakePGTx) Rollback() error { return nil }
+
+type fakePGStmt struct {
+	d     *fakePGDriver
+	query string
+}
+
+func (s *fakePGStmt) Close() error  { return nil }
+func (s *fakePGStmt) NumInput() int { return -1 }
+func (s *fakePGStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	s.d.queries = append(s.d.queries, s.query)
+	s.d.args = append(s.d.args, args)
+	return driver.RowsAffected(0), nil
+}
+func (s *fakePGStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, sql.ErrNoRows
+}
+
+func TestPostgresSinkBuildsUpsertWithOnConflict(t *testing.T) {
+	sink := NewPostgresSink(nil, WithSchema("bluesky"), WithTable("records"))
+	query, args := sink.buildUpsert([]pendingRow{
+		{key: UpsertKey{DID: "did:plc:alice", Collection: "app.bsky.feed.post", Rkey: "1", CID: "bafy1"}},
+		{key: UpsertKey{DID: "did:plc:bob", Collection: "app.bsky.feed.post", Rkey: "2", CID: "bafy2"}},
+	})
+
+	if !strings.Contains(query, "bluesky.records") {
+		t.Errorf("query = %q, want it to reference bluesky.records", query)
+	}
+	if !strings.Contains(query, "ON CONFLICT (did, collection, rkey) DO UPDATE") {
+		t.Errorf("query = %q, want an ON CONFLICT upsert clause", query)
+	}
+	if !strings.Contains(query, "$1, $2, $3, $4, $5") || !strings.Contains(query, "$6, $7, $8, $9, $10") {
+		t.Errorf("query = %q, want placeholders for both rows", query)
+	}
+	if len(args) != 10 {
+		t.Fatalf("got %d args, want 10 (5 per row)", len(args))
+	}
+}
+
+func TestPostgresSinkFlushExecutesOneStatementPerBatch(t *testing.T) {
+	drv := &fakePGDriver{}
+	driverName := "fake-postgres-" + t.Name()
+	sql.Register(driverName, drv)
+
+	db, err := sql.Open(driverName, "")
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	defer db.Close()
+
+	sink := NewPostgresSink(db, WithBatchSize(2))
+	ctx := context.Background()
+
+	if err := sink.Write(ctx, "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1", CID: "bafy1"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := sink.Write(ctx, "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/2", CID: "bafy2"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	drv.mu.Lock()
+	got := len(drv.queries)
+	drv.mu.Unlock()
+	if got != 1 {
+		t.Fatalf("got %d exec'd statements after reaching batch size, want 1", got)
+	}
+}
+
+func TestPostgresSinkCloseFlushesRemainder(t *testing.T) {
+	drv := &fakePGDriver{}
+	driverName := "fake-postgres-" + t.Name()
+	sql.Register(driverName, drv)
+
+	db, err := sql.Open(driverName, "")
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	defer db.Close()
+
+	sink := NewPostgresSink(db, WithBatchSize(10))
+	ctx := context.Background()
+
+	if err := sink.Write(ctx, "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1", CID: "bafy1"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	drv.mu.Lock()
+	before := len(drv.queries)
+	drv.mu.Unlock()
+	if before != 0 {
+		t.Fatalf("got %d exec'd statements before Close, want 0 (below batch size)", before)
+	}
+
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	drv.mu.Lock()
+	after := len(drv.queries)
+	drv.mu.Unlock()
+	if after != 1 {
+		t.Fatalf("got %d exec'd statements after Close, want 1", after)
+	}
+}
+
+func TestPostgresSinkWithFlushIntervalFlushesAPartialBatch(t *testing.T) {
+	drv := &fakePGDriver{}
+	driverName := "fake-postgres-" + t.Name()
+	sql.Register(driverName, drv)
+
+	db, err := sql.Open(driverName, "")
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	defer db.Close()
+
+	sink := NewPostgresSink(db, WithBatchSize(1000), WithFlushInterval(10*time.Millisecond))
+	defer sink.Close()
+
+	if err := sink.Write(context.Background(), "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1", CID: "bafy1"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		drv.mu.Lock()
+		n := len(drv.queries)
+		drv.mu.Unlock()
+		if n == 1 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("flush interval never flushed the buffered row")
+}
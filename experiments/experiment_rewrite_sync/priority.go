@@ -0,0 +1,33 @@
+package backfill
+
+import "sort"
+
+// orderByPriority returns items sorted so higher-priority DIDs come first.
+// Items with equal priority keep their relative input order.
+func orderByPriority(items []WorkItem) []WorkItem {
+	ordered := make([]WorkItem, len(items))
+	copy(ordered, items)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Priority > ordered[j].Priority
+	})
+	return ordered
+}
+
+// workItemChannel normalizes cfg's work items to a channel for Run's
+// resolve stage: cfg.DIDStream directly, if set, or a channel fed from
+// orderByPriority(cfg.DIDs) otherwise. Using DIDStream avoids holding the
+// priority-ordered copy orderByPriority would otherwise produce, for
+// input sets too large to duplicate in memory.
+func workItemChannel(cfg Config) <-chan WorkItem {
+	if cfg.DIDStream != nil {
+		return cfg.DIDStream
+	}
+	ch := make(chan WorkItem)
+	go func() {
+		defer close(ch)
+		for _, item := range orderByPriority(cfg.DIDs) {
+			ch <- item
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,63 @@
+package backfill
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOrderByPriority(t *testing.T) {
+	items := []WorkItem{
+		{DID: "did:plc:a", Priority: 0},
+		{DID: "did:plc:b", Priority: 5},
+		{DID: "did:plc:c", Priority: 0},
+		{DID: "did:plc:d", Priority: 5},
+	}
+
+	got := orderByPriority(items)
+
+	want := []WorkItem{
+		{DID: "did:plc:b", Priority: 5},
+		{DID: "did:plc:d", Priority: 5},
+		{DID: "did:plc:a", Priority: 0},
+		{DID: "did:plc:c", Priority: 0},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("orderByPriority() = %+v, want %+v", got, want)
+	}
+}
+
+func TestWorkItemChannelOrdersDIDsByPriority(t *testing.T) {
+	cfg := Config{DIDs: []WorkItem{
+		{DID: "did:plc:a", Priority: 0},
+		{DID: "did:plc:b", Priority: 5},
+	}}
+
+	var got []WorkItem
+	for item := range workItemChannel(cfg) {
+		got = append(got, item)
+	}
+
+	want := []WorkItem{{DID: "did:plc:b", Priority: 5}, {DID: "did:plc:a", Priority: 0}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("workItemChannel() = %+v, want %+v", got, want)
+	}
+}
+
+func TestWorkItemChannelPrefersDIDStream(t *testing.T) {
+	stream := make(chan WorkItem, 1)
+	stream <- WorkItem{DID: "did:plc:streamed"}
+	close(stream)
+
+	cfg := Config{DIDs: []WorkItem{{DID: "did:plc:ignored"}}, DIDStream: stream}
+
+	var got []WorkItem
+	for item := range workItemChannel(cfg) {
+		got = append(got, item)
+	}
+
+	want := []WorkItem{{DID: "did:plc:streamed"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("workItemChannel() = %+v, want %+v", got, want)
+	}
+}
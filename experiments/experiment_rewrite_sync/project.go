@@ -0,0 +1,64 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// ProjectFields extracts fields from rec into a new JSON object and returns
+// a copy of rec with Value replaced by it. Each field is either one of the
+// well-known identifiers did, uri, cid, collection, rkey (resolved the same
+// way as FilterExpr) or a top-level key of the record's original decoded
+// value, e.g. "text" or "createdAt". Fields that resolve to nothing are
+// omitted rather than erroring, since --fields lists are often reused
+// across collections that don't all have the same shape.
+//
+// Record.URI and Record.CID on the returned Record are left unchanged:
+// Fields controls only the contents of Value, since URI/CID are how
+// downstream tooling (diff, upsert keys, manifests) identifies the record
+// regardless of projection.
+func ProjectFields(did string, rec Record, fields []string) (Record, error) {
+	ctx := buildFilterContext(did, rec)
+	out := make(map[string]any, len(fields))
+	for _, field := range fields {
+		if v, ok := ctx.lookup(field); ok {
+			out[field] = v
+			continue
+		}
+		if ctx.record == nil {
+			continue
+		}
+		if v, ok := ctx.record[field]; ok {
+			out[field] = v
+		}
+	}
+
+	value, err := json.Marshal(out)
+	if err != nil {
+		return Record{}, fmt.Errorf("project fields: %w", err)
+	}
+	rec.Value = value
+	return rec, nil
+}
+
+// ProjectSink wraps another Sink and rewrites every record's Value down to
+// the selected Fields before forwarding it, shrinking output for consumers
+// that only need a handful of columns.
+type ProjectSink struct {
+	fields []string
+	next   Sink
+}
+
+// NewProjectSink wraps next so every write is projected to fields first.
+func NewProjectSink(fields []string, next Sink) *ProjectSink {
+	return &ProjectSink{fields: fields, next: next}
+}
+
+func (s *ProjectSink) Write(ctx context.Context, did string, rec Record) error {
+	projected, err := ProjectFields(did, rec, s.fields)
+	if err != nil {
+		return err
+	}
+	return s.next.Write(ctx, did, projected)
+}
@@ -0,0 +1,83 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestProjectFieldsSelectsWellKnownAndRecordFields(t *testing.T) {
+	rec := Record{
+		URI:   "at://did:plc:alice/app.bsky.feed.post/1",
+		CID:   "bafy1",
+		Value: []byte(`{"text":"hello","createdAt":"2023-06-15T12:00:00Z","extra":"drop me"}`),
+	}
+
+	got, err := ProjectFields("did:plc:alice", rec, []string{"did", "uri", "createdAt", "text"})
+	if err != nil {
+		t.Fatalf("ProjectFields() error = %v", err)
+	}
+
+	var out map[string]any
+	if err := json.Unmarshal(got.Value, &out); err != nil {
+		t.Fatalf("unmarshal projected value: %v", err)
+	}
+
+	want := map[string]any{
+		"did":       "did:plc:alice",
+		"uri":       "at://did:plc:alice/app.bsky.feed.post/1",
+		"createdAt": "2023-06-15T12:00:00Z",
+		"text":      "hello",
+	}
+	if len(out) != len(want) {
+		t.Fatalf("got %d fields, want %d: %v", len(out), len(want), out)
+	}
+	for k, v := range want {
+		if out[k] != v {
+			t.Errorf("field %q = %v, want %v", k, out[k], v)
+		}
+	}
+
+	// URI/CID on the envelope are untouched by projection.
+	if got.URI != rec.URI || got.CID != rec.CID {
+		t.Errorf("got URI/CID = %q/%q, want them unchanged", got.URI, got.CID)
+	}
+}
+
+func TestProjectFieldsOmitsMissingFields(t *testing.T) {
+	rec := Record{URI: "at://did:plc:alice/app.bsky.feed.post/1", Value: []byte(`{"text":"hi"}`)}
+
+	got, err := ProjectFields("did:plc:alice", rec, []string{"text", "langs"})
+	if err != nil {
+		t.Fatalf("ProjectFields() error = %v", err)
+	}
+
+	var out map[string]any
+	if err := json.Unmarshal(got.Value, &out); err != nil {
+		t.Fatalf("unmarshal projected value: %v", err)
+	}
+	if _, ok := out["langs"]; ok {
+		t.Errorf("got langs field present, want it omitted")
+	}
+	if out["text"] != "hi" {
+		t.Errorf("text = %v, want %q", out["text"], "hi")
+	}
+}
+
+func TestProjectSinkForwardsProjectedRecord(t *testing.T) {
+	next := &recordingSink{}
+	sink := NewProjectSink([]string{"text"}, next)
+
+	rec := Record{URI: "at://did:plc:alice/app.bsky.feed.post/1", Value: []byte(`{"text":"hi","extra":"x"}`)}
+	if err := sink.Write(context.Background(), "did:plc:alice", rec); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	var out map[string]any
+	if err := json.Unmarshal(next.writes[0].Value, &out); err != nil {
+		t.Fatalf("unmarshal projected value: %v", err)
+	}
+	if len(out) != 1 || out["text"] != "hi" {
+		t.Errorf("got %v, want only text=hi", out)
+	}
+}
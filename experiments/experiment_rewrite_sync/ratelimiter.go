@@ -0,0 +1,112 @@
+package backfill
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// tokenBucketLimiter throttles outbound requests to a fixed rate, shared
+// across every goroutine in a run. Unlike hostLimiter (which caps how
+// many requests may be in flight at once), it caps how many can start per
+// second, and its token level can be snapshotted and restored across
+// process restarts (see ratelimiterstate.go).
+type tokenBucketLimiter struct {
+	mu         sync.Mutex
+	ratePerSec float64
+	burst      float64
+	tokens     float64
+	last       time.Time
+	now        func() time.Time
+}
+
+// newTokenBucketLimiter returns a limiter allowing ratePerSec requests
+// per second, with a burst of up to one second's worth (at least 1). It
+// returns nil for ratePerSec <= 0, so callers can treat a nil limiter as
+// "unlimited" without a separate nil check at every call site.
+func newTokenBucketLimiter(ratePerSec float64) *tokenBucketLimiter {
+	if ratePerSec <= 0 {
+		return nil
+	}
+	burst := ratePerSec
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucketLimiter{ratePerSec: ratePerSec, burst: burst, tokens: burst, last: time.Now(), now: time.Now}
+}
+
+// tokenEpsilon absorbs the floating-point error that accumulates in
+// l.tokens across repeated fractional refills. Without it, a token level
+// that's arithmetically exactly 1 can land a hair under due to rounding,
+// and wait would compute a new near-zero wait duration forever without
+// ever seeing tokens reach 1 — a hang that doesn't depend on how long
+// the caller is willing to block.
+const tokenEpsilon = 1e-9
+
+// wait blocks until a token is available, or ctx is canceled. A nil
+// limiter never blocks.
+func (l *tokenBucketLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	for {
+		l.mu.Lock()
+		l.refillLocked()
+		if l.tokens >= 1-tokenEpsilon {
+			l.tokens--
+			if l.tokens < 0 {
+				l.tokens = 0
+			}
+			l.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - l.tokens) / l.ratePerSec * float64(time.Second))
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (l *tokenBucketLimiter) refillLocked() {
+	now := l.now()
+	elapsed := now.Sub(l.last).Seconds()
+	l.tokens += elapsed * l.ratePerSec
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+}
+
+// snapshot returns the current token level, for persistence across
+// restarts. It returns 0 for a nil limiter.
+func (l *tokenBucketLimiter) snapshot() float64 {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refillLocked()
+	return l.tokens
+}
+
+// restore sets the current token level from a persisted snapshot,
+// clamped to [0, burst]. A no-op on a nil limiter.
+func (l *tokenBucketLimiter) restore(tokens float64) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if tokens < 0 {
+		tokens = 0
+	}
+	if tokens > l.burst {
+		tokens = l.burst
+	}
+	l.tokens = tokens
+	l.last = l.now()
+}
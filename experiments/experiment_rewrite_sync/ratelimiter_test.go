@@ -0,0 +1,78 @@
+package backfill
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketLimiterNilIsUnlimited(t *testing.T) {
+	var l *tokenBucketLimiter
+	if err := l.wait(context.Background()); err != nil {
+		t.Errorf("wait() on nil limiter = %v, want nil", err)
+	}
+	if got := l.snapshot(); got != 0 {
+		t.Errorf("snapshot() on nil limiter = %v, want 0", got)
+	}
+}
+
+func TestTokenBucketLimiterConsumesAndRefillsTokens(t *testing.T) {
+	fakeNow := time.Now()
+	l := newTokenBucketLimiter(10)
+	l.now = func() time.Time { return fakeNow }
+	l.last = fakeNow
+
+	for i := 0; i < 10; i++ {
+		if err := l.wait(context.Background()); err != nil {
+			t.Fatalf("wait() #%d error = %v", i, err)
+		}
+	}
+	if got := l.snapshot(); got >= 1 {
+		t.Errorf("snapshot() after exhausting burst = %v, want < 1", got)
+	}
+
+	fakeNow = fakeNow.Add(500 * time.Millisecond)
+	if got, want := l.snapshot(), 5.0; got < want-0.01 || got > want+0.01 {
+		t.Errorf("snapshot() after 500ms at 10/s = %v, want ~%v", got, want)
+	}
+}
+
+func TestTokenBucketLimiterWaitBlocksUntilRefill(t *testing.T) {
+	l := newTokenBucketLimiter(100)
+	l.restore(0)
+
+	start := time.Now()
+	if err := l.wait(context.Background()); err != nil {
+		t.Fatalf("wait() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 5*time.Millisecond {
+		t.Errorf("wait() returned in %v, want to block ~10ms for a refill at 100/s", elapsed)
+	}
+}
+
+func TestTokenBucketLimiterWaitRespectsContextCancellation(t *testing.T) {
+	l := newTokenBucketLimiter(1)
+	if err := l.wait(context.Background()); err != nil {
+		t.Fatalf("wait() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.wait(ctx); err == nil {
+		t.Error("wait() on an already-canceled context = nil, want context.Canceled")
+	}
+}
+
+func TestTokenBucketLimiterRestoreClampsToBurst(t *testing.T) {
+	fakeNow := time.Now()
+	l := newTokenBucketLimiter(5)
+	l.now = func() time.Time { return fakeNow }
+	l.restore(100)
+	if got := l.snapshot(); got != 5 {
+		t.Errorf("snapshot() after restore(100) = %v, want clamped to burst 5", got)
+	}
+	l.restore(-10)
+	if got := l.snapshot(); got != 0 {
+		t.Errorf("snapshot() after restore(-10) = %v, want clamped to 0", got)
+	}
+}
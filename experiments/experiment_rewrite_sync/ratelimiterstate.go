@@ -0,0 +1,47 @@
+package backfill
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// rateLimiterState is the on-disk snapshot of a tokenBucketLimiter's
+// token level, so an immediate restart after a crash doesn't start back
+// up with a full burst and blow through the provider's window limit.
+type rateLimiterState struct {
+	Tokens float64 `json:"tokens"`
+}
+
+// loadRateLimiterState reads a persisted token level from path. ok is
+// false (not an error) if path is empty or the file doesn't exist yet,
+// e.g. on a fresh run with no prior state to restore.
+func loadRateLimiterState(path string) (state rateLimiterState, ok bool, err error) {
+	if path == "" {
+		return rateLimiterState{}, false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return rateLimiterState{}, false, nil
+		}
+		return rateLimiterState{}, false, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return rateLimiterState{}, false, err
+	}
+	return state, true, nil
+}
+
+// saveRateLimiterState writes the current token level to path, so a
+// future run can restore from it instead of starting with a full burst.
+// A no-op if path is empty.
+func saveRateLimiterState(path string, state rateLimiterState) error {
+	if path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,42 @@
+package backfill
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRateLimiterStateEmptyPathIsNoop(t *testing.T) {
+	if _, ok, err := loadRateLimiterState(""); err != nil || ok {
+		t.Errorf("loadRateLimiterState(\"\") = (_, %v, %v), want (_, false, nil)", ok, err)
+	}
+	if err := saveRateLimiterState("", rateLimiterState{Tokens: 5}); err != nil {
+		t.Errorf("saveRateLimiterState(\"\", ...) error = %v, want nil", err)
+	}
+}
+
+func TestRateLimiterStateMissingFileIsNotAnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.json")
+	if _, ok, err := loadRateLimiterState(path); err != nil || ok {
+		t.Errorf("loadRateLimiterState(missing) = (_, %v, %v), want (_, false, nil)", ok, err)
+	}
+}
+
+func TestRateLimiterStateRoundTrips(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rate-limit.json")
+	want := rateLimiterState{Tokens: 12.5}
+
+	if err := saveRateLimiterState(path, want); err != nil {
+		t.Fatalf("saveRateLimiterState() error = %v", err)
+	}
+
+	got, ok, err := loadRateLimiterState(path)
+	if err != nil {
+		t.Fatalf("loadRateLimiterState() error = %v", err)
+	}
+	if !ok {
+		t.Fatal("loadRateLimiterState() ok = false, want true")
+	}
+	if got != want {
+		t.Errorf("loadRateLimiterState() = %+v, want %+v", got, want)
+	}
+}
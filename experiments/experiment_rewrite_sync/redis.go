@@ -0,0 +1,104 @@
+package backfill
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// redisClient is a minimal RESP (REdis Serialization Protocol) client
+// supporting exactly the commands redisRateLimiter needs (INCR,
+// PEXPIRE, PTTL). It dials a fresh connection per command rather than
+// pooling, which is fine at the request rates a rate limiter itself is
+// meant to bound. It is not a general-purpose Redis client: no pooling,
+// no pipelining, no pub/sub, no RESP3.
+type redisClient struct {
+	addr    string
+	timeout time.Duration
+}
+
+func newRedisClient(addr string, timeout time.Duration) *redisClient {
+	return &redisClient{addr: addr, timeout: timeout}
+}
+
+// do sends a single RESP command and returns its reply: an int64 for
+// integer replies, a string for simple/bulk string replies, or nil for a
+// nil bulk reply. It returns an error for an error reply or any I/O
+// failure.
+func (c *redisClient) do(args ...string) (interface{}, error) {
+	conn, err := net.DialTimeout("tcp", c.addr, nonZeroOr(c.timeout, 5*time.Second))
+	if err != nil {
+		return nil, fmt.Errorf("redis: dial %s: %w", c.addr, err)
+	}
+	defer conn.Close()
+	if c.timeout > 0 {
+		conn.SetDeadline(time.Now().Add(c.timeout))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := conn.Write([]byte(b.String())); err != nil {
+		return nil, fmt.Errorf("redis: write %v: %w", args, err)
+	}
+
+	reply, err := readRESP(bufio.NewReader(conn))
+	if err != nil {
+		return nil, fmt.Errorf("redis: %v: %w", args, err)
+	}
+	return reply, nil
+}
+
+func nonZeroOr(d, fallback time.Duration) time.Duration {
+	if d > 0 {
+		return d
+	}
+	return fallback
+}
+
+// readRESP reads one RESP reply: a simple string (+), error (-), integer
+// (:), or bulk string ($, including the nil bulk reply $-1).
+func readRESP(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("read reply: %w", err)
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, fmt.Errorf("%s", line[1:])
+	case ':':
+		n, err := strconv.ParseInt(line[1:], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed integer reply %q: %w", line, err)
+		}
+		return n, nil
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("malformed bulk reply %q: %w", line, err)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2) // payload plus trailing \r\n
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, fmt.Errorf("read bulk body: %w", err)
+		}
+		return string(buf[:n]), nil
+	default:
+		return nil, fmt.Errorf("unsupported reply type %q", line[0])
+	}
+}
@@ -0,0 +1,115 @@
+package backfill
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeRedisServer is a minimal RESP server that replies to each command
+// with a canned reply looked up by the command name, so redisClient can
+// be tested without a real Redis instance.
+type fakeRedisServer struct {
+	ln      net.Listener
+	replies map[string]string // command name -> raw RESP reply, e.g. ":1\r\n"
+}
+
+func newFakeRedisServer(t *testing.T, replies map[string]string) *fakeRedisServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	s := &fakeRedisServer{ln: ln, replies: replies}
+	go s.serve()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *fakeRedisServer) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeRedisServer) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readRESPCommand(r)
+		if err != nil {
+			return
+		}
+		reply, ok := s.replies[args[0]]
+		if !ok {
+			reply = "-ERR unexpected command\r\n"
+		}
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+// readRESPCommand reads one RESP array-of-bulk-strings command, the
+// format redisClient.do sends.
+func readRESPCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		if _, err := r.ReadString('\n'); err != nil { // "$<len>"
+			return nil, err
+		}
+		bulk, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, strings.TrimRight(bulk, "\r\n"))
+	}
+	return args, nil
+}
+
+func TestRedisClientDoParsesIntegerReply(t *testing.T) {
+	srv := newFakeRedisServer(t, map[string]string{"INCR": ":1\r\n"})
+	client := newRedisClient(srv.ln.Addr().String(), time.Second)
+
+	got, err := client.do("INCR", "some-key")
+	if err != nil {
+		t.Fatalf("do() error = %v", err)
+	}
+	if got != int64(1) {
+		t.Fatalf("do() = %v, want int64(1)", got)
+	}
+}
+
+func TestRedisClientDoParsesErrorReply(t *testing.T) {
+	srv := newFakeRedisServer(t, map[string]string{"PEXPIRE": "-ERR boom\r\n"})
+	client := newRedisClient(srv.ln.Addr().String(), time.Second)
+
+	_, err := client.do("PEXPIRE", "some-key", "1000")
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("do() error = %v, want it to mention the server's error reply", err)
+	}
+}
+
+func TestRedisClientDoReturnsErrorOnDialFailure(t *testing.T) {
+	client := newRedisClient("127.0.0.1:0", 100*time.Millisecond)
+
+	if _, err := client.do("PTTL", "some-key"); err == nil {
+		t.Fatal("do() error = nil, want a dial error against an unreachable address")
+	}
+}
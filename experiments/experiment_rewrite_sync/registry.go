@@ -0,0 +1,121 @@
+package backfill
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// RegistryEntry is what the registry tracks for a single DID: enough to
+// decide whether it needs resyncing and to report on what happened the
+// last time it was.
+type RegistryEntry struct {
+	DID          string    `json:"did"`
+	LastRev      string    `json:"last_rev"`
+	LastSyncedAt time.Time `json:"last_synced_at"`
+	RecordCount  int       `json:"record_count"`
+	Status       string    `json:"status"`
+}
+
+// RepoRegistry tracks the last-known sync state of every DID a backfill
+// process has touched, so later runs (incremental sync, skip-already-done
+// logic, dataset bookkeeping) have something durable to read and update
+// instead of re-deriving it from scratch each time.
+//
+// This module has no vendored dependencies, so there's no SQLite driver
+// available to back this with an actual database file as the name might
+// suggest; it's a JSON document (one entry per DID) rewritten atomically
+// on every Upsert instead. That's fine at the DID-count scales this
+// module targets today, but a process restarting mid-Upsert always sees
+// either the previous complete state or the new one, never a partial
+// write, and every Upsert pays the cost of rewriting the whole file.
+type RepoRegistry struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]RegistryEntry
+}
+
+// OpenRepoRegistry opens the registry persisted at path, creating an
+// empty one if path doesn't exist yet.
+func OpenRepoRegistry(path string) (*RepoRegistry, error) {
+	r := &RepoRegistry{path: path, entries: make(map[string]RegistryEntry)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open registry %s: %w", path, err)
+	}
+
+	var entries []RegistryEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("open registry %s: %w", path, err)
+	}
+	for _, e := range entries {
+		r.entries[e.DID] = e
+	}
+	return r, nil
+}
+
+// Get returns the registered state for did, if any.
+func (r *RepoRegistry) Get(did string) (RegistryEntry, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.entries[did]
+	return e, ok
+}
+
+// All returns every entry currently in the registry, in no particular
+// order.
+func (r *RepoRegistry) All() []RegistryEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]RegistryEntry, 0, len(r.entries))
+	for _, e := range r.entries {
+		out = append(out, e)
+	}
+	return out
+}
+
+// Upsert records entry, replacing any existing entry for the same DID,
+// and persists the registry to disk before returning.
+func (r *RepoRegistry) Upsert(entry RegistryEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[entry.DID] = entry
+	return r.save()
+}
+
+// save rewrites the registry file from the in-memory entries, via a temp
+// file and rename so a reader never observes a partially-written file.
+func (r *RepoRegistry) save() error {
+	entries := make([]RegistryEntry, 0, len(r.entries))
+	for _, e := range r.entries {
+		entries = append(entries, e)
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("save registry %s: %w", r.path, err)
+	}
+
+	if dir := filepath.Dir(r.path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("save registry %s: %w", r.path, err)
+		}
+	}
+
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("save registry %s: %w", r.path, err)
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		return fmt.Errorf("save registry %s: %w", r.path, err)
+	}
+	return nil
+}
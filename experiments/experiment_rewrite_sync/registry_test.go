@@ -0,0 +1,100 @@
+package backfill
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRepoRegistryUpsertThenGet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.json")
+	reg, err := OpenRepoRegistry(path)
+	if err != nil {
+		t.Fatalf("OpenRepoRegistry() error = %v", err)
+	}
+
+	if _, ok := reg.Get("did:plc:alice"); ok {
+		t.Fatal("Get() ok = true before any Upsert")
+	}
+
+	entry := RegistryEntry{
+		DID:          "did:plc:alice",
+		LastRev:      "abc123",
+		LastSyncedAt: time.Unix(1700000000, 0).UTC(),
+		RecordCount:  42,
+		Status:       "ok",
+	}
+	if err := reg.Upsert(entry); err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+
+	got, ok := reg.Get("did:plc:alice")
+	if !ok {
+		t.Fatal("Get() ok = false after Upsert")
+	}
+	if got != entry {
+		t.Errorf("Get() = %+v, want %+v", got, entry)
+	}
+}
+
+func TestRepoRegistryUpsertOverwritesPriorEntryForSameDID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.json")
+	reg, err := OpenRepoRegistry(path)
+	if err != nil {
+		t.Fatalf("OpenRepoRegistry() error = %v", err)
+	}
+
+	if err := reg.Upsert(RegistryEntry{DID: "did:plc:alice", Status: "ok", RecordCount: 1}); err != nil {
+		t.Fatalf("first Upsert() error = %v", err)
+	}
+	if err := reg.Upsert(RegistryEntry{DID: "did:plc:alice", Status: "error", RecordCount: 2}); err != nil {
+		t.Fatalf("second Upsert() error = %v", err)
+	}
+
+	got, ok := reg.Get("did:plc:alice")
+	if !ok {
+		t.Fatal("Get() ok = false")
+	}
+	if got.Status != "error" || got.RecordCount != 2 {
+		t.Errorf("Get() = %+v, want the second Upsert's values", got)
+	}
+	if len(reg.All()) != 1 {
+		t.Errorf("All() has %d entries, want 1 (the second Upsert should overwrite, not append)", len(reg.All()))
+	}
+}
+
+func TestRepoRegistryPersistsAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.json")
+	reg, err := OpenRepoRegistry(path)
+	if err != nil {
+		t.Fatalf("OpenRepoRegistry() error = %v", err)
+	}
+	if err := reg.Upsert(RegistryEntry{DID: "did:plc:alice", Status: "ok"}); err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+	if err := reg.Upsert(RegistryEntry{DID: "did:plc:bob", Status: "filtered"}); err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+
+	reopened, err := OpenRepoRegistry(path)
+	if err != nil {
+		t.Fatalf("re-OpenRepoRegistry() error = %v", err)
+	}
+	if len(reopened.All()) != 2 {
+		t.Fatalf("reopened registry has %d entries, want 2", len(reopened.All()))
+	}
+	if got, ok := reopened.Get("did:plc:bob"); !ok || got.Status != "filtered" {
+		t.Errorf("Get(\"did:plc:bob\") = %+v, %v, want Status=filtered", got, ok)
+	}
+}
+
+func TestOpenRepoRegistryStartsEmptyWhenFileDoesNotExist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "does-not-exist.json")
+	reg, err := OpenRepoRegistry(path)
+	if err != nil {
+		t.Fatalf("OpenRepoRegistry() error = %v", err)
+	}
+	if len(reg.All()) != 0 {
+		t.Errorf("All() = %v, want empty", reg.All())
+	}
+}
@@ -0,0 +1,83 @@
+package backfill
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// resolutionMapEntry is one DID's persisted resolution outcome: either a
+// successful ResolvedDID, or the error resolution hit, so a later
+// fetch-only run loading the map can tell the two apart without needing
+// DIDResult's non-JSON-safe error field.
+type resolutionMapEntry struct {
+	DID         string `json:"did"`
+	Handle      string `json:"handle,omitempty"`
+	PDSHost     string `json:"pds_host,omitempty"`
+	PDSEndpoint string `json:"pds_endpoint,omitempty"`
+	Err         string `json:"error,omitempty"`
+}
+
+// WriteResolutionMap persists resolved and failed DID resolutions to path
+// as a JSON array, producing a reusable artifact: a later run can pass
+// path as Config.LoadResolutionMapPath to fetch repos straight from it
+// without resolving against the PLC directory again.
+func WriteResolutionMap(resolved []ResolvedDID, failed []DIDResult, path string) error {
+	entries := make([]resolutionMapEntry, 0, len(resolved)+len(failed))
+	for _, r := range resolved {
+		entries = append(entries, resolutionMapEntry{
+			DID:         r.DID,
+			Handle:      r.Handle,
+			PDSHost:     r.PDSHost,
+			PDSEndpoint: r.PDSEndpoint,
+		})
+	}
+	for _, d := range failed {
+		entry := resolutionMapEntry{DID: d.DID}
+		if d.Err != nil {
+			entry.Err = d.Err.Error()
+		}
+		entries = append(entries, entry)
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadResolutionMap reads a resolution map previously written by
+// WriteResolutionMap, returning only the entries that resolved
+// successfully; entries that failed to resolve are dropped, since
+// there's nothing for the fetch phase to do with them (a caller that
+// wants to retry those DIDs should re-resolve them normally instead).
+func LoadResolutionMap(path string) ([]ResolvedDID, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []resolutionMapEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	var resolved []ResolvedDID
+	for _, e := range entries {
+		if e.Err != "" || e.PDSEndpoint == "" {
+			continue
+		}
+		resolved = append(resolved, ResolvedDID{
+			DID:         e.DID,
+			Handle:      e.Handle,
+			PDSHost:     e.PDSHost,
+			PDSEndpoint: e.PDSEndpoint,
+		})
+	}
+	return resolved, nil
+}
@@ -0,0 +1,51 @@
+package backfill
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteAndLoadResolutionMapRoundTrips(t *testing.T) {
+	resolved := []ResolvedDID{
+		{DID: "did:plc:alice", Handle: "alice.bsky.social", PDSHost: "bsky.social", PDSEndpoint: "https://bsky.social"},
+	}
+	failed := []DIDResult{
+		{DID: "did:plc:bob", Err: errors.New("not found")},
+	}
+
+	path := filepath.Join(t.TempDir(), "resolution.json")
+	if err := WriteResolutionMap(resolved, failed, path); err != nil {
+		t.Fatalf("WriteResolutionMap() error = %v", err)
+	}
+
+	loaded, err := LoadResolutionMap(path)
+	if err != nil {
+		t.Fatalf("LoadResolutionMap() error = %v", err)
+	}
+	if len(loaded) != 1 {
+		t.Fatalf("got %d resolved entries, want 1", len(loaded))
+	}
+	got := loaded[0]
+	want := resolved[0]
+	if got.DID != want.DID || got.Handle != want.Handle || got.PDSHost != want.PDSHost || got.PDSEndpoint != want.PDSEndpoint {
+		t.Errorf("loaded[0] = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadResolutionMapDropsFailedEntries(t *testing.T) {
+	failed := []DIDResult{{DID: "did:plc:bob", Err: errors.New("not found")}}
+
+	path := filepath.Join(t.TempDir(), "resolution.json")
+	if err := WriteResolutionMap(nil, failed, path); err != nil {
+		t.Fatalf("WriteResolutionMap() error = %v", err)
+	}
+
+	loaded, err := LoadResolutionMap(path)
+	if err != nil {
+		t.Fatalf("LoadResolutionMap() error = %v", err)
+	}
+	if len(loaded) != 0 {
+		t.Errorf("got %d resolved entries, want 0", len(loaded))
+	}
+}
@@ -0,0 +1,159 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// defaultPLCDirectoryURL is the DID PLC directory used to resolve did:plc
+// identifiers to their current PDS service endpoint, unless overridden
+// (e.g. in tests, to point at a mock server).
+const defaultPLCDirectoryURL = "https://plc.directory"
+
+// ResolvedDID is what resolution produces for a single actor: the PDS
+// endpoint currently serving their repo, plus their handle when the PLC
+// document advertises one.
+type ResolvedDID struct {
+	DID    string
+	Handle string
+
+	// PDSHost is the bare host (no scheme) used for filtering and
+	// scheduling, e.g. "bsky.social".
+	PDSHost string
+
+	// PDSEndpoint is the full service endpoint URL used to make requests,
+	// e.g. "https://bsky.social" (or an http:// mock server URL in tests).
+	PDSEndpoint string
+
+	// Attempts records every resolution attempt that led to this result,
+	// including retries. ResolveDID itself never populates this — it's
+	// filled in by resolveWithRetry, which is the only caller that knows
+	// how many attempts it took.
+	Attempts []Attempt
+}
+
+// plcDocument is the subset of a did:plc document we care about.
+type plcDocument struct {
+	AlsoKnownAs []string `json:"alsoKnownAs"`
+	Service     []struct {
+		ID              string `json:"id"`
+		Type            string `json:"type"`
+		ServiceEndpoint string `json:"serviceEndpoint"`
+	} `json:"service"`
+}
+
+// resolveGroup coalesces concurrent ResolveDID calls for the same
+// (plcBaseURL, did) pair, so duplicate DIDs in a run's input (or
+// retries racing each other across goroutines) share one PLC lookup
+// instead of each issuing its own request.
+var resolveGroup singleflightGroup
+
+// ResolveDID looks up the PDS endpoint currently serving did's repo via the
+// PLC directory at plcBaseURL (defaultPLCDirectoryURL if empty). It does
+// not yet handle did:web identifiers.
+func ResolveDID(ctx context.Context, httpClient *http.Client, plcBaseURL, did string) (ResolvedDID, error) {
+	if plcBaseURL == "" {
+		plcBaseURL = defaultPLCDirectoryURL
+	}
+	v, err, _ := resolveGroup.do(plcBaseURL+"|"+did, func() (interface{}, error) {
+		return resolveDID(ctx, httpClient, plcBaseURL, did)
+	})
+	return v.(ResolvedDID), err
+}
+
+// resolveDID does the actual PLC directory lookup; ResolveDID wraps it
+// with resolveGroup for request coalescing.
+func resolveDID(ctx context.Context, httpClient *http.Client, plcBaseURL, did string) (ResolvedDID, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, plcBaseURL+"/"+did, nil)
+	if err != nil {
+		return ResolvedDID{}, fmt.Errorf("resolve %s: %w", did, err)
+	}
+
+	cacheKey := plcBaseURL + "|" + did
+	cached, haveCached := plcCache.get(cacheKey)
+	if haveCached {
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return ResolvedDID{}, fmt.Errorf("resolve %s: %w", did, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && haveCached {
+		return cached.resolved, nil
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// fall through
+	case http.StatusNotFound:
+		return ResolvedDID{}, fmt.Errorf("resolve %s: %w", did, ErrDIDNotFound)
+	case http.StatusGone:
+		return ResolvedDID{}, fmt.Errorf("resolve %s: %w", did, ErrTakenDown)
+	case http.StatusTooManyRequests:
+		return ResolvedDID{}, fmt.Errorf("resolve %s: %w", did, ErrRateLimited)
+	default:
+		return ResolvedDID{}, fmt.Errorf("resolve %s: %w", did, &HTTPStatusError{Op: "plc directory", StatusCode: resp.StatusCode})
+	}
+
+	var doc plcDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return ResolvedDID{}, fmt.Errorf("resolve %s: decode plc document: %w", did, err)
+	}
+
+	var pdsEndpoint string
+	for _, svc := range doc.Service {
+		if svc.Type == "AtprotoPersonalDataServer" {
+			pdsEndpoint = svc.ServiceEndpoint
+			break
+		}
+	}
+	if pdsEndpoint == "" {
+		return ResolvedDID{}, fmt.Errorf("resolve %s: %w", did, ErrNoService)
+	}
+
+	var handle string
+	for _, aka := range doc.AlsoKnownAs {
+		if strings.HasPrefix(aka, "at://") {
+			handle = strings.TrimPrefix(aka, "at://")
+			break
+		}
+	}
+
+	resolved := ResolvedDID{
+		DID:         did,
+		Handle:      handle,
+		PDSHost:     hostFromEndpoint(pdsEndpoint),
+		PDSEndpoint: pdsEndpoint,
+	}
+	plcCache.set(cacheKey, plcCacheEntry{
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+		resolved:     resolved,
+	})
+	return resolved, nil
+}
+
+// hostFromEndpoint strips the scheme from a service endpoint URL, leaving
+// just the host, e.g. "https://bsky.social" -> "bsky.social".
+func hostFromEndpoint(endpoint string) string {
+	host := endpoint
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	return strings.TrimSuffix(host, "/")
+}
@@ -0,0 +1,106 @@
+package backfill
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestResolveDIDCoalescesConcurrentDuplicateCalls(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"service":[{"id":"#atproto_pds","type":"AtprotoPersonalDataServer","serviceEndpoint":"https://pds.example"}]}`))
+	}))
+	defer srv.Close()
+
+	const n = 5
+	var wg sync.WaitGroup
+	results := make([]ResolvedDID, n)
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r, err := ResolveDID(context.Background(), srv.Client(), srv.URL, "did:plc:alice")
+			results[i], errs[i] = r, err
+		}(i)
+	}
+
+	time.Sleep(20 * time.Millisecond) // give every goroutine a chance to join the in-flight lookup
+	close(release)
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("PLC directory got %d requests for %d concurrent duplicate ResolveDID calls, want 1", got, n)
+	}
+	for i := range results {
+		if errs[i] != nil {
+			t.Fatalf("ResolveDID()[%d] error = %v", i, errs[i])
+		}
+		if results[i].PDSHost != "pds.example" {
+			t.Errorf("PDSHost[%d] = %q, want %q", i, results[i].PDSHost, "pds.example")
+		}
+	}
+}
+
+func TestResolveDIDReusesCachedResultOn304(t *testing.T) {
+	did := "did:plc:" + t.Name() // unique key per test so plcCache entries don't leak between tests
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"service":[{"id":"#atproto_pds","type":"AtprotoPersonalDataServer","serviceEndpoint":"https://pds.example"}]}`))
+	}))
+	defer srv.Close()
+
+	first, err := ResolveDID(context.Background(), srv.Client(), srv.URL, did)
+	if err != nil {
+		t.Fatalf("first ResolveDID() error = %v", err)
+	}
+
+	second, err := ResolveDID(context.Background(), srv.Client(), srv.URL, did)
+	if err != nil {
+		t.Fatalf("second ResolveDID() error = %v", err)
+	}
+
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Fatalf("PLC directory got %d requests, want 2 (full GET then a conditional GET that 304s)", got)
+	}
+	if second.PDSHost != first.PDSHost || second.PDSEndpoint != first.PDSEndpoint || second.Handle != first.Handle {
+		t.Errorf("second ResolveDID() = %+v, want the cached result %+v from the 304", second, first)
+	}
+}
+
+func TestResolveDIDDoesNotCoalesceDifferentDIDs(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"service":[{"id":"#atproto_pds","type":"AtprotoPersonalDataServer","serviceEndpoint":"https://pds.example"}]}`))
+	}))
+	defer srv.Close()
+
+	if _, err := ResolveDID(context.Background(), srv.Client(), srv.URL, "did:plc:alice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ResolveDID(context.Background(), srv.Client(), srv.URL, "did:plc:bob"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Fatalf("PLC directory got %d requests for 2 distinct DIDs, want 2", got)
+	}
+}
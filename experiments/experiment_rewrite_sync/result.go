@@ -0,0 +1,168 @@
+package backfill
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// DIDResult is the outcome of processing a single DID through a run:
+// resolution, filtering, and (in later stages) fetching.
+type DIDResult struct {
+	DID      string
+	PDSHost  string
+	Handle   string
+	Filtered bool
+	Reason   string
+	Err      error
+
+	// SpanID identifies this DID's processing within its run, generated
+	// by NewSpanID, so log lines and metrics touching this DID can be
+	// correlated even when Config.Concurrency interleaves many DIDs'
+	// output.
+	SpanID string
+
+	// HandleVerified is only meaningful when Config.VerifyHandles is set
+	// and Handle is non-empty. It reports whether independently
+	// resolving Handle's own DNS TXT or .well-known record confirms it
+	// actually points back at this DID, flagging a handle spoofed in the
+	// PLC document's alsoKnownAs (which the directory operator, not the
+	// handle's owner, controls).
+	HandleVerified bool
+
+	// HandleVerifyErr holds any error from that verification attempt
+	// (e.g. both the DNS and well-known lookups failed), distinct from
+	// Err so a handle verification problem doesn't get confused with a
+	// resolution or fetch failure.
+	HandleVerifyErr error
+
+	// ErrClass categorizes Err using the sentinel error taxonomy (e.g.
+	// "rate_limited", "not_found"), or "" if Err is nil or unrecognized.
+	ErrClass string
+
+	// Attempts records every resolution attempt made for this DID,
+	// including retries. Most DIDs have exactly one.
+	Attempts []Attempt
+
+	// Records and Bytes are populated once Run fetches repo data for this
+	// DID; both are zero for DIDs that only went through resolution and
+	// filtering.
+	Records int
+	Bytes   int64
+
+	// Collections tallies Records by collection NSID (e.g.
+	// "app.bsky.feed.post"), populated alongside Records and Bytes. Nil
+	// for DIDs that only went through resolution and filtering.
+	Collections map[string]int
+
+	// Duration is the wall-clock time spent processing this DID, from
+	// resolution through the end of fetching.
+	Duration time.Duration
+}
+
+// didResultAlias has DIDResult's exact fields with none of its methods,
+// so MarshalJSON/UnmarshalJSON can round-trip through it without
+// recursing into themselves.
+type didResultAlias DIDResult
+
+// MarshalJSON encodes Err and HandleVerifyErr as their error strings,
+// since the error interface itself has no JSON representation and
+// Job.Result (see jobserver.go) is persisted to disk and returned over
+// the REST API as JSON.
+func (d DIDResult) MarshalJSON() ([]byte, error) {
+	wire := struct {
+		didResultAlias
+		Err             string `json:",omitempty"`
+		HandleVerifyErr string `json:",omitempty"`
+	}{didResultAlias: didResultAlias(d)}
+	if d.Err != nil {
+		wire.Err = d.Err.Error()
+	}
+	if d.HandleVerifyErr != nil {
+		wire.HandleVerifyErr = d.HandleVerifyErr.Error()
+	}
+	return json.Marshal(wire)
+}
+
+// UnmarshalJSON is MarshalJSON's inverse. The reconstructed Err and
+// HandleVerifyErr are plain errors carrying only the original message —
+// they don't round-trip through errors.Is against the sentinel errors in
+// errors.go, so ErrClass (which does survive the round trip) remains the
+// right field to branch on after a reload.
+func (d *DIDResult) UnmarshalJSON(data []byte) error {
+	wire := struct {
+		didResultAlias
+		Err             string `json:",omitempty"`
+		HandleVerifyErr string `json:",omitempty"`
+	}{}
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err
+	}
+	*d = DIDResult(wire.didResultAlias)
+	if wire.Err != "" {
+		d.Err = errors.New(wire.Err)
+	}
+	if wire.HandleVerifyErr != "" {
+		d.HandleVerifyErr = errors.New(wire.HandleVerifyErr)
+	}
+	return nil
+}
+
+// Result aggregates the outcome of a full backfill run.
+type Result struct {
+	// RunID is the run that produced PerDID, either Config.RunID or one
+	// generated by Run via NewRunID.
+	RunID string
+
+	PerDID []DIDResult
+
+	// ConnectionStats is populated when Config.WarmUpHosts is set,
+	// reporting how many of the pre-run warm-up requests needed a fresh
+	// handshake versus reused an already-warm connection. Zero value
+	// otherwise.
+	ConnectionStats ConnectionStats
+}
+
+// Skipped returns the DIDs that were filtered out of the run.
+func (r *Result) Skipped() []DIDResult {
+	var out []DIDResult
+	for _, d := range r.PerDID {
+		if d.Filtered {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// Failed returns the DIDs that errored during resolution or fetching.
+func (r *Result) Failed() []DIDResult {
+	var out []DIDResult
+	for _, d := range r.PerDID {
+		if !d.Filtered && d.Err != nil {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// TotalBytes sums Bytes across every DID result, the total volume
+// downloaded across the whole run.
+func (r *Result) TotalBytes() int64 {
+	var total int64
+	for _, d := range r.PerDID {
+		total += d.Bytes
+	}
+	return total
+}
+
+// CollectionCounts sums Collections across every DID result, the run's
+// dataset composition by collection NSID.
+func (r *Result) CollectionCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, d := range r.PerDID {
+		for collection, n := range d.Collections {
+			counts[collection] += n
+		}
+	}
+	return counts
+}
@@ -0,0 +1,112 @@
+package backfill
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// DIDResultJSON is the snake_case projection of a DIDResult written to a
+// ResultReport. It leaves out Attempts and the handle-verification
+// fields; DIDResult's own MarshalJSON (see result.go) keeps the Go field
+// names and every field.
+type DIDResultJSON struct {
+	DID      string        `json:"did"`
+	SpanID   string        `json:"span_id,omitempty"`
+	PDSHost  string        `json:"pds_host,omitempty"`
+	Handle   string        `json:"handle,omitempty"`
+	Filtered bool          `json:"filtered,omitempty"`
+	Reason   string        `json:"reason,omitempty"`
+	Err      string        `json:"error,omitempty"`
+	ErrClass string        `json:"err_class,omitempty"`
+	Records  int           `json:"records,omitempty"`
+	Bytes    int64         `json:"bytes,omitempty"`
+
+	// Collections tallies Records by collection NSID; see
+	// DIDResult.Collections.
+	Collections map[string]int `json:"collections,omitempty"`
+	Duration    time.Duration  `json:"duration"`
+}
+
+// ResultReport is the result JSON written for a run: a provenance record
+// of what happened to every DID, plus the per-host aggregates needed to
+// describe where the data came from and how reliable each source was.
+type ResultReport struct {
+	// RunID identifies the run that produced this report, so multi-run
+	// log and metric aggregation can attribute it to the producing job.
+	RunID string `json:"run_id,omitempty"`
+
+	PerDID []DIDResultJSON      `json:"per_did"`
+	ByHost map[string]HostStats `json:"by_host"`
+
+	// TotalBytes is the sum of Bytes across every DID result, the total
+	// volume downloaded across the whole run.
+	TotalBytes int64 `json:"total_bytes"`
+
+	// CollectionCounts is the run's dataset composition by collection
+	// NSID, summed across every DID result; see Result.CollectionCounts.
+	CollectionCounts map[string]int `json:"collection_counts,omitempty"`
+
+	// EstimatedEgressCostUSD is TotalBytes priced at egressCostPerGB
+	// (see NewResultReport), for grant reporting and capacity planning.
+	// Omitted when no cost per GB was given.
+	EstimatedEgressCostUSD float64 `json:"estimated_egress_cost_usd,omitempty"`
+}
+
+// bytesPerGB converts bytes to gigabytes for egress cost estimation.
+const bytesPerGB = 1 << 30
+
+// NewResultReport builds a ResultReport from result. egressCostPerGB, if
+// positive, estimates EstimatedEgressCostUSD as result.TotalBytes()
+// priced at that rate; zero or negative omits the estimate.
+func NewResultReport(result *Result, egressCostPerGB float64) ResultReport {
+	perDID := make([]DIDResultJSON, len(result.PerDID))
+	for i, d := range result.PerDID {
+		j := DIDResultJSON{
+			DID:      d.DID,
+			SpanID:   d.SpanID,
+			PDSHost:  d.PDSHost,
+			Handle:   d.Handle,
+			Filtered: d.Filtered,
+			Reason:   d.Reason,
+			ErrClass:    d.ErrClass,
+			Records:     d.Records,
+			Bytes:       d.Bytes,
+			Collections: d.Collections,
+			Duration:    d.Duration,
+		}
+		if d.Err != nil {
+			j.Err = d.Err.Error()
+		}
+		perDID[i] = j
+	}
+
+	totalBytes := result.TotalBytes()
+	report := ResultReport{
+		RunID:            result.RunID,
+		PerDID:           perDID,
+		ByHost:           ComputeHostStats(result.PerDID),
+		TotalBytes:       totalBytes,
+		CollectionCounts: result.CollectionCounts(),
+	}
+	if egressCostPerGB > 0 {
+		report.EstimatedEgressCostUSD = float64(totalBytes) / bytesPerGB * egressCostPerGB
+	}
+	return report
+}
+
+// WriteResultReport builds a ResultReport from result (see
+// NewResultReport for egressCostPerGB) and writes it as indented JSON to
+// path.
+func WriteResultReport(result *Result, path string, egressCostPerGB float64) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("write result report: %w", err)
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(NewResultReport(result, egressCostPerGB))
+}
@@ -0,0 +1,62 @@
+package backfill
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteResultReportWritesPerDIDAndPerHost(t *testing.T) {
+	result := &Result{
+		PerDID: []DIDResult{
+			{DID: "did:plc:alice", PDSHost: "bsky.social", Handle: "alice.bsky.social", Records: 3, Bytes: 30},
+			{DID: "did:plc:bob", Err: errors.New("resolve failed")},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "result.json")
+	if err := WriteResultReport(result, path, 0); err != nil {
+		t.Fatalf("WriteResultReport() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read result report: %v", err)
+	}
+
+	var report ResultReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("unmarshal result report: %v", err)
+	}
+
+	if len(report.PerDID) != 2 {
+		t.Fatalf("got %d per_did entries, want 2", len(report.PerDID))
+	}
+	if report.PerDID[1].Err != "resolve failed" {
+		t.Errorf("PerDID[1].Err = %q, want %q", report.PerDID[1].Err, "resolve failed")
+	}
+	if got := report.ByHost["bsky.social"].Records; got != 3 {
+		t.Errorf("ByHost[bsky.social].Records = %d, want 3", got)
+	}
+	if report.TotalBytes != 30 {
+		t.Errorf("TotalBytes = %d, want 30", report.TotalBytes)
+	}
+	if report.EstimatedEgressCostUSD != 0 {
+		t.Errorf("EstimatedEgressCostUSD = %v, want 0 when no cost per GB given", report.EstimatedEgressCostUSD)
+	}
+}
+
+func TestNewResultReportEstimatesEgressCost(t *testing.T) {
+	result := &Result{
+		PerDID: []DIDResult{
+			{DID: "did:plc:alice", Bytes: bytesPerGB},
+		},
+	}
+
+	report := NewResultReport(result, 0.09)
+	if got, want := report.EstimatedEgressCostUSD, 0.09; got != want {
+		t.Errorf("EstimatedEgressCostUSD = %v, want %v", got, want)
+	}
+}
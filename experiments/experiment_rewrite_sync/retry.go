@@ -0,0 +1,165 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Attempt is a single resolution attempt against the PLC directory,
+// recorded so retry behavior can be audited after the fact (see
+// ComputeRetryStats). StatusCode is 0 if the attempt never got a response
+// at all (e.g. a transport-level failure or context cancellation).
+type Attempt struct {
+	Host       string
+	StatusCode int
+	Err        error
+	Duration   time.Duration
+}
+
+// attemptAlias has Attempt's exact fields with none of its methods, so
+// MarshalJSON/UnmarshalJSON can round-trip through it without recursing
+// into themselves.
+type attemptAlias Attempt
+
+// MarshalJSON encodes Err as its error string; see DIDResult.MarshalJSON
+// in result.go for why the error interface needs this.
+func (a Attempt) MarshalJSON() ([]byte, error) {
+	wire := struct {
+		attemptAlias
+		Err string `json:",omitempty"`
+	}{attemptAlias: attemptAlias(a)}
+	if a.Err != nil {
+		wire.Err = a.Err.Error()
+	}
+	return json.Marshal(wire)
+}
+
+// UnmarshalJSON is MarshalJSON's inverse. The reconstructed Err is a
+// plain error carrying only the original message.
+func (a *Attempt) UnmarshalJSON(data []byte) error {
+	wire := struct {
+		attemptAlias
+		Err string `json:",omitempty"`
+	}{}
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err
+	}
+	*a = Attempt(wire.attemptAlias)
+	if wire.Err != "" {
+		a.Err = errors.New(wire.Err)
+	}
+	return nil
+}
+
+// retryPolicy bounds how many times a resolution attempt is retried and
+// how long to wait between attempts. Only rate limiting and 5xx responses
+// are retried; anything else (not found, taken down, a malformed
+// document) would never succeed on retry.
+type retryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+// defaultRetryPolicy backs off 200ms, 400ms, then gives up, which is
+// enough to ride out a brief PLC directory hiccup without materially
+// slowing down a run full of DIDs that resolve fine on the first try.
+var defaultRetryPolicy = retryPolicy{maxAttempts: 3, baseDelay: 200 * time.Millisecond, maxDelay: 2 * time.Second}
+
+// backoff returns how long to wait before the attempt after attempt
+// (0-indexed) retries, doubling each time and capped at maxDelay.
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	d := p.baseDelay << attempt
+	if d <= 0 || d > p.maxDelay {
+		d = p.maxDelay
+	}
+	return d
+}
+
+// isRetryableResolveErr reports whether err, returned by ResolveDID, is
+// worth retrying: rate limiting and 5xx responses are transient; anything
+// else isn't.
+func isRetryableResolveErr(err error) bool {
+	if errors.Is(err, ErrRateLimited) {
+		return true
+	}
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500
+	}
+	return false
+}
+
+// statusCodeOfResolveErr extracts the HTTP status code a failed ResolveDID
+// call observed, for Attempt bookkeeping. It returns 0 for errors that
+// never got a response (e.g. a transport error or context cancellation).
+func statusCodeOfResolveErr(err error) int {
+	switch {
+	case err == nil:
+		return http.StatusOK
+	case errors.Is(err, ErrDIDNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrTakenDown):
+		return http.StatusGone
+	case errors.Is(err, ErrRateLimited):
+		return http.StatusTooManyRequests
+	}
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode
+	}
+	return 0
+}
+
+// resolveWithRetry calls resolveWithWatchdog, retrying transient failures
+// (rate limiting, 5xx) up to policy.maxAttempts times with exponential
+// backoff. It returns every attempt made, successful or not, so callers
+// can attach them to the eventual DIDResult for later reporting. limiter,
+// if non-nil, is waited on before every attempt (including retries).
+func resolveWithRetry(ctx context.Context, httpClient *http.Client, plcDirectoryURL, did string, policy retryPolicy, watchdog *Watchdog, limiter requestLimiter, hooks Hooks) (ResolvedDID, []Attempt, error) {
+	host := hostFromEndpoint(plcDirectoryURL)
+	if host == "" {
+		host = "plc.directory"
+	}
+
+	var attempts []Attempt
+	var lastErr error
+	for attempt := 0; attempt < policy.maxAttempts; attempt++ {
+		if limiter != nil {
+			if err := limiter.wait(ctx); err != nil {
+				attempts = append(attempts, Attempt{Host: host, Err: err})
+				return ResolvedDID{}, attempts, err
+			}
+		}
+
+		start := time.Now()
+		r, err := resolveWithWatchdog(ctx, httpClient, plcDirectoryURL, did, watchdog, hooks)
+		duration := time.Since(start)
+		statusCode := statusCodeOfResolveErr(err)
+		attempts = append(attempts, Attempt{Host: host, StatusCode: statusCode, Err: err, Duration: duration})
+
+		if err == nil {
+			r.Attempts = attempts
+			return r, attempts, nil
+		}
+		lastErr = err
+		if !isRetryableResolveErr(err) || attempt == policy.maxAttempts-1 {
+			return ResolvedDID{}, attempts, err
+		}
+
+		if statusCode == http.StatusTooManyRequests {
+			hooks.OnRateLimited(host)
+		}
+		hooks.OnRetry(did, host, statusCode, attempt+1)
+
+		select {
+		case <-ctx.Done():
+			return ResolvedDID{}, attempts, ctx.Err()
+		case <-time.After(policy.backoff(attempt)):
+		}
+	}
+	return ResolvedDID{}, attempts, lastErr
+}
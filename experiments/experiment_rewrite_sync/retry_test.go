@@ -0,0 +1,110 @@
+package backfill
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type retryRecordingHooks struct {
+	NoopHooks
+	mu      sync.Mutex
+	retries []int
+}
+
+func (h *retryRecordingHooks) OnRetry(did, host string, statusCode, attempt int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.retries = append(h.retries, statusCode)
+}
+
+func TestResolveWithRetrySucceedsAfterTransient500s(t *testing.T) {
+	var calls int
+	plc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"alsoKnownAs":["at://alice.bsky.social"],"service":[{"type":"AtprotoPersonalDataServer","serviceEndpoint":"https://pds.example"}]}`))
+	}))
+	defer plc.Close()
+
+	policy := retryPolicy{maxAttempts: 3, baseDelay: 0, maxDelay: 0}
+	hooks := &retryRecordingHooks{}
+
+	r, attempts, err := resolveWithRetry(context.Background(), http.DefaultClient, plc.URL, "did:plc:alice", policy, nil, nil, hooks)
+	if err != nil {
+		t.Fatalf("resolveWithRetry() error = %v", err)
+	}
+	if r.Handle != "alice.bsky.social" {
+		t.Errorf("Handle = %q, want alice.bsky.social", r.Handle)
+	}
+	if len(attempts) != 3 {
+		t.Fatalf("got %d attempts, want 3", len(attempts))
+	}
+	if attempts[0].StatusCode != http.StatusInternalServerError || attempts[2].StatusCode != http.StatusOK {
+		t.Errorf("attempts = %+v, want first two 500s then a 200", attempts)
+	}
+
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+	if len(hooks.retries) != 2 {
+		t.Errorf("OnRetry fired %d times, want 2", len(hooks.retries))
+	}
+}
+
+func TestResolveWithRetryGivesUpOnNonRetryableError(t *testing.T) {
+	plc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer plc.Close()
+
+	policy := retryPolicy{maxAttempts: 3, baseDelay: 0, maxDelay: 0}
+	_, attempts, err := resolveWithRetry(context.Background(), http.DefaultClient, plc.URL, "did:plc:alice", policy, nil, nil, NoopHooks{})
+	if err == nil {
+		t.Fatal("resolveWithRetry() error = nil, want ErrDIDNotFound")
+	}
+	if len(attempts) != 1 {
+		t.Errorf("got %d attempts, want 1 (not-found isn't retryable)", len(attempts))
+	}
+}
+
+func TestResolveWithRetryStopsAtMaxAttempts(t *testing.T) {
+	var calls int
+	plc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer plc.Close()
+
+	policy := retryPolicy{maxAttempts: 2, baseDelay: 0, maxDelay: 0}
+	_, attempts, err := resolveWithRetry(context.Background(), http.DefaultClient, plc.URL, "did:plc:alice", policy, nil, nil, NoopHooks{})
+	if err == nil {
+		t.Fatal("resolveWithRetry() error = nil, want ErrRateLimited")
+	}
+	if calls != 2 || len(attempts) != 2 {
+		t.Errorf("calls = %d, len(attempts) = %d, want 2 and 2", calls, len(attempts))
+	}
+}
+
+func TestIsRetryableResolveErr(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{ErrRateLimited, true},
+		{&HTTPStatusError{Op: "x", StatusCode: 503}, true},
+		{ErrDIDNotFound, false},
+		{ErrTakenDown, false},
+		{&HTTPStatusError{Op: "x", StatusCode: 400}, false},
+	}
+	for _, c := range cases {
+		if got := isRetryableResolveErr(c.err); got != c.want {
+			t.Errorf("isRetryableResolveErr(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package backfill
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HostStatus identifies a (host, HTTP status code) bucket in RetryStats.
+// StatusCode is 0 for attempts that never got a response at all.
+type HostStatus struct {
+	Host       string
+	StatusCode int
+}
+
+// StatusCounts summarizes every attempt observed for one HostStatus
+// bucket.
+type StatusCounts struct {
+	Attempts     int
+	Failures     int
+	TotalLatency time.Duration
+}
+
+// AvgLatency returns the mean latency across every attempt in the bucket,
+// or 0 if there were none.
+func (c StatusCounts) AvgLatency() time.Duration {
+	if c.Attempts == 0 {
+		return 0
+	}
+	return c.TotalLatency / time.Duration(c.Attempts)
+}
+
+// RetryStats summarizes retry and failure behavior across a run, broken
+// down by host and HTTP status code, so an operator can tell their own
+// bugs (consistent failures against one status code) apart from remote
+// throttling (429s that eventually succeed).
+type RetryStats struct {
+	TotalAttempts int
+	TotalRetries  int
+	TotalFailures int
+
+	ByHostStatus map[HostStatus]StatusCounts
+}
+
+// ComputeRetryStats aggregates RetryStats over every Attempt recorded
+// across results.
+func ComputeRetryStats(results []DIDResult) RetryStats {
+	s := RetryStats{ByHostStatus: make(map[HostStatus]StatusCounts)}
+	for _, d := range results {
+		if len(d.Attempts) > 1 {
+			s.TotalRetries += len(d.Attempts) - 1
+		}
+		for _, a := range d.Attempts {
+			s.TotalAttempts++
+			key := HostStatus{Host: a.Host, StatusCode: a.StatusCode}
+			c := s.ByHostStatus[key]
+			c.Attempts++
+			c.TotalLatency += a.Duration
+			if a.Err != nil {
+				c.Failures++
+				s.TotalFailures++
+			}
+			s.ByHostStatus[key] = c
+		}
+	}
+	return s
+}
+
+// sortedHostStatus returns every HostStatus key in s.ByHostStatus, ordered
+// by attempt count descending, then by host and status code for a stable
+// order among ties.
+func (s RetryStats) sortedHostStatus() []HostStatus {
+	keys := make([]HostStatus, 0, len(s.ByHostStatus))
+	for k := range s.ByHostStatus {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ci, cj := s.ByHostStatus[keys[i]], s.ByHostStatus[keys[j]]
+		if ci.Attempts != cj.Attempts {
+			return ci.Attempts > cj.Attempts
+		}
+		if keys[i].Host != keys[j].Host {
+			return keys[i].Host < keys[j].Host
+		}
+		return keys[i].StatusCode < keys[j].StatusCode
+	})
+	return keys
+}
+
+// Report renders s as tab-separated lines, one per host/status bucket,
+// suitable for printing alongside the rest of a run's log output.
+func (s RetryStats) Report() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "total_attempts\t%d\n", s.TotalAttempts)
+	fmt.Fprintf(&b, "total_retries\t%d\n", s.TotalRetries)
+	fmt.Fprintf(&b, "total_failures\t%d\n", s.TotalFailures)
+	for _, k := range s.sortedHostStatus() {
+		c := s.ByHostStatus[k]
+		fmt.Fprintf(&b, "  %s\tstatus=%d\tattempts=%d\tfailures=%d\tavg_latency=%s\n", k.Host, k.StatusCode, c.Attempts, c.Failures, c.AvgLatency())
+	}
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package backfill
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestComputeRetryStatsAggregatesByHostAndStatus(t *testing.T) {
+	results := []DIDResult{
+		{
+			DID: "did:plc:alice",
+			Attempts: []Attempt{
+				{Host: "plc.directory", StatusCode: 500, Err: errors.New("boom"), Duration: 10 * time.Millisecond},
+				{Host: "plc.directory", StatusCode: 200, Duration: 5 * time.Millisecond},
+			},
+		},
+		{
+			DID: "did:plc:bob",
+			Attempts: []Attempt{
+				{Host: "plc.directory", StatusCode: 200, Duration: 4 * time.Millisecond},
+			},
+		},
+		{
+			DID: "did:plc:carol",
+			Attempts: []Attempt{
+				{Host: "plc.directory", StatusCode: 404, Err: ErrDIDNotFound, Duration: 1 * time.Millisecond},
+			},
+		},
+	}
+
+	s := ComputeRetryStats(results)
+	if s.TotalAttempts != 4 {
+		t.Errorf("TotalAttempts = %d, want 4", s.TotalAttempts)
+	}
+	if s.TotalRetries != 1 {
+		t.Errorf("TotalRetries = %d, want 1", s.TotalRetries)
+	}
+	if s.TotalFailures != 2 {
+		t.Errorf("TotalFailures = %d, want 2", s.TotalFailures)
+	}
+
+	c := s.ByHostStatus[HostStatus{Host: "plc.directory", StatusCode: 200}]
+	if c.Attempts != 2 || c.Failures != 0 {
+		t.Errorf("200 bucket = %+v, want 2 attempts, 0 failures", c)
+	}
+
+	c500 := s.ByHostStatus[HostStatus{Host: "plc.directory", StatusCode: 500}]
+	if c500.Attempts != 1 || c500.Failures != 1 || c500.AvgLatency() != 10*time.Millisecond {
+		t.Errorf("500 bucket = %+v, want 1 attempt, 1 failure, 10ms avg latency", c500)
+	}
+}
+
+func TestRetryStatsReportIncludesEveryBucket(t *testing.T) {
+	s := ComputeRetryStats([]DIDResult{
+		{Attempts: []Attempt{{Host: "a.example", StatusCode: 429}, {Host: "a.example", StatusCode: 200}}},
+	})
+	report := s.Report()
+	if !strings.Contains(report, "a.example") || !strings.Contains(report, "status=429") || !strings.Contains(report, "status=200") {
+		t.Errorf("Report() = %q, missing expected buckets", report)
+	}
+}
@@ -0,0 +1,33 @@
+package backfill
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// NewRunID generates a random identifier for a single Run invocation, so
+// multi-run log aggregation, metrics, and output files can all be
+// attributed back to the job that produced them. 8 bytes of entropy (16
+// hex characters) is plenty to disambiguate concurrent runs without the
+// verbosity of a full UUID.
+func NewRunID() string {
+	return randomHexID(8)
+}
+
+// NewSpanID generates a random identifier for a single DID's processing
+// within a run, so log lines touching one DID can be correlated even when
+// Config.Concurrency interleaves many DIDs' output.
+func NewSpanID() string {
+	return randomHexID(8)
+}
+
+func randomHexID(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand.Read on a real OS essentially never fails; if it
+		// somehow does, a constant fallback ID is far better than having
+		// Run fail outright over an id used only for correlation.
+		return "0000000000000000"[:2*n]
+	}
+	return hex.EncodeToString(b)
+}
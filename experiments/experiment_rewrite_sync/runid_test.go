@@ -0,0 +1,24 @@
+package backfill
+
+import "testing"
+
+func TestNewRunIDAndNewSpanIDAreNonEmptyAndDistinct(t *testing.T) {
+	a, b := NewRunID(), NewSpanID()
+	if a == "" || b == "" {
+		t.Fatalf("NewRunID()/NewSpanID() = %q/%q, want non-empty", a, b)
+	}
+	if a == b {
+		t.Errorf("NewRunID() == NewSpanID() (%q), want distinct random ids", a)
+	}
+}
+
+func TestNewRunIDGeneratesDistinctValues(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id := NewRunID()
+		if seen[id] {
+			t.Fatalf("NewRunID() repeated %q within %d calls", id, i+1)
+		}
+		seen[id] = true
+	}
+}
@@ -0,0 +1,188 @@
+package backfill
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// BuildInfo captures the running binary's provenance: the Go toolchain it
+// was built with and, when built from a git checkout, the exact commit —
+// so a dataset's RunManifest can be traced back to the code that produced
+// it even after that code has since changed.
+type BuildInfo struct {
+	GoVersion   string `json:"go_version"`
+	GitRevision string `json:"git_revision,omitempty"`
+
+	// GitDirty is true if the binary was built from a working tree with
+	// uncommitted changes, meaning GitRevision alone doesn't fully
+	// describe the code that ran.
+	GitDirty bool `json:"git_dirty,omitempty"`
+}
+
+// CurrentBuildInfo reads the running binary's build provenance via
+// runtime/debug.ReadBuildInfo, which the Go toolchain populates
+// automatically from the VCS state at build time — no ldflags or
+// generated version file required. GitRevision and GitDirty are left
+// zero when the binary wasn't built from a git checkout (e.g. `go run`
+// with no VCS metadata available).
+func CurrentBuildInfo() BuildInfo {
+	info := BuildInfo{GoVersion: runtime.Version()}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.GitRevision = s.Value
+		case "vcs.modified":
+			info.GitDirty = s.Value == "true"
+		}
+	}
+	return info
+}
+
+// ConfigSummary is the JSON-serializable subset of Config: every field
+// that describes run behavior, for recording exactly how a dataset was
+// produced. It omits the runtime wiring (Sink, Hooks, HTTPClient) that
+// has no meaningful JSON form.
+type ConfigSummary struct {
+	DIDCount int `json:"did_count"`
+
+	AllowDIDs  []string `json:"allow_dids,omitempty"`
+	BlockDIDs  []string `json:"block_dids,omitempty"`
+	AllowHosts []string `json:"allow_hosts,omitempty"`
+	BlockHosts []string `json:"block_hosts,omitempty"`
+
+	MaxInFlightPerHost int      `json:"max_in_flight_per_host,omitempty"`
+	MaxRepoSizeBytes   int64    `json:"max_repo_size_bytes,omitempty"`
+	Collections        []string `json:"collections,omitempty"`
+	Concurrency        int      `json:"concurrency,omitempty"`
+	RateLimit          float64  `json:"rate_limit,omitempty"`
+	PDSSyncRateLimit   float64  `json:"pds_sync_rate_limit,omitempty"`
+	AppViewRateLimit   float64  `json:"app_view_rate_limit,omitempty"`
+	BlobRateLimit      float64  `json:"blob_rate_limit,omitempty"`
+
+	VerifyHandles         bool          `json:"verify_handles,omitempty"`
+	HealthAwareScheduling bool          `json:"health_aware_scheduling,omitempty"`
+	DNSCacheTTL           time.Duration `json:"dns_cache_ttl,omitempty"`
+}
+
+// SummarizeConfig projects cfg's JSON-serializable fields into a
+// ConfigSummary for inclusion in a RunManifest.
+func SummarizeConfig(cfg Config) ConfigSummary {
+	return ConfigSummary{
+		DIDCount:              len(cfg.DIDs),
+		AllowDIDs:             cfg.AllowDIDs,
+		BlockDIDs:             cfg.BlockDIDs,
+		AllowHosts:            cfg.AllowHosts,
+		BlockHosts:            cfg.BlockHosts,
+		MaxInFlightPerHost:    cfg.MaxInFlightPerHost,
+		MaxRepoSizeBytes:      cfg.MaxRepoSizeBytes,
+		Collections:           cfg.Collections,
+		Concurrency:           cfg.Concurrency,
+		RateLimit:             cfg.RateLimit,
+		PDSSyncRateLimit:      cfg.PDSSyncRateLimit,
+		AppViewRateLimit:      cfg.AppViewRateLimit,
+		BlobRateLimit:         cfg.BlobRateLimit,
+		VerifyHandles:         cfg.VerifyHandles,
+		HealthAwareScheduling: cfg.HealthAwareScheduling,
+		DNSCacheTTL:           cfg.DNSCacheTTL,
+	}
+}
+
+// ResultSummary aggregates a Result down to the handful of numbers needed
+// to sanity-check a dataset against its RunManifest without re-reading
+// every DIDResult.
+type ResultSummary struct {
+	TotalDIDs    int   `json:"total_dids"`
+	Resolved     int   `json:"resolved"`
+	Filtered     int   `json:"filtered"`
+	Errored      int   `json:"errored"`
+	TotalRecords int   `json:"total_records"`
+	TotalBytes   int64 `json:"total_bytes"`
+}
+
+// SummarizeResult builds a ResultSummary from result.
+func SummarizeResult(result *Result) ResultSummary {
+	s := ResultSummary{TotalDIDs: len(result.PerDID), TotalBytes: result.TotalBytes()}
+	for _, d := range result.PerDID {
+		switch {
+		case d.Err != nil:
+			s.Errored++
+		case d.Filtered:
+			s.Filtered++
+		default:
+			s.Resolved++
+		}
+		s.TotalRecords += d.Records
+	}
+	return s
+}
+
+// RunManifest records everything needed to reproduce a published dataset:
+// the exact config it was produced with, the binary's build provenance,
+// the input DID file's checksum, when the run started and ended, and a
+// summary of the outcome.
+type RunManifest struct {
+	// RunID identifies the run this manifest describes, matching
+	// Result.RunID and ResultReport.RunID, so logs, metrics, and output
+	// files from the same run can all be correlated back to it.
+	RunID string `json:"run_id,omitempty"`
+
+	Config ConfigSummary `json:"config"`
+	Build  BuildInfo     `json:"build"`
+
+	// InputSHA256 is the SHA-256 of the --dids input file, omitted when
+	// the run's DIDs weren't read from a file.
+	InputSHA256 string `json:"input_sha256,omitempty"`
+
+	StartTime time.Time     `json:"start_time"`
+	EndTime   time.Time     `json:"end_time"`
+	Result    ResultSummary `json:"result"`
+}
+
+// NewRunManifest builds a RunManifest for a completed run. inputPath, if
+// non-empty, is hashed as the run's InputSHA256; pass the empty string
+// when the DIDs didn't come from a file.
+func NewRunManifest(cfg Config, inputPath string, start, end time.Time, result *Result) (RunManifest, error) {
+	m := RunManifest{
+		RunID:     result.RunID,
+		Config:    SummarizeConfig(cfg),
+		Build:     CurrentBuildInfo(),
+		StartTime: start,
+		EndTime:   end,
+		Result:    SummarizeResult(result),
+	}
+	if inputPath != "" {
+		sum, err := sha256File(inputPath)
+		if err != nil {
+			return RunManifest{}, fmt.Errorf("run manifest: %w", err)
+		}
+		m.InputSHA256 = sum
+	}
+	return m, nil
+}
+
+// WriteRunManifest builds a RunManifest (see NewRunManifest) and writes it
+// as indented JSON to path.
+func WriteRunManifest(cfg Config, inputPath string, start, end time.Time, result *Result, path string) error {
+	m, err := NewRunManifest(cfg, inputPath, start, end, result)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("write run manifest: %w", err)
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
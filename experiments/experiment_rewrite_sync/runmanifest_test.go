@@ -0,0 +1,108 @@
+package backfill
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCurrentBuildInfoSetsGoVersion(t *testing.T) {
+	info := CurrentBuildInfo()
+	if info.GoVersion == "" {
+		t.Error("GoVersion is empty, want the running Go toolchain version")
+	}
+}
+
+func TestSummarizeResultCountsOutcomes(t *testing.T) {
+	result := &Result{
+		PerDID: []DIDResult{
+			{DID: "did:plc:alice", Records: 3, Bytes: 30},
+			{DID: "did:plc:bob", Err: errors.New("resolve failed")},
+			{DID: "did:plc:carol", Filtered: true, Reason: "did blocklisted"},
+		},
+	}
+
+	s := SummarizeResult(result)
+	if s.TotalDIDs != 3 {
+		t.Errorf("TotalDIDs = %d, want 3", s.TotalDIDs)
+	}
+	if s.Resolved != 1 {
+		t.Errorf("Resolved = %d, want 1", s.Resolved)
+	}
+	if s.Errored != 1 {
+		t.Errorf("Errored = %d, want 1", s.Errored)
+	}
+	if s.Filtered != 1 {
+		t.Errorf("Filtered = %d, want 1", s.Filtered)
+	}
+	if s.TotalRecords != 3 {
+		t.Errorf("TotalRecords = %d, want 3", s.TotalRecords)
+	}
+	if s.TotalBytes != 30 {
+		t.Errorf("TotalBytes = %d, want 30", s.TotalBytes)
+	}
+}
+
+func TestWriteRunManifestIncludesConfigBuildAndInputHash(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "dids.txt")
+	if err := os.WriteFile(inputPath, []byte("did:plc:alice\n"), 0o644); err != nil {
+		t.Fatalf("write input file: %v", err)
+	}
+
+	cfg := Config{
+		DIDs:        []WorkItem{{DID: "did:plc:alice"}},
+		Concurrency: 4,
+		RateLimit:   10,
+	}
+	result := &Result{PerDID: []DIDResult{{DID: "did:plc:alice", Records: 1, Bytes: 10}}}
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(time.Minute)
+
+	manifestPath := filepath.Join(dir, "manifest.json")
+	if err := WriteRunManifest(cfg, inputPath, start, end, result, manifestPath); err != nil {
+		t.Fatalf("WriteRunManifest() error = %v", err)
+	}
+
+	body, err := os.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatalf("read run manifest: %v", err)
+	}
+	var m RunManifest
+	if err := json.Unmarshal(body, &m); err != nil {
+		t.Fatalf("unmarshal run manifest: %v", err)
+	}
+
+	if m.Config.DIDCount != 1 {
+		t.Errorf("Config.DIDCount = %d, want 1", m.Config.DIDCount)
+	}
+	if m.Config.Concurrency != 4 {
+		t.Errorf("Config.Concurrency = %d, want 4", m.Config.Concurrency)
+	}
+	if m.Build.GoVersion == "" {
+		t.Error("Build.GoVersion is empty")
+	}
+	if m.InputSHA256 == "" {
+		t.Error("InputSHA256 is empty, want it populated from inputPath")
+	}
+	if !m.StartTime.Equal(start) || !m.EndTime.Equal(end) {
+		t.Errorf("StartTime/EndTime = %v/%v, want %v/%v", m.StartTime, m.EndTime, start, end)
+	}
+	if m.Result.TotalRecords != 1 {
+		t.Errorf("Result.TotalRecords = %d, want 1", m.Result.TotalRecords)
+	}
+}
+
+func TestNewRunManifestOmitsInputHashWhenNoInputPath(t *testing.T) {
+	m, err := NewRunManifest(Config{}, "", time.Now(), time.Now(), &Result{})
+	if err != nil {
+		t.Fatalf("NewRunManifest() error = %v", err)
+	}
+	if m.InputSHA256 != "" {
+		t.Errorf("InputSHA256 = %q, want empty when inputPath is empty", m.InputSHA256)
+	}
+}
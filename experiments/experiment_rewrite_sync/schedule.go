@@ -0,0 +1,34 @@
+package backfill
+
+// scheduleByHost groups resolved DIDs by their PDS host and interleaves the
+// groups round-robin, so consecutive work items spread across hosts instead
+// of hammering one host until it's drained. Within a host, input order is
+// preserved.
+func scheduleByHost(resolved []ResolvedDID) []ResolvedDID {
+	hosts := make([]string, 0)
+	groups := make(map[string][]ResolvedDID)
+	for _, r := range resolved {
+		if _, ok := groups[r.PDSHost]; !ok {
+			hosts = append(hosts, r.PDSHost)
+		}
+		groups[r.PDSHost] = append(groups[r.PDSHost], r)
+	}
+
+	scheduled := make([]ResolvedDID, 0, len(resolved))
+	for {
+		progressed := false
+		for _, host := range hosts {
+			queue := groups[host]
+			if len(queue) == 0 {
+				continue
+			}
+			scheduled = append(scheduled, queue[0])
+			groups[host] = queue[1:]
+			progressed = true
+		}
+		if !progressed {
+			break
+		}
+	}
+	return scheduled
+}
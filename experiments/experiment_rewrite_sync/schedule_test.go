@@ -0,0 +1,28 @@
+package backfill
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScheduleByHost(t *testing.T) {
+	resolved := []ResolvedDID{
+		{DID: "did:plc:a1", PDSHost: "bsky.social"},
+		{DID: "did:plc:a2", PDSHost: "bsky.social"},
+		{DID: "did:plc:b1", PDSHost: "self-hosted.example"},
+		{DID: "did:plc:a3", PDSHost: "bsky.social"},
+	}
+
+	got := scheduleByHost(resolved)
+
+	want := []ResolvedDID{
+		{DID: "did:plc:a1", PDSHost: "bsky.social"},
+		{DID: "did:plc:b1", PDSHost: "self-hosted.example"},
+		{DID: "did:plc:a2", PDSHost: "bsky.social"},
+		{DID: "did:plc:a3", PDSHost: "bsky.social"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("scheduleByHost() = %+v, want %+v", got, want)
+	}
+}
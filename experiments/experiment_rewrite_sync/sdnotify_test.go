@@ -0,0 +1,82 @@
+package backfill
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSDNotifierIsNoOpWithoutNotifySocket(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	n := NewSDNotifier()
+
+	if err := n.Ready(); err != nil {
+		t.Errorf("Ready() error = %v, want nil", err)
+	}
+	if err := n.Watchdog(); err != nil {
+		t.Errorf("Watchdog() error = %v, want nil", err)
+	}
+	if err := n.Status("starting"); err != nil {
+		t.Errorf("Status() error = %v, want nil", err)
+	}
+	if err := n.Stopping(); err != nil {
+		t.Errorf("Stopping() error = %v, want nil", err)
+	}
+}
+
+func TestSDNotifierSendsOverNotifySocket(t *testing.T) {
+	dir := t.TempDir()
+	socketPath := dir + "/notify.sock"
+
+	addr, err := net.ResolveUnixAddr("unixgram", socketPath)
+	if err != nil {
+		t.Fatalf("ResolveUnixAddr() error = %v", err)
+	}
+	conn, err := net.ListenUnixgram("unixgram", addr)
+	if err != nil {
+		t.Fatalf("ListenUnixgram() error = %v", err)
+	}
+	defer conn.Close()
+
+	t.Setenv("NOTIFY_SOCKET", socketPath)
+	n := NewSDNotifier()
+	if err := n.Ready(); err != nil {
+		t.Fatalf("Ready() error = %v", err)
+	}
+
+	buf := make([]byte, 64)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	size, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("read from notify socket: %v", err)
+	}
+	if got := string(buf[:size]); got != "READY=1" {
+		t.Errorf("received %q, want %q", got, "READY=1")
+	}
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	tests := []struct {
+		name     string
+		usec     string
+		wantOK   bool
+		wantHalf time.Duration
+	}{
+		{name: "unset", usec: "", wantOK: false},
+		{name: "invalid", usec: "not-a-number", wantOK: false},
+		{name: "zero", usec: "0", wantOK: false},
+		{name: "thirty seconds", usec: "30000000", wantOK: true, wantHalf: 15 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("WATCHDOG_USEC", tt.usec)
+			interval, ok := WatchdogInterval()
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && interval != tt.wantHalf {
+				t.Errorf("interval = %v, want %v", interval, tt.wantHalf)
+			}
+		})
+	}
+}
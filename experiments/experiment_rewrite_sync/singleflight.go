@@ -0,0 +1,57 @@
+package backfill
+
+import "sync"
+
+// singleflightCall tracks one in-flight call and lets duplicate callers
+// for the same key wait on its result instead of repeating the work.
+type singleflightCall struct {
+	wg  sync.WaitGroup
+	val interface{}
+	err error
+}
+
+// singleflightGroup coalesces concurrent calls that share the same key
+// into a single underlying call: ResolveDID and FetchRepo use one to
+// make sure duplicate DIDs in a run's input, or retries racing each
+// other across goroutines, share one PLC lookup or one repo fetch
+// instead of each issuing its own network request. It is not a cache —
+// once a call completes, the next caller for the same key runs fn again.
+type singleflightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*singleflightCall
+}
+
+// do calls fn and returns its result, unless another call for key is
+// already in flight, in which case it waits for that call instead and
+// returns its result. shared reports whether the result came from
+// another caller's call rather than this one's own invocation of fn.
+//
+// Callers should be aware that when shared is true, val and err came
+// from whichever context the first caller passed to fn — if that
+// context is canceled, every waiter sees its cancellation even if their
+// own context is still valid.
+func (g *singleflightGroup) do(key string, fn func() (interface{}, error)) (val interface{}, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*singleflightCall)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.val, c.err, true
+	}
+
+	c := &singleflightCall{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	c.val, c.err = fn()
+	c.wg.Done()
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+
+	return c.val, c.err, false
+}
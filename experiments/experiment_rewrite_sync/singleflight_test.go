@@ -0,0 +1,108 @@
+package backfill
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSingleflightGroupCoalescesConcurrentCallsForSameKey(t *testing.T) {
+	var g singleflightGroup
+	var calls int32
+	release := make(chan struct{})
+
+	const n = 5
+	var wg sync.WaitGroup
+	vals := make([]interface{}, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v, err, _ := g.do("key", func() (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				return "result", nil
+			})
+			if err != nil {
+				t.Errorf("do()[%d] error = %v", i, err)
+			}
+			vals[i] = v
+		}(i)
+	}
+
+	time.Sleep(20 * time.Millisecond) // give every goroutine a chance to join the in-flight call
+	close(release)
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("fn called %d times for %d concurrent calls sharing a key, want 1", got, n)
+	}
+	for i, v := range vals {
+		if v != "result" {
+			t.Errorf("do()[%d] = %v, want %q", i, v, "result")
+		}
+	}
+}
+
+func TestSingleflightGroupRunsFnAgainAfterPriorCallCompletes(t *testing.T) {
+	var g singleflightGroup
+	var calls int32
+
+	call := func() (interface{}, error, bool) {
+		return g.do("key", func() (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			return nil, nil
+		})
+	}
+
+	if _, _, shared := call(); shared {
+		t.Error("first call reported shared = true, want false")
+	}
+	if _, _, shared := call(); shared {
+		t.Error("second call (after the first completed) reported shared = true, want false")
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("fn called %d times across two non-overlapping calls, want 2", got)
+	}
+}
+
+func TestSingleflightGroupPropagatesError(t *testing.T) {
+	var g singleflightGroup
+	wantErr := errors.New("boom")
+
+	_, err, _ := g.do("key", func() (interface{}, error) {
+		return nil, wantErr
+	})
+	if !errors.Is(err, wantErr) {
+		t.Errorf("do() error = %v, want %v", err, wantErr)
+	}
+}
+
+func TestSingleflightGroupDifferentKeysDoNotCoalesce(t *testing.T) {
+	var g singleflightGroup
+	var calls int32
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for _, key := range []string{"a", "b"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			_, _, _ = g.do(key, func() (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				return nil, nil
+			})
+		}(key)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Fatalf("fn called %d times for 2 distinct keys, want 2", got)
+	}
+}
@@ -0,0 +1,45 @@
+package backfill
+
+import (
+	"context"
+	"time"
+)
+
+// Sink receives records produced during a run. Concrete sinks (FileSink,
+// PostgresSink, SQLFileSink, KafkaSink, ...) live in their own files.
+// Config.Sink carries one into a run, but Run and RunStream never call
+// Write themselves: neither fetches repo records.
+type Sink interface {
+	Write(ctx context.Context, did string, rec Record) error
+}
+
+// TombstoneAction distinguishes the two ways a record can stop existing: a
+// single record being deleted, or its whole account going away.
+type TombstoneAction string
+
+const (
+	TombstoneActionDelete  TombstoneAction = "delete"
+	TombstoneActionAccount TombstoneAction = "account_tombstone"
+)
+
+// Tombstone records that uri (or, for TombstoneActionAccount, an entire
+// account) stopped existing at Time. It exists for live-tail consumers
+// (firehose, Jetstream — not yet implemented by this package, see
+// DIDSet) where a deletion is itself meaningful data: a
+// one-shot Run has no prior state to delete, but a downstream store that
+// already ingested the record does, and a data-retention policy may
+// require it to apply the deletion rather than keep a stale copy forever.
+type Tombstone struct {
+	URI    string          `json:"uri,omitempty"`
+	Action TombstoneAction `json:"action"`
+	Time   time.Time       `json:"time"`
+}
+
+// TombstoneSink is implemented by a Sink that can also record deletions and
+// account tombstones. Sinks that don't implement it simply have tombstones
+// dropped; callers should type-assert for it the same way main.go does for
+// Close (see sinkCloser) rather than requiring every Sink to grow the
+// method.
+type TombstoneSink interface {
+	WriteTombstone(ctx context.Context, did string, t Tombstone) error
+}
@@ -0,0 +1,84 @@
+package backfill
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SlowDID describes one DID whose processing time exceeded
+// SlowDIDStats.Threshold.
+type SlowDID struct {
+	DID      string
+	PDSHost  string
+	Duration time.Duration
+	Bytes    int64
+}
+
+// SlowDIDStats summarizes slow-DID detection across a run: the median
+// processing time it was measured against, the multiple and resulting
+// threshold applied, and the slowest offenders (capped at the topN passed
+// to ComputeSlowDIDStats), so an operator can tune the multiple or decide
+// which hosts to exclude.
+type SlowDIDStats struct {
+	Median    time.Duration
+	Multiple  float64
+	Threshold time.Duration
+	Slow      []SlowDID
+}
+
+// ComputeSlowDIDStats finds DIDs whose Duration is at least multiple times
+// the median Duration across results, returning the topN slowest (by
+// descending duration). A multiple of 0 or fewer than two results
+// disables detection, returning a zero SlowDIDStats.
+func ComputeSlowDIDStats(results []DIDResult, multiple float64, topN int) SlowDIDStats {
+	if multiple <= 0 || len(results) < 2 {
+		return SlowDIDStats{}
+	}
+
+	durations := make([]time.Duration, len(results))
+	for i, d := range results {
+		durations[i] = d.Duration
+	}
+	median := medianDuration(durations)
+	threshold := time.Duration(float64(median) * multiple)
+
+	var slow []SlowDID
+	for _, d := range results {
+		if d.Duration >= threshold {
+			slow = append(slow, SlowDID{DID: d.DID, PDSHost: d.PDSHost, Duration: d.Duration, Bytes: d.Bytes})
+		}
+	}
+	sort.Slice(slow, func(i, j int) bool { return slow[i].Duration > slow[j].Duration })
+	if topN > 0 && len(slow) > topN {
+		slow = slow[:topN]
+	}
+
+	return SlowDIDStats{Median: median, Multiple: multiple, Threshold: threshold, Slow: slow}
+}
+
+// medianDuration returns the median of durations, rounding down to the
+// lower of the two middle values for an even-length input. It does not
+// mutate durations.
+func medianDuration(durations []time.Duration) time.Duration {
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[(len(sorted)-1)/2]
+}
+
+// Report renders s as tab-separated lines, one per slow DID, suitable for
+// printing alongside the rest of a run's log output. Empty if detection
+// found nothing slow (or was disabled).
+func (s SlowDIDStats) Report() string {
+	if len(s.Slow) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "slow DIDs (median=%s, threshold=%s at %gx):\n", s.Median, s.Threshold, s.Multiple)
+	for _, d := range s.Slow {
+		fmt.Fprintf(&b, "  %s\thost=%s\tduration=%s\tbytes=%d\n", d.DID, d.PDSHost, d.Duration, d.Bytes)
+	}
+	return b.String()
+}
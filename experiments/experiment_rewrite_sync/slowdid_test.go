@@ -0,0 +1,65 @@
+package backfill
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestComputeSlowDIDStatsFlagsOutliersAboveThreshold(t *testing.T) {
+	results := []DIDResult{
+		{DID: "did:plc:a", PDSHost: "a.example", Duration: 10 * time.Millisecond},
+		{DID: "did:plc:b", PDSHost: "a.example", Duration: 12 * time.Millisecond},
+		{DID: "did:plc:c", PDSHost: "b.example", Duration: 11 * time.Millisecond},
+		{DID: "did:plc:slow", PDSHost: "c.example", Duration: 500 * time.Millisecond, Bytes: 1024},
+	}
+
+	s := ComputeSlowDIDStats(results, 2.0, 10)
+	if s.Median != 11*time.Millisecond {
+		t.Errorf("Median = %s, want 11ms", s.Median)
+	}
+	if len(s.Slow) != 1 || s.Slow[0].DID != "did:plc:slow" {
+		t.Fatalf("Slow = %+v, want just did:plc:slow", s.Slow)
+	}
+	if s.Slow[0].Bytes != 1024 || s.Slow[0].PDSHost != "c.example" {
+		t.Errorf("Slow[0] = %+v, missing host/bytes", s.Slow[0])
+	}
+}
+
+func TestComputeSlowDIDStatsCapsAtTopN(t *testing.T) {
+	results := []DIDResult{
+		{DID: "did:plc:base1", Duration: 1 * time.Millisecond},
+		{DID: "did:plc:base2", Duration: 1 * time.Millisecond},
+		{DID: "did:plc:slow1", Duration: 100 * time.Millisecond},
+		{DID: "did:plc:slow2", Duration: 200 * time.Millisecond},
+		{DID: "did:plc:slow3", Duration: 300 * time.Millisecond},
+	}
+
+	s := ComputeSlowDIDStats(results, 2.0, 2)
+	if len(s.Slow) != 2 {
+		t.Fatalf("len(Slow) = %d, want 2", len(s.Slow))
+	}
+	if s.Slow[0].DID != "did:plc:slow3" || s.Slow[1].DID != "did:plc:slow2" {
+		t.Errorf("Slow = %+v, want [slow3, slow2] by descending duration", s.Slow)
+	}
+}
+
+func TestComputeSlowDIDStatsDisabledByZeroMultiple(t *testing.T) {
+	s := ComputeSlowDIDStats([]DIDResult{{Duration: time.Second}, {Duration: time.Hour}}, 0, 10)
+	if len(s.Slow) != 0 {
+		t.Errorf("Slow = %+v, want none with multiple=0", s.Slow)
+	}
+}
+
+func TestSlowDIDStatsReportIncludesHostAndSize(t *testing.T) {
+	s := ComputeSlowDIDStats([]DIDResult{
+		{DID: "did:plc:a", Duration: time.Millisecond},
+		{DID: "did:plc:b", Duration: time.Millisecond},
+		{DID: "did:plc:slow", PDSHost: "slow.example", Duration: time.Second, Bytes: 2048},
+	}, 2.0, 10)
+
+	report := s.Report()
+	if !strings.Contains(report, "did:plc:slow") || !strings.Contains(report, "slow.example") || !strings.Contains(report, "bytes=2048") {
+		t.Errorf("Report() = %q, missing expected fields", report)
+	}
+}
@@ -0,0 +1,100 @@
+package backfill
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// SnapshotManager organizes a base directory into one subdirectory per
+// run, keyed by a caller-supplied run ID, with a "latest" symlink
+// pointing at the most recently promoted one and an optional retention
+// policy pruning older snapshots. This lets longitudinal analyses
+// compare a DID's repo across collection waves (each snapshot is a
+// complete, independent copy of that run's output) while "latest"
+// gives anything that only cares about the most recent data a stable
+// path that doesn't change with every run.
+type SnapshotManager struct {
+	baseDir string
+
+	// Retain caps how many snapshot directories are kept; Promote deletes
+	// the oldest ones beyond this count. Zero means keep every snapshot
+	// forever.
+	Retain int
+}
+
+// NewSnapshotManager returns a SnapshotManager rooted at baseDir, creating
+// it if it doesn't exist yet.
+func NewSnapshotManager(baseDir string) (*SnapshotManager, error) {
+	if err := os.MkdirAll(baseDir, 0o755); err != nil {
+		return nil, fmt.Errorf("snapshot manager %s: %w", baseDir, err)
+	}
+	return &SnapshotManager{baseDir: baseDir}, nil
+}
+
+// Dir returns the snapshot directory for runID (baseDir/runID), creating
+// it if it doesn't exist, for a sink to write its shards into.
+func (m *SnapshotManager) Dir(runID string) (string, error) {
+	dir := filepath.Join(m.baseDir, runID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("snapshot dir %s: %w", runID, err)
+	}
+	return dir, nil
+}
+
+// Promote points "latest" at runID's snapshot directory and, if Retain
+// is set, prunes the oldest snapshots beyond that count. Call this only
+// after runID's snapshot has finished writing successfully — Promote
+// itself has no way to tell a complete snapshot from a half-written one.
+func (m *SnapshotManager) Promote(runID string) error {
+	target := filepath.Join(m.baseDir, runID)
+	if _, err := os.Stat(target); err != nil {
+		return fmt.Errorf("promote snapshot %s: %w", runID, err)
+	}
+
+	latest := filepath.Join(m.baseDir, "latest")
+	tmp := latest + ".tmp"
+	_ = os.Remove(tmp)
+	if err := os.Symlink(runID, tmp); err != nil {
+		return fmt.Errorf("promote snapshot %s: %w", runID, err)
+	}
+	if err := os.Rename(tmp, latest); err != nil {
+		return fmt.Errorf("promote snapshot %s: %w", runID, err)
+	}
+
+	if m.Retain > 0 {
+		return m.prune()
+	}
+	return nil
+}
+
+// prune removes the oldest snapshot directories beyond Retain, ordered
+// by directory name — callers should use a lexicographically sortable
+// run ID (e.g. a timestamp prefix) for retention to keep the snapshots
+// they expect.
+func (m *SnapshotManager) prune() error {
+	entries, err := os.ReadDir(m.baseDir)
+	if err != nil {
+		return fmt.Errorf("prune snapshots: %w", err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+
+	if len(names) <= m.Retain {
+		return nil
+	}
+	for _, name := range names[:len(names)-m.Retain] {
+		if err := os.RemoveAll(filepath.Join(m.baseDir, name)); err != nil {
+			return fmt.Errorf("prune snapshot %s: %w", name, err)
+		}
+	}
+	return nil
+}
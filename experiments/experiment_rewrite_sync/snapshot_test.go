@@ -0,0 +1,106 @@
+package backfill
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshotManagerDirCreatesSubdirectory(t *testing.T) {
+	mgr, err := NewSnapshotManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewSnapshotManager() error = %v", err)
+	}
+
+	dir, err := mgr.Dir("run1")
+	if err != nil {
+		t.Fatalf("Dir() error = %v", err)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		t.Fatalf("Dir() = %q, want an existing directory", dir)
+	}
+}
+
+func TestSnapshotManagerPromotePointsLatestAtRunID(t *testing.T) {
+	base := t.TempDir()
+	mgr, err := NewSnapshotManager(base)
+	if err != nil {
+		t.Fatalf("NewSnapshotManager() error = %v", err)
+	}
+
+	if _, err := mgr.Dir("run1"); err != nil {
+		t.Fatalf("Dir() error = %v", err)
+	}
+	if err := mgr.Promote("run1"); err != nil {
+		t.Fatalf("Promote() error = %v", err)
+	}
+
+	target, err := os.Readlink(filepath.Join(base, "latest"))
+	if err != nil {
+		t.Fatalf("Readlink(latest) error = %v", err)
+	}
+	if target != "run1" {
+		t.Errorf("latest -> %q, want %q", target, "run1")
+	}
+
+	if _, err := mgr.Dir("run2"); err != nil {
+		t.Fatalf("Dir() error = %v", err)
+	}
+	if err := mgr.Promote("run2"); err != nil {
+		t.Fatalf("second Promote() error = %v", err)
+	}
+	target, err = os.Readlink(filepath.Join(base, "latest"))
+	if err != nil {
+		t.Fatalf("Readlink(latest) error = %v", err)
+	}
+	if target != "run2" {
+		t.Errorf("latest -> %q after second Promote(), want %q", target, "run2")
+	}
+}
+
+func TestSnapshotManagerPromotePrunesOldestBeyondRetain(t *testing.T) {
+	base := t.TempDir()
+	mgr, err := NewSnapshotManager(base)
+	if err != nil {
+		t.Fatalf("NewSnapshotManager() error = %v", err)
+	}
+	mgr.Retain = 2
+
+	for _, run := range []string{"run1", "run2", "run3"} {
+		if _, err := mgr.Dir(run); err != nil {
+			t.Fatalf("Dir(%s) error = %v", run, err)
+		}
+		if err := mgr.Promote(run); err != nil {
+			t.Fatalf("Promote(%s) error = %v", run, err)
+		}
+	}
+
+	entries, err := os.ReadDir(base)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	var dirs []string
+	for _, e := range entries {
+		if e.IsDir() {
+			dirs = append(dirs, e.Name())
+		}
+	}
+	if len(dirs) != 2 {
+		t.Fatalf("got snapshot dirs %v, want 2 (run1 pruned)", dirs)
+	}
+	for _, name := range dirs {
+		if name == "run1" {
+			t.Errorf("run1 still present after Retain=2 with 3 promoted snapshots")
+		}
+	}
+}
+
+func TestSnapshotManagerPromoteErrorsOnMissingRunDir(t *testing.T) {
+	mgr, err := NewSnapshotManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewSnapshotManager() error = %v", err)
+	}
+	if err := mgr.Promote("never-created"); err == nil {
+		t.Fatal("Promote() error = nil, want error for a run ID with no snapshot directory")
+	}
+}
@@ -0,0 +1,234 @@
+package backfill
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SQLFileSink writes records into a local SQLite or DuckDB file, one table
+// per AT Protocol collection (e.g. app.bsky.feed.post -> app_bsky_feed_post),
+// so analysts get a single queryable artifact per cohort backfill instead of
+// a directory of JSON-lines shards.
+//
+// Like PostgresSink, it takes an already-opened *sql.DB rather than
+// importing a driver: this module vendors no dependencies, so the caller's
+// own main registers whichever one it needs (mattn/go-sqlite3,
+// modernc.org/sqlite, marcboeker/go-duckdb, ...). Both SQLite and DuckDB
+// accept "?" positional placeholders and INSERT ... ON CONFLICT upserts, so
+// the same statement shape works against either.
+type SQLFileSink struct {
+	db            *sql.DB
+	batchSize     int
+	flushInterval time.Duration
+
+	stopCh  chan struct{}
+	stopped sync.WaitGroup
+
+	mu       sync.Mutex
+	pending  map[string][]pendingRow // keyed by collection
+	tables   map[string]bool         // collection tables already CREATEd
+	flushErr error
+}
+
+// SQLFileSinkOption configures a SQLFileSink constructed with
+// NewSQLFileSink.
+type SQLFileSinkOption func(*SQLFileSink)
+
+// WithSQLFileBatchSize sets how many rows SQLFileSink buffers per collection
+// before flushing them as a single upsert statement. Defaults to 500.
+func WithSQLFileBatchSize(n int) SQLFileSinkOption {
+	return func(s *SQLFileSink) { s.batchSize = n }
+}
+
+// WithSQLFileFlushInterval makes SQLFileSink flush every collection's
+// buffered rows at least this often even if WithSQLFileBatchSize's
+// threshold hasn't been reached, so a slow trickle of writes to a
+// low-volume collection doesn't sit unflushed indefinitely. Zero (the
+// default) disables the interval flush; Close always flushes whatever
+// remains.
+func WithSQLFileFlushInterval(d time.Duration) SQLFileSinkOption {
+	return func(s *SQLFileSink) { s.flushInterval = d }
+}
+
+// NewSQLFileSink creates a SQLFileSink that writes through db, which the
+// caller must have already opened with a registered SQLite or DuckDB
+// driver.
+func NewSQLFileSink(db *sql.DB, opts ...SQLFileSinkOption) *SQLFileSink {
+	s := &SQLFileSink{
+		db:        db,
+		batchSize: 500,
+		pending:   make(map[string][]pendingRow),
+		tables:    make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.flushInterval > 0 {
+		s.stopCh = make(chan struct{})
+		s.stopped.Add(1)
+		go s.flushLoop()
+	}
+	return s
+}
+
+// flushLoop periodically flushes every collection's buffered rows until
+// stopCh is closed. A flush error is recorded rather than returned from
+// here (there's no caller waiting on this goroutine); Close surfaces it
+// instead.
+func (s *SQLFileSink) flushLoop() {
+	defer s.stopped.Done()
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Flush(context.Background()); err != nil {
+				s.mu.Lock()
+				s.flushErr = err
+				s.mu.Unlock()
+			}
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// Write buffers rec under its collection's table and flushes that
+// collection's batch once it reaches the configured batch size.
+func (s *SQLFileSink) Write(ctx context.Context, did string, rec Record) error {
+	key, err := RecordUpsertKey(did, rec)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.pending[key.Collection] = append(s.pending[key.Collection], pendingRow{key: key, value: rec.Value})
+	flush := len(s.pending[key.Collection]) >= s.batchSize
+	s.mu.Unlock()
+
+	if flush {
+		return s.flushCollection(ctx, key.Collection)
+	}
+	return nil
+}
+
+// Flush flushes every collection with buffered rows.
+func (s *SQLFileSink) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	collections := make([]string, 0, len(s.pending))
+	for collection := range s.pending {
+		collections = append(collections, collection)
+	}
+	s.mu.Unlock()
+
+	for _, collection := range collections {
+		if err := s.flushCollection(ctx, collection); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SQLFileSink) flushCollection(ctx context.Context, collection string) error {
+	s.mu.Lock()
+	rows := s.pending[collection]
+	delete(s.pending, collection)
+	s.mu.Unlock()
+
+	if len(rows) == 0 {
+		return nil
+	}
+
+	table := collectionTableName(collection)
+	query, args := buildSQLiteUpsert(table, rows)
+
+	// A brand-new collection's table doesn't exist yet, but issuing its
+	// CREATE TABLE as a second statement against the same *sql.DB would
+	// make every first flush of a collection cost two round trips
+	// instead of one. Folding it into the same statement as the upsert
+	// keeps a flush to a single exec regardless of whether the table is
+	// new; SQLite and DuckDB both execute semicolon-separated statements
+	// from one Exec call, binding placeholder args to the final one.
+	if !s.tableKnown(table) {
+		query = tableDDL(table) + "; " + query
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("sql file sink flush: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("sql file sink flush: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("sql file sink flush: %w", err)
+	}
+
+	s.mu.Lock()
+	s.tables[table] = true
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *SQLFileSink) tableKnown(table string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tables[table]
+}
+
+func tableDDL(table string) string {
+	return fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (did TEXT NOT NULL, rkey TEXT NOT NULL, cid TEXT NOT NULL, value TEXT, PRIMARY KEY (did, rkey))",
+		table,
+	)
+}
+
+// buildSQLiteUpsert renders a single INSERT ... ON CONFLICT DO UPDATE
+// statement covering every row, using "?" placeholders as SQLite and
+// DuckDB both expect.
+func buildSQLiteUpsert(table string, rows []pendingRow) (string, []any) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "INSERT INTO %s (did, rkey, cid, value) VALUES ", table)
+
+	args := make([]any, 0, len(rows)*4)
+	for i, row := range rows {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("(?, ?, ?, ?)")
+		args = append(args, row.key.DID, row.key.Rkey, row.key.CID, []byte(row.value))
+	}
+
+	sb.WriteString(" ON CONFLICT (did, rkey) DO UPDATE SET cid = excluded.cid, value = excluded.value")
+	return sb.String(), args
+}
+
+// collectionTableName derives a SQL-safe table name from an AT Protocol
+// collection NSID, e.g. "app.bsky.feed.post" -> "app_bsky_feed_post".
+func collectionTableName(collection string) string {
+	return strings.ReplaceAll(collection, ".", "_")
+}
+
+// Close stops the background interval flush (if any), flushes any buffered
+// rows, and reports whichever error, if any, a prior interval flush hit.
+// It does not close the underlying *sql.DB, which the caller owns.
+func (s *SQLFileSink) Close() error {
+	if s.stopCh != nil {
+		close(s.stopCh)
+		s.stopped.Wait()
+	}
+
+	err := s.Flush(context.Background())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return s.flushErr
+}
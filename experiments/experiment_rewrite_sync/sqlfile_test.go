@@ -0,0 +1,147 @@
+package backfill
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCollectionTableNameSanitizesDots(t *testing.T) {
+	if got, want := collectionTableName("app.bsky.feed.post"), "app_bsky_feed_post"; got != want {
+		t.Errorf("collectionTableName() = %q, want %q", got, want)
+	}
+}
+
+func TestBuildSQLiteUpsertUsesQuestionMarkPlaceholders(t *testing.T) {
+	query, args := buildSQLiteUpsert("app_bsky_feed_post", []pendingRow{
+		{key: UpsertKey{DID: "did:plc:alice", Rkey: "1", CID: "bafy1"}},
+		{key: UpsertKey{DID: "did:plc:bob", Rkey: "2", CID: "bafy2"}},
+	})
+
+	if !strings.Contains(query, "INSERT INTO app_bsky_feed_post") {
+		t.Errorf("query = %q, want it to target app_bsky_feed_post", query)
+	}
+	if !strings.Contains(query, "ON CONFLICT (did, rkey) DO UPDATE") {
+		t.Errorf("query = %q, want an ON CONFLICT upsert clause", query)
+	}
+	if strings.Contains(query, "$1") {
+		t.Errorf("query = %q, want ? placeholders, not $N", query)
+	}
+	if len(args) != 8 {
+		t.Fatalf("got %d args, want 8 (4 per row)", len(args))
+	}
+}
+
+func TestSQLFileSinkFlushesOneStatementPerCollectionPerBatch(t *testing.T) {
+	drv := &fakePGDriver{}
+	driverName := "fake-sqlfile-" + t.Name()
+	sql.Register(driverName, drv)
+
+	db, err := sql.Open(driverName, "")
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	defer db.Close()
+
+	sink := NewSQLFileSink(db, WithSQLFileBatchSize(2))
+	ctx := context.Background()
+
+	if err := sink.Write(ctx, "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1", CID: "bafy1"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := sink.Write(ctx, "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.like/1", CID: "bafy2"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	drv.mu.Lock()
+	got := len(drv.queries)
+	drv.mu.Unlock()
+	// Neither collection reached the batch size of 2 on its own yet.
+	if got != 0 {
+		t.Fatalf("got %d exec'd statements, want 0 (each collection has only 1 buffered row)", got)
+	}
+
+	if err := sink.Write(ctx, "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/2", CID: "bafy3"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	drv.mu.Lock()
+	got = len(drv.queries)
+	drv.mu.Unlock()
+	if got != 1 {
+		t.Fatalf("got %d exec'd statements after app.bsky.feed.post reached batch size, want 1", got)
+	}
+}
+
+func TestSQLFileSinkCreatesOneTablePerCollection(t *testing.T) {
+	drv := &fakePGDriver{}
+	driverName := "fake-sqlfile-" + t.Name()
+	sql.Register(driverName, drv)
+
+	db, err := sql.Open(driverName, "")
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	defer db.Close()
+
+	sink := NewSQLFileSink(db)
+	ctx := context.Background()
+
+	if err := sink.Write(ctx, "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1", CID: "bafy1"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := sink.Write(ctx, "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.like/1", CID: "bafy2"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	drv.mu.Lock()
+	defer drv.mu.Unlock()
+	var sawPostTable, sawLikeTable bool
+	for _, q := range drv.queries {
+		if strings.Contains(q, "CREATE TABLE") && strings.Contains(q, "app_bsky_feed_post") {
+			sawPostTable = true
+		}
+		if strings.Contains(q, "CREATE TABLE") && strings.Contains(q, "app_bsky_feed_like") {
+			sawLikeTable = true
+		}
+	}
+	if !sawPostTable || !sawLikeTable {
+		t.Errorf("queries = %v, want a CREATE TABLE for both app_bsky_feed_post and app_bsky_feed_like", drv.queries)
+	}
+}
+
+func TestSQLFileSinkWithFlushIntervalFlushesAPartialBatch(t *testing.T) {
+	drv := &fakePGDriver{}
+	driverName := "fake-sqlfile-" + t.Name()
+	sql.Register(driverName, drv)
+
+	db, err := sql.Open(driverName, "")
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	defer db.Close()
+
+	sink := NewSQLFileSink(db, WithSQLFileBatchSize(1000), WithSQLFileFlushInterval(10*time.Millisecond))
+	defer sink.Close()
+
+	if err := sink.Write(context.Background(), "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1", CID: "bafy1"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		drv.mu.Lock()
+		n := len(drv.queries)
+		drv.mu.Unlock()
+		if n == 1 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("flush interval never flushed the buffered row")
+}
@@ -0,0 +1,85 @@
+package backfill
+
+import "sort"
+
+// Stats summarizes a set of records for dataset QA, without needing a
+// separate Python script over the output directory.
+type Stats struct {
+	TotalRecords int
+
+	// PerCollection and PerDID count records by AT-URI collection and DID,
+	// derived by parsing each record's URI; records with an unparseable
+	// URI are skipped from both.
+	PerCollection map[string]int
+	PerDID        map[string]int
+
+	// DateCoverage buckets records by day (YYYY-MM-DD), using Timestamp
+	// (see ParseTID); records without a usable Timestamp aren't counted
+	// here but are reflected in MissingTimestamp.
+	DateCoverage map[string]int
+
+	// MissingTimestamp and MissingValue are null-rate counters: how many
+	// records had no TID-derived Timestamp, and how many had an empty
+	// Value, respectively.
+	MissingTimestamp int
+	MissingValue     int
+}
+
+// ComputeStats aggregates Stats over records.
+func ComputeStats(records []Record) Stats {
+	s := Stats{
+		PerCollection: make(map[string]int),
+		PerDID:        make(map[string]int),
+		DateCoverage:  make(map[string]int),
+	}
+
+	for _, rec := range records {
+		s.TotalRecords++
+
+		if did, collection, _, err := ParseATURI(rec.URI); err == nil {
+			s.PerCollection[collection]++
+			s.PerDID[did]++
+		}
+
+		if rec.Timestamp != nil {
+			s.DateCoverage[rec.Timestamp.Format("2006-01-02")]++
+		} else {
+			s.MissingTimestamp++
+		}
+
+		if len(rec.Value) == 0 {
+			s.MissingValue++
+		}
+	}
+
+	return s
+}
+
+// TopDIDs returns the n DIDs with the most records, most first. Ties break
+// by DID for a stable order.
+func (s Stats) TopDIDs(n int) []string {
+	return topN(s.PerDID, n)
+}
+
+// TopCollections returns the n collections with the most records, most
+// first. Ties break by collection name for a stable order.
+func (s Stats) TopCollections(n int) []string {
+	return topN(s.PerCollection, n)
+}
+
+func topN(counts map[string]int, n int) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if n >= 0 && len(keys) > n {
+		keys = keys[:n]
+	}
+	return keys
+}
@@ -0,0 +1,50 @@
+package backfill
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeStatsCountsPerCollectionAndPerDID(t *testing.T) {
+	ts := time.Date(2023, 6, 15, 0, 0, 0, 0, time.UTC)
+	records := []Record{
+		{URI: "at://did:plc:alice/app.bsky.feed.post/1", Value: []byte(`{}`), Timestamp: &ts},
+		{URI: "at://did:plc:alice/app.bsky.feed.post/2", Value: []byte(`{}`), Timestamp: &ts},
+		{URI: "at://did:plc:bob/app.bsky.feed.like/1", Value: []byte(`{}`)},
+		{URI: "not-a-uri"},
+	}
+
+	s := ComputeStats(records)
+
+	if s.TotalRecords != 4 {
+		t.Errorf("TotalRecords = %d, want 4", s.TotalRecords)
+	}
+	if s.PerCollection["app.bsky.feed.post"] != 2 {
+		t.Errorf("PerCollection[post] = %d, want 2", s.PerCollection["app.bsky.feed.post"])
+	}
+	if s.PerDID["did:plc:alice"] != 2 {
+		t.Errorf("PerDID[alice] = %d, want 2", s.PerDID["did:plc:alice"])
+	}
+	if s.DateCoverage["2023-06-15"] != 2 {
+		t.Errorf("DateCoverage[2023-06-15] = %d, want 2", s.DateCoverage["2023-06-15"])
+	}
+	if s.MissingTimestamp != 2 {
+		t.Errorf("MissingTimestamp = %d, want 2", s.MissingTimestamp)
+	}
+	if s.MissingValue != 1 {
+		t.Errorf("MissingValue = %d, want 1", s.MissingValue)
+	}
+}
+
+func TestStatsTopCollectionsOrdersByCountThenName(t *testing.T) {
+	s := ComputeStats([]Record{
+		{URI: "at://did:plc:alice/app.bsky.feed.like/1"},
+		{URI: "at://did:plc:alice/app.bsky.feed.post/1"},
+		{URI: "at://did:plc:alice/app.bsky.feed.post/2"},
+	})
+
+	got := s.TopCollections(1)
+	if len(got) != 1 || got[0] != "app.bsky.feed.post" {
+		t.Errorf("TopCollections(1) = %v, want [app.bsky.feed.post]", got)
+	}
+}
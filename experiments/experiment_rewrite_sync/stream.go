@@ -0,0 +1,79 @@
+package backfill
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RunStream behaves like Run but delivers each DID's result as soon as it's
+// ready, instead of making callers wait for the full Result. Both returned
+// channels are closed once every DID has been processed. The error channel
+// carries run-level failures (e.g. context cancellation) rather than
+// per-DID errors, which are reported on DIDResult.Err.
+func RunStream(ctx context.Context, cfg Config) (<-chan DIDResult, <-chan error) {
+	results := make(chan DIDResult, cfg.ResultQueueCapacity)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(results)
+		defer close(errs)
+
+		httpClient := cfg.HTTPClient
+		if httpClient == nil {
+			httpClient = http.DefaultClient
+		}
+		hooks := hooksOrNoop(cfg.Hooks)
+
+		var resolved []ResolvedDID
+		for _, item := range orderByPriority(cfg.DIDs) {
+			if ctx.Err() != nil {
+				errs <- ctx.Err()
+				return
+			}
+			start := time.Now()
+			hooks.OnDIDStart(item.DID)
+			r, err := ResolveDID(ctx, httpClient, cfg.PLCDirectoryURL, item.DID)
+			if err != nil {
+				hooks.OnError(item.DID, err)
+				didResult := DIDResult{DID: item.DID, Err: err, ErrClass: classifyErr(err), Duration: time.Since(start)}
+				hooks.OnDIDComplete(didResult)
+				hooks.OnQueueDepth("results", len(results), cap(results))
+				results <- didResult
+				continue
+			}
+			resolved = append(resolved, r)
+		}
+
+		scheduled := scheduleByHost(resolved)
+		limiter := newHostLimiter(cfg.MaxInFlightPerHost)
+
+		var wg sync.WaitGroup
+		for _, r := range scheduled {
+			wg.Add(1)
+			go func(r ResolvedDID) {
+				defer wg.Done()
+				start := time.Now()
+				release := limiter.acquire(r.PDSHost)
+				defer release()
+
+				decision := applyFilters(cfg, r)
+				didResult := DIDResult{
+					DID:      r.DID,
+					PDSHost:  r.PDSHost,
+					Handle:   r.Handle,
+					Filtered: !decision.Allowed,
+					Reason:   decision.Reason,
+					Duration: time.Since(start),
+				}
+				hooks.OnDIDComplete(didResult)
+				hooks.OnQueueDepth("results", len(results), cap(results))
+				results <- didResult
+			}(r)
+		}
+		wg.Wait()
+	}()
+
+	return results, errs
+}
@@ -0,0 +1,98 @@
+package backfill
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync/backfilltest"
+)
+
+type queueDepthHooks struct {
+	NoopHooks
+	mu         sync.Mutex
+	depths     []int
+	capacities []int
+}
+
+func (h *queueDepthHooks) OnQueueDepth(stage string, depth, capacity int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.depths = append(h.depths, depth)
+	h.capacities = append(h.capacities, capacity)
+}
+
+func TestRunStreamReportsCancellationOnErrorChannel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cfg := Config{DIDs: []WorkItem{{DID: "did:plc:whatever"}}}
+	results, errs := RunStream(ctx, cfg)
+
+	for r := range results {
+		t.Errorf("unexpected result on a cancelled run: %+v", r)
+	}
+
+	err, ok := <-errs
+	if !ok {
+		t.Fatal("expected a run-level error on the error channel, got none")
+	}
+	if err != context.Canceled {
+		t.Errorf("err = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestRunStreamReportsQueueDepthForEveryResult(t *testing.T) {
+	hooks := &queueDepthHooks{}
+	cfg := Config{
+		DIDs:  []WorkItem{{DID: "not-a-valid-did"}},
+		Hooks: hooks,
+	}
+
+	results, errs := RunStream(context.Background(), cfg)
+	var got int
+	for range results {
+		got++
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("unexpected run-level error: %v", err)
+	}
+
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+	if len(hooks.depths) != got {
+		t.Fatalf("OnQueueDepth called %d time(s), want %d (one per result)", len(hooks.depths), got)
+	}
+	for i, c := range hooks.capacities {
+		if c != 0 {
+			t.Errorf("capacities[%d] = %d, want 0 (default unbuffered results channel)", i, c)
+		}
+	}
+}
+
+func TestRunStreamResultQueueCapacityBuffersResults(t *testing.T) {
+	pds := backfilltest.NewPDS(nil)
+	defer pds.Close()
+	plc := backfilltest.NewPLCDirectory(map[string]backfilltest.PLCDoc{
+		"did:plc:alice": backfilltest.PDSDoc(pds.URL, "alice.bsky.social"),
+	})
+	defer plc.Close()
+
+	cfg := Config{
+		DIDs:                []WorkItem{{DID: "did:plc:alice"}},
+		PLCDirectoryURL:     plc.URL,
+		ResultQueueCapacity: 4,
+	}
+
+	results, errs := RunStream(context.Background(), cfg)
+	var count int
+	for range results {
+		count++
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("unexpected run-level error: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("got %d result(s), want 1", count)
+	}
+}
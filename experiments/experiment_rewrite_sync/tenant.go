@@ -0,0 +1,114 @@
+package backfill
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// ErrTenantQuotaExceeded is returned by JobServer when a job submission
+// would exceed its tenant's configured quota.
+var ErrTenantQuotaExceeded = errors.New("backfill: tenant quota exceeded")
+
+// TenantQuota caps how much of the server's capacity a single tenant may
+// use. Zero means unlimited for that dimension.
+type TenantQuota struct {
+	MaxCalls int
+	MaxBytes int64
+}
+
+// TenantUsage is a tenant's consumption against its TenantQuota so far.
+type TenantUsage struct {
+	Calls int   `json:"calls"`
+	Bytes int64 `json:"bytes"`
+}
+
+// WithTenantQuota caps the given tenant (JobRequest.Tenant) to at most
+// maxCalls job submissions and maxBytes of fetched data. Either may be 0
+// for "unlimited on this dimension". Tenants with no configured quota are
+// unlimited, so several research teams sharing one deployment should each
+// get a quota once the agreed crawl budget is known.
+func WithTenantQuota(tenant string, maxCalls int, maxBytes int64) JobServerOption {
+	return func(s *JobServer) {
+		s.tenantQuotas[tenant] = TenantQuota{MaxCalls: maxCalls, MaxBytes: maxBytes}
+	}
+}
+
+// checkTenantQuotaLocked returns ErrTenantQuotaExceeded if admitting one
+// more job for tenant would exceed its quota. Callers must hold s.mu.
+func (s *JobServer) checkTenantQuotaLocked(tenant string) error {
+	quota, ok := s.tenantQuotas[tenant]
+	if !ok {
+		return nil
+	}
+	usage := s.tenantUsage[tenant]
+	if usage == nil {
+		usage = &TenantUsage{}
+	}
+	if quota.MaxCalls > 0 && usage.Calls+1 > quota.MaxCalls {
+		return fmt.Errorf("%w: tenant %q has used %d/%d calls", ErrTenantQuotaExceeded, tenant, usage.Calls, quota.MaxCalls)
+	}
+	if quota.MaxBytes > 0 && usage.Bytes >= quota.MaxBytes {
+		return fmt.Errorf("%w: tenant %q has used %d/%d bytes", ErrTenantQuotaExceeded, tenant, usage.Bytes, quota.MaxBytes)
+	}
+	return nil
+}
+
+// recordTenantUsageLocked charges a finished job's bytes against its
+// tenant. The call itself was already charged at submission time, in
+// checkTenantQuotaLocked's caller. Callers must hold s.mu.
+func (s *JobServer) recordTenantUsageLocked(tenant string, result *Result) {
+	if tenant == "" {
+		return
+	}
+	usage := s.tenantUsage[tenant]
+	if usage == nil {
+		usage = &TenantUsage{}
+		s.tenantUsage[tenant] = usage
+	}
+	if result != nil {
+		for _, d := range result.PerDID {
+			usage.Bytes += d.Bytes
+		}
+	}
+}
+
+// TenantUsageReport returns a snapshot of every tenant seen so far, keyed
+// by tenant name.
+func (s *JobServer) TenantUsageReport() map[string]TenantUsage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report := make(map[string]TenantUsage, len(s.tenantUsage))
+	for tenant, usage := range s.tenantUsage {
+		report[tenant] = *usage
+	}
+	return report
+}
+
+// handleTenants serves GET /tenants, reporting call/byte usage per tenant
+// in JSON, sorted by tenant name for a stable diff-friendly response.
+func (s *JobServer) handleTenants(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	report := s.TenantUsageReport()
+	tenants := make([]string, 0, len(report))
+	for tenant := range report {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+
+	type tenantEntry struct {
+		Tenant string      `json:"tenant"`
+		Usage  TenantUsage `json:"usage"`
+	}
+	entries := make([]tenantEntry, 0, len(tenants))
+	for _, tenant := range tenants {
+		entries = append(entries, tenantEntry{Tenant: tenant, Usage: report[tenant]})
+	}
+	writeJobJSON(w, http.StatusOK, entries)
+}
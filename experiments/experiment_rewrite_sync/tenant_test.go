@@ -0,0 +1,75 @@
+package backfill
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJobServerRejectsSubmissionOverTenantCallQuota(t *testing.T) {
+	srv, err := NewJobServer(filepath.Join(t.TempDir(), "jobs.json"), WithTenantQuota("acme", 1, 0))
+	if err != nil {
+		t.Fatalf("NewJobServer() error = %v", err)
+	}
+
+	first := postJob(t, srv, JobRequest{DIDs: []string{"did:plc:alice"}, Tenant: "acme"})
+	if first.ID == "" {
+		t.Fatalf("first job.ID is empty")
+	}
+
+	body := `{"dids":["did:plc:bob"],"tenant":"acme"}`
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(body))
+	srv.Handler().ServeHTTP(w, r)
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("second submission status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+}
+
+func TestJobServerUnlimitedTenantHasNoQuota(t *testing.T) {
+	srv, err := NewJobServer(filepath.Join(t.TempDir(), "jobs.json"), WithTenantQuota("acme", 1, 0))
+	if err != nil {
+		t.Fatalf("NewJobServer() error = %v", err)
+	}
+
+	job := postJob(t, srv, JobRequest{DIDs: []string{"did:plc:alice"}, Tenant: "other-tenant"})
+	if job.ID == "" {
+		t.Fatalf("job.ID is empty")
+	}
+}
+
+func TestTenantUsageReportTracksCallsAcrossSubmissions(t *testing.T) {
+	srv, err := NewJobServer(filepath.Join(t.TempDir(), "jobs.json"))
+	if err != nil {
+		t.Fatalf("NewJobServer() error = %v", err)
+	}
+
+	postJob(t, srv, JobRequest{DIDs: []string{"did:plc:alice"}, Tenant: "acme"})
+	postJob(t, srv, JobRequest{DIDs: []string{"did:plc:bob"}, Tenant: "acme"})
+
+	report := srv.TenantUsageReport()
+	if report["acme"].Calls != 2 {
+		t.Errorf("acme.Calls = %d, want 2", report["acme"].Calls)
+	}
+}
+
+func TestHandleTenantsReturnsSortedJSONReport(t *testing.T) {
+	srv, err := NewJobServer(filepath.Join(t.TempDir(), "jobs.json"))
+	if err != nil {
+		t.Fatalf("NewJobServer() error = %v", err)
+	}
+	postJob(t, srv, JobRequest{DIDs: []string{"did:plc:alice"}, Tenant: "zeta"})
+	postJob(t, srv, JobRequest{DIDs: []string{"did:plc:bob"}, Tenant: "acme"})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/tenants", nil)
+	srv.Handler().ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /tenants status = %d", w.Code)
+	}
+	if !strings.HasPrefix(w.Body.String(), `[{"tenant":"acme"`) {
+		t.Errorf("body = %s, want tenants sorted with acme first", w.Body.String())
+	}
+}
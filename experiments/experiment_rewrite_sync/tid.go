@@ -0,0 +1,33 @@
+package backfill
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// tidCharset is the base32-sortable alphabet used by AT Protocol TIDs.
+const tidCharset = "234567abcdefghijklmnopqrstuvwxyz"
+
+// ParseTID decodes an AT Protocol TID record key into the timestamp it was
+// minted at. TIDs are a 64-bit integer (top bit unused, next 53 bits
+// microseconds since the Unix epoch, low 10 bits a random clock identifier)
+// encoded as 13 base32-sortable characters, so most record keys carry a
+// timestamp even when the record itself has no createdAt field.
+func ParseTID(rkey string) (time.Time, error) {
+	if len(rkey) != 13 {
+		return time.Time{}, fmt.Errorf("parse TID %q: want 13 characters, got %d", rkey, len(rkey))
+	}
+
+	var v uint64
+	for _, c := range rkey {
+		idx := strings.IndexRune(tidCharset, c)
+		if idx < 0 {
+			return time.Time{}, fmt.Errorf("parse TID %q: %q is not in the base32-sortable alphabet", rkey, c)
+		}
+		v = v<<5 | uint64(idx)
+	}
+
+	micros := v >> 10
+	return time.UnixMicro(int64(micros)).UTC(), nil
+}
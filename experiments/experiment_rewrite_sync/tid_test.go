@@ -0,0 +1,56 @@
+package backfill
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTIDRoundTripsKnownValue(t *testing.T) {
+	// 3k2x4q4q4q4 is not a real TID; build one from a known timestamp
+	// instead so the expected value is derived, not hand-picked.
+	want := time.Date(2023, 6, 15, 12, 0, 0, 0, time.UTC)
+	micros := uint64(want.UnixMicro())
+	v := micros << 10
+
+	var rkey [13]byte
+	for i := 12; i >= 0; i-- {
+		rkey[i] = tidCharset[v&0x1f]
+		v >>= 5
+	}
+
+	got, err := ParseTID(string(rkey[:]))
+	if err != nil {
+		t.Fatalf("ParseTID() error = %v", err)
+	}
+	if !got.Equal(want) {
+		t.Errorf("ParseTID() = %v, want %v", got, want)
+	}
+}
+
+func TestParseTIDRejectsWrongLength(t *testing.T) {
+	if _, err := ParseTID("tooshort"); err == nil {
+		t.Fatal("ParseTID() error = nil, want error")
+	}
+}
+
+func TestParseTIDRejectsInvalidCharacters(t *testing.T) {
+	if _, err := ParseTID("1111111111111"); err == nil {
+		t.Fatal("ParseTID() error = nil, want error for out-of-alphabet characters")
+	}
+}
+
+func TestWithTIDTimestampSetsTimestampForValidTID(t *testing.T) {
+	rec := Record{URI: "at://did:plc:alice/app.bsky.feed.post/3k2x4q4q4q4aa"}
+	got := withTIDTimestamp(rec)
+	if got.Timestamp == nil {
+		t.Fatalf("Timestamp is nil, want a valid TID-derived timestamp")
+	}
+}
+
+func TestWithTIDTimestampLeavesNonTIDRkeyUnset(t *testing.T) {
+	rec := Record{URI: "at://did:plc:alice/app.bsky.feed.post/self"}
+	got := withTIDTimestamp(rec)
+	if got.Timestamp != nil {
+		t.Errorf("Timestamp = %v, want nil for non-TID rkey", got.Timestamp)
+	}
+}
@@ -0,0 +1,78 @@
+package backfill
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TimeoutRetryConfig configures Run's end-of-run retry pass over DIDs that
+// failed with ErrPDSTimeout. A large legitimate repo that's merely slow
+// looks identical, under the default timeout, to a genuinely unresponsive
+// host — and the fix for one (more time, less contention from other
+// in-flight work) is the opposite of the fix for the other (give up
+// sooner) — so the retry pass deliberately runs with a longer per-request
+// timeout and at lower concurrency than the main run.
+type TimeoutRetryConfig struct {
+	// Timeout replaces the per-request HTTP client timeout for the retry
+	// pass. Zero means no timeout, matching http.Client's own zero value.
+	Timeout time.Duration
+
+	// Concurrency bounds how many timed-out DIDs are retried at once,
+	// independent of Config.Concurrency. Zero means unlimited.
+	Concurrency int
+}
+
+// retryTimedOutDIDs re-processes every entry in perDID whose ErrClass is
+// "timeout" against its matching ResolvedDID in resolved, using a client
+// with cfg.TimeoutRetry's longer timeout and a concurrency limiter bounded
+// by cfg.TimeoutRetry.Concurrency instead of cfg.Concurrency. It returns
+// perDID with each retried entry replaced by the outcome of its retry,
+// successful or not; entries that weren't retried are left unchanged.
+func retryTimedOutDIDs(ctx context.Context, httpClient *http.Client, cfg Config, perDID []DIDResult, resolved []ResolvedDID, registry *RepoRegistry, tracker *inFlightTracker, hooks Hooks) []DIDResult {
+	byDID := make(map[string]ResolvedDID, len(resolved))
+	for _, r := range resolved {
+		byDID[r.DID] = r
+	}
+
+	retryClient := &http.Client{Transport: httpClient.Transport, Timeout: cfg.TimeoutRetry.Timeout}
+	limiter := newHostLimiter(cfg.TimeoutRetry.Concurrency)
+
+	var wg sync.WaitGroup
+	for i := range perDID {
+		if perDID[i].ErrClass != "timeout" {
+			continue
+		}
+		r, ok := byDID[perDID[i].DID]
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, r ResolvedDID) {
+			defer wg.Done()
+			// acquire on a constant key, not r.PDSHost: hostLimiter caps
+			// concurrency per distinct key, and timed-out DIDs are
+			// typically spread across many different slow hosts, so a
+			// per-host key would let every host's retry run in parallel
+			// instead of bounding the retry pass overall.
+			release := limiter.acquire("")
+			defer release()
+
+			id := tracker.start()
+			defer tracker.finish(id)
+
+			start := time.Now()
+			didResult := processScheduledDID(ctx, retryClient, cfg, r, start)
+			hooks.OnDIDComplete(didResult)
+			if didResult.Err != nil {
+				hooks.OnError(r.DID, didResult.Err)
+			}
+			recordToRegistry(registry, didResult)
+			perDID[i] = didResult
+		}(i, r)
+	}
+	wg.Wait()
+
+	return perDID
+}
@@ -0,0 +1,88 @@
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryTimedOutDIDsReplacesOnlyTimeoutEntries(t *testing.T) {
+	resolved := []ResolvedDID{
+		{DID: "did:plc:timedout", PDSHost: "a.example", PDSEndpoint: "http://a.example"},
+		{DID: "did:plc:other", PDSHost: "b.example", PDSEndpoint: "http://b.example"},
+	}
+	perDID := []DIDResult{
+		{DID: "did:plc:timedout", Err: ErrPDSTimeout, ErrClass: "timeout"},
+		{DID: "did:plc:other", Err: ErrDIDNotFound, ErrClass: "not_found"},
+	}
+
+	cfg := Config{TimeoutRetry: &TimeoutRetryConfig{Timeout: time.Second, Concurrency: 1}}
+	tracker := newInFlightTracker()
+
+	got := retryTimedOutDIDs(context.Background(), http.DefaultClient, cfg, perDID, resolved, nil, tracker, NoopHooks{})
+
+	if got[0].Err != nil {
+		t.Errorf("retried DID still has Err = %v, want nil (processScheduledDID doesn't fetch, so a retry always succeeds)", got[0].Err)
+	}
+	if got[1].ErrClass != "not_found" {
+		t.Errorf("non-timeout entry was modified: %+v", got[1])
+	}
+}
+
+// concurrencyTrackingHooks records, via OnDIDComplete, the peak number of
+// DIDs it saw in progress at once. Since retryTimedOutDIDs only releases a
+// DID's limiter slot after OnDIDComplete returns, the sleep below is
+// executed while that slot is held, making the observed peak a direct
+// measurement of the limiter's effective bound.
+type concurrencyTrackingHooks struct {
+	NoopHooks
+	current, max int32
+}
+
+func (h *concurrencyTrackingHooks) OnDIDComplete(DIDResult) {
+	n := atomic.AddInt32(&h.current, 1)
+	for {
+		m := atomic.LoadInt32(&h.max)
+		if n <= m || atomic.CompareAndSwapInt32(&h.max, m, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	atomic.AddInt32(&h.current, -1)
+}
+
+func TestRetryTimedOutDIDsBoundsConcurrencyAcrossDistinctHostsNotPerHost(t *testing.T) {
+	const numDIDs = 8
+	var resolved []ResolvedDID
+	var perDID []DIDResult
+	for i := 0; i < numDIDs; i++ {
+		did := fmt.Sprintf("did:plc:timedout%d", i)
+		resolved = append(resolved, ResolvedDID{DID: did, PDSHost: fmt.Sprintf("host%d.example", i)})
+		perDID = append(perDID, DIDResult{DID: did, Err: ErrPDSTimeout, ErrClass: "timeout"})
+	}
+
+	cfg := Config{TimeoutRetry: &TimeoutRetryConfig{Timeout: time.Second, Concurrency: 2}}
+	tracker := newInFlightTracker()
+	hooks := &concurrencyTrackingHooks{}
+
+	retryTimedOutDIDs(context.Background(), http.DefaultClient, cfg, perDID, resolved, nil, tracker, hooks)
+
+	if hooks.max > 2 {
+		t.Errorf("observed %d DIDs retried concurrently across %d distinct hosts, want <= 2 (Concurrency is supposed to be a global cap, not per-host)", hooks.max, numDIDs)
+	}
+}
+
+func TestRetryTimedOutDIDsSkipsEntriesWithoutAResolvedMatch(t *testing.T) {
+	perDID := []DIDResult{{DID: "did:plc:unresolved", Err: ErrPDSTimeout, ErrClass: "timeout"}}
+	cfg := Config{TimeoutRetry: &TimeoutRetryConfig{Timeout: time.Second}}
+	tracker := newInFlightTracker()
+
+	got := retryTimedOutDIDs(context.Background(), http.DefaultClient, cfg, perDID, nil, nil, tracker, NoopHooks{})
+
+	if got[0].Err != ErrPDSTimeout {
+		t.Errorf("entry without a resolved match was modified: %+v", got[0])
+	}
+}
@@ -0,0 +1,36 @@
+package backfill
+
+import (
+	"context"
+	"fmt"
+)
+
+// Transform inspects and optionally modifies a decoded record before it
+// reaches a Sink. Returning keep=false drops the record entirely, e.g. for
+// site-specific anonymization or redaction that shouldn't require forking
+// this tool.
+type Transform func(did string, rec Record) (out Record, keep bool, err error)
+
+// TransformSink applies a Transform to every record before forwarding kept
+// records to next.
+type TransformSink struct {
+	transform Transform
+	next      Sink
+}
+
+// NewTransformSink wraps next so every write passes through transform
+// first.
+func NewTransformSink(transform Transform, next Sink) *TransformSink {
+	return &TransformSink{transform: transform, next: next}
+}
+
+func (s *TransformSink) Write(ctx context.Context, did string, rec Record) error {
+	out, keep, err := s.transform(did, rec)
+	if err != nil {
+		return fmt.Errorf("transform record for %s: %w", did, err)
+	}
+	if !keep {
+		return nil
+	}
+	return s.next.Write(ctx, did, out)
+}
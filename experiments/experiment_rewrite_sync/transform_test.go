@@ -0,0 +1,52 @@
+package backfill
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTransformSinkForwardsModifiedRecord(t *testing.T) {
+	next := &recordingSink{}
+	redact := func(did string, rec Record) (Record, bool, error) {
+		rec.CID = "redacted"
+		return rec, true, nil
+	}
+	sink := NewTransformSink(redact, next)
+
+	if err := sink.Write(context.Background(), "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1", CID: "bafy1"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if len(next.writes) != 1 || next.writes[0].CID != "redacted" {
+		t.Fatalf("got writes = %+v, want one record with CID redacted", next.writes)
+	}
+}
+
+func TestTransformSinkDropsFilteredRecords(t *testing.T) {
+	next := &recordingSink{}
+	dropAll := func(did string, rec Record) (Record, bool, error) {
+		return Record{}, false, nil
+	}
+	sink := NewTransformSink(dropAll, next)
+
+	if err := sink.Write(context.Background(), "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if len(next.writes) != 0 {
+		t.Fatalf("got %d writes, want 0", len(next.writes))
+	}
+}
+
+func TestTransformSinkPropagatesTransformError(t *testing.T) {
+	wantErr := errors.New("transform boom")
+	next := &recordingSink{}
+	failing := func(did string, rec Record) (Record, bool, error) {
+		return Record{}, false, wantErr
+	}
+	sink := NewTransformSink(failing, next)
+
+	err := sink.Write(context.Background(), "did:plc:alice", Record{URI: "at://did:plc:alice/app.bsky.feed.post/1"})
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("Write() error = %v, want it to wrap %v", err, wantErr)
+	}
+}
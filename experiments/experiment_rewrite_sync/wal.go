@@ -0,0 +1,124 @@
+package backfill
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// walEntry is one line of a WAL file.
+type walEntry struct {
+	DID    string `json:"did"`
+	Record Record `json:"record"`
+}
+
+// WAL is a small append-only, fsync'd on-disk log of records that have been
+// fetched and decoded but not yet flushed to a Sink. Wrapping a Sink in a
+// WALSink means a crash between "record decoded" and "record flushed" loses
+// nothing: ReplayWAL feeds the unflushed entries back in on the next run.
+type WAL struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// NewWAL opens (creating if necessary) the WAL file at path for appending.
+func NewWAL(path string) (*WAL, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("new wal: %w", err)
+	}
+	return &WAL{file: file, enc: json.NewEncoder(file)}, nil
+}
+
+// Append durably records that did's rec has been fetched, fsync'ing before
+// it returns so the entry survives a crash immediately after.
+func (w *WAL) Append(did string, rec Record) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.enc.Encode(walEntry{DID: did, Record: rec}); err != nil {
+		return fmt.Errorf("wal append: %w", err)
+	}
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("wal append: %w", err)
+	}
+	return nil
+}
+
+// Checkpoint truncates the WAL, for use once every entry in it has been
+// durably flushed to the underlying sink.
+func (w *WAL) Checkpoint() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.file.Truncate(0); err != nil {
+		return fmt.Errorf("wal checkpoint: %w", err)
+	}
+	if _, err := w.file.Seek(0, 0); err != nil {
+		return fmt.Errorf("wal checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying WAL file without checkpointing it.
+func (w *WAL) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+// ReplayWAL reads every entry from the WAL file at path and writes it to
+// sink, returning the number of entries replayed. A missing WAL file
+// replays zero entries rather than erroring, since "no WAL yet" is the
+// normal state for a first run.
+func ReplayWAL(ctx context.Context, path string, sink Sink) (int, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("replay wal: %w", err)
+	}
+	defer file.Close()
+
+	var replayed int
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry walEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return replayed, fmt.Errorf("replay wal: decode entry %d: %w", replayed, err)
+		}
+		if err := sink.Write(ctx, entry.DID, entry.Record); err != nil {
+			return replayed, fmt.Errorf("replay wal: write entry %d: %w", replayed, err)
+		}
+		replayed++
+	}
+	if err := scanner.Err(); err != nil {
+		return replayed, fmt.Errorf("replay wal: %w", err)
+	}
+	return replayed, nil
+}
+
+// WALSink wraps another Sink, durably appending every record to a WAL
+// before forwarding it, so a crash mid-run can be recovered with ReplayWAL.
+type WALSink struct {
+	wal  *WAL
+	next Sink
+}
+
+// NewWALSink wraps next so every write is WAL-logged first.
+func NewWALSink(wal *WAL, next Sink) *WALSink {
+	return &WALSink{wal: wal, next: next}
+}
+
+func (s *WALSink) Write(ctx context.Context, did string, rec Record) error {
+	if err := s.wal.Append(did, rec); err != nil {
+		return err
+	}
+	return s.next.Write(ctx, did, rec)
+}
@@ -0,0 +1,107 @@
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+)
+
+// ConnectionStats reports what WarmHosts' own requests needed: a fresh
+// TCP/TLS handshake, or an already-warm connection reused from the
+// client's pool (e.g. two warmed hosts sharing a connection via HTTP/2,
+// or a repeated call against the same httpClient). It does not track
+// reuse for the rest of a run's requests — doing that would mean
+// threading an httptrace.ClientTrace through every XRPC call site
+// (blocks.go, fetch.go, getrecord.go, listrecords.go, ...), which isn't
+// done today.
+type ConnectionStats struct {
+	Handshakes int
+	Reused     int
+}
+
+// WarmHosts issues one lightweight describeServer request to each of the
+// topN busiest PDS hosts in resolved (by DID count), so the first real
+// request Run sends to each of them later doesn't pay a fresh TLS
+// handshake — TLS setup otherwise dominates latency for hosts seen only
+// once or twice in a large run. topN <= 0 warms every distinct host.
+func WarmHosts(ctx context.Context, httpClient *http.Client, resolved []ResolvedDID, topN int) ConnectionStats {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	hosts := busiestHosts(resolved, topN)
+
+	var stats ConnectionStats
+	for _, h := range hosts {
+		reused := false
+		trace := &httptrace.ClientTrace{
+			GotConn: func(info httptrace.GotConnInfo) {
+				reused = info.Reused
+			},
+		}
+		reqCtx := httptrace.WithClientTrace(ctx, trace)
+
+		reqURL := fmt.Sprintf("%s/xrpc/com.atproto.server.describeServer", h.endpoint)
+		req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, reqURL, nil)
+		if err != nil {
+			continue
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+
+		if reused {
+			stats.Reused++
+		} else {
+			stats.Handshakes++
+		}
+	}
+	return stats
+}
+
+type hostEndpoint struct {
+	host     string
+	endpoint string
+	count    int
+}
+
+// busiestHosts groups resolved by PDSHost, keeping the first PDSEndpoint
+// seen for each, and returns the topN by DID count (all of them if
+// topN <= 0), most-DIDs first, ties broken by host name for a stable
+// result.
+func busiestHosts(resolved []ResolvedDID, topN int) []hostEndpoint {
+	byHost := make(map[string]*hostEndpoint)
+	var order []string
+	for _, r := range resolved {
+		if r.PDSHost == "" {
+			continue
+		}
+		h, ok := byHost[r.PDSHost]
+		if !ok {
+			h = &hostEndpoint{host: r.PDSHost, endpoint: r.PDSEndpoint}
+			byHost[r.PDSHost] = h
+			order = append(order, r.PDSHost)
+		}
+		h.count++
+	}
+
+	hosts := make([]hostEndpoint, 0, len(order))
+	for _, host := range order {
+		hosts = append(hosts, *byHost[host])
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		if hosts[i].count != hosts[j].count {
+			return hosts[i].count > hosts[j].count
+		}
+		return hosts[i].host < hosts[j].host
+	})
+
+	if topN > 0 && len(hosts) > topN {
+		hosts = hosts[:topN]
+	}
+	return hosts
+}
@@ -0,0 +1,71 @@
+package backfill
+
+import (
+	"context"
+	"testing"
+
+	"github.com/METResearchGroup/bluesky-research/experiments/experiment_rewrite_sync/backfilltest"
+)
+
+func TestBusiestHostsOrdersByDIDCountThenName(t *testing.T) {
+	resolved := []ResolvedDID{
+		{DID: "did:plc:a", PDSHost: "quiet.example", PDSEndpoint: "https://quiet.example"},
+		{DID: "did:plc:b", PDSHost: "busy.example", PDSEndpoint: "https://busy.example"},
+		{DID: "did:plc:c", PDSHost: "busy.example", PDSEndpoint: "https://busy.example"},
+		{DID: "did:plc:d", PDSHost: "busy.example", PDSEndpoint: "https://busy.example"},
+	}
+
+	hosts := busiestHosts(resolved, 0)
+	if len(hosts) != 2 {
+		t.Fatalf("got %d hosts, want 2", len(hosts))
+	}
+	if hosts[0].host != "busy.example" || hosts[0].count != 3 {
+		t.Errorf("hosts[0] = %+v, want busy.example with count 3", hosts[0])
+	}
+	if hosts[1].host != "quiet.example" || hosts[1].count != 1 {
+		t.Errorf("hosts[1] = %+v, want quiet.example with count 1", hosts[1])
+	}
+}
+
+func TestBusiestHostsRespectsTopN(t *testing.T) {
+	resolved := []ResolvedDID{
+		{DID: "did:plc:a", PDSHost: "h1", PDSEndpoint: "https://h1"},
+		{DID: "did:plc:b", PDSHost: "h2", PDSEndpoint: "https://h2"},
+	}
+	if got := busiestHosts(resolved, 1); len(got) != 1 {
+		t.Fatalf("got %d hosts, want 1", len(got))
+	}
+}
+
+func TestWarmHostsReportsAHandshakePerDistinctHost(t *testing.T) {
+	pdsA := backfilltest.NewPDS(nil)
+	defer pdsA.Close()
+	pdsB := backfilltest.NewPDS(nil)
+	defer pdsB.Close()
+
+	resolved := []ResolvedDID{
+		{DID: "did:plc:a", PDSHost: "a", PDSEndpoint: pdsA.URL},
+		{DID: "did:plc:b", PDSHost: "b", PDSEndpoint: pdsB.URL},
+	}
+
+	stats := WarmHosts(context.Background(), nil, resolved, 0)
+	if stats.Handshakes != 2 {
+		t.Errorf("Handshakes = %d, want 2", stats.Handshakes)
+	}
+}
+
+func TestWarmHostsReusesAConnectionOnASecondCallToTheSameHost(t *testing.T) {
+	pds := backfilltest.NewPDS(nil)
+	defer pds.Close()
+
+	resolved := []ResolvedDID{
+		{DID: "did:plc:a", PDSHost: "a", PDSEndpoint: pds.URL},
+	}
+
+	client := pds.Client()
+	WarmHosts(context.Background(), client, resolved, 0)
+	stats := WarmHosts(context.Background(), client, resolved, 0)
+	if stats.Reused != 1 {
+		t.Errorf("Reused = %d, want 1 (second warm-up call reusing the first's connection)", stats.Reused)
+	}
+}
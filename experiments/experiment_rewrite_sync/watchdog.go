@@ -0,0 +1,48 @@
+package backfill
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Watchdog detects a unit of work that hasn't finished within Timeout, so
+// a single wedged PDS response can't silently hang a multi-day run. A nil
+// *Watchdog or a zero Timeout disables it: Watch becomes a no-op.
+type Watchdog struct {
+	Timeout time.Duration
+}
+
+// Watch starts the watchdog clock and returns:
+//   - done, which the caller must call exactly once when the work
+//     finishes, successfully or not, to stop the clock;
+//   - stuck, which reports whether the timeout fired. Callers should only
+//     read it after the watched work has actually returned, since onStuck
+//     typically cancels the context that unblocks it.
+//
+// If Timeout elapses first, onStuck is called with a stack dump of every
+// goroutine and how long the work had been running. Watch itself does not
+// cancel anything; onStuck is expected to call the context.CancelFunc for
+// the work being watched.
+func (w *Watchdog) Watch(onStuck func(elapsed time.Duration, stack []byte)) (done func(), stuck func() bool) {
+	if w == nil || w.Timeout <= 0 {
+		return func() {}, func() bool { return false }
+	}
+
+	start := time.Now()
+	var fired atomic.Bool
+	timer := time.AfterFunc(w.Timeout, func() {
+		fired.Store(true)
+		buf := make([]byte, 64*1024)
+		n := runtime.Stack(buf, true)
+		onStuck(time.Since(start), buf[:n])
+	})
+
+	var once sync.Once
+	done = func() {
+		once.Do(func() { timer.Stop() })
+	}
+	stuck = func() bool { return fired.Load() }
+	return done, stuck
+}
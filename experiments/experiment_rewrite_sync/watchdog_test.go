@@ -0,0 +1,62 @@
+package backfill
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatchdogFiresOnStuckWork(t *testing.T) {
+	w := &Watchdog{Timeout: 5 * time.Millisecond}
+
+	fired := make(chan struct{})
+	done, stuck := w.Watch(func(elapsed time.Duration, stack []byte) {
+		close(fired)
+	})
+	defer done()
+
+	select {
+	case <-fired:
+	case <-time.After(time.Second):
+		t.Fatal("onStuck was never called")
+	}
+	time.Sleep(time.Millisecond) // let the atomic store land before we read it
+	if !stuck() {
+		t.Error("stuck() = false, want true after onStuck fired")
+	}
+}
+
+func TestWatchdogDoneBeforeTimeoutNeverFires(t *testing.T) {
+	w := &Watchdog{Timeout: 50 * time.Millisecond}
+
+	done, stuck := w.Watch(func(elapsed time.Duration, stack []byte) {
+		t.Error("onStuck should not have been called")
+	})
+	done()
+
+	time.Sleep(60 * time.Millisecond)
+	if stuck() {
+		t.Error("stuck() = true, want false")
+	}
+}
+
+func TestNilWatchdogWatchIsNoop(t *testing.T) {
+	var w *Watchdog
+	done, stuck := w.Watch(func(elapsed time.Duration, stack []byte) {
+		t.Error("onStuck should never be called on a nil watchdog")
+	})
+	done()
+	if stuck() {
+		t.Error("stuck() = true, want false")
+	}
+}
+
+func TestZeroTimeoutWatchIsNoop(t *testing.T) {
+	w := &Watchdog{}
+	done, stuck := w.Watch(func(elapsed time.Duration, stack []byte) {
+		t.Error("onStuck should never be called with a zero timeout")
+	})
+	done()
+	if stuck() {
+		t.Error("stuck() = true, want false")
+	}
+}
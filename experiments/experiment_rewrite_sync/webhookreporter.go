@@ -0,0 +1,142 @@
+package backfill
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WebhookReporter posts run problems to a generic HTTP webhook (a Sentry
+// ingestion endpoint configured to accept plain JSON works fine, as does
+// any incident-alerting webhook) as they happen, so an unattended
+// overnight run surfaces failures immediately instead of finishing
+// silently with errors nobody notices until someone reads the logs the
+// next day. It implements Hooks directly rather than wrapping a vendored
+// Sentry SDK, since this module has no vendored dependencies.
+type WebhookReporter struct {
+	NoopHooks
+
+	url        string
+	runID      string
+	httpClient *http.Client
+
+	mu      sync.Mutex
+	classes map[string]*errorClassCount
+}
+
+type errorClassCount struct {
+	count   int
+	example string
+}
+
+// WebhookReporterOption configures a WebhookReporter constructed with
+// NewWebhookReporter.
+type WebhookReporterOption func(*WebhookReporter)
+
+// WithWebhookHTTPClient overrides the http.Client used to post events.
+// Defaults to http.DefaultClient.
+func WithWebhookHTTPClient(c *http.Client) WebhookReporterOption {
+	return func(r *WebhookReporter) { r.httpClient = c }
+}
+
+// NewWebhookReporter creates a WebhookReporter that posts JSON events to
+// url, tagging every event with runID (see NewRunID) so alerts from the
+// same run can be correlated with its logs and manifest.
+func NewWebhookReporter(url, runID string, opts ...WebhookReporterOption) *WebhookReporter {
+	r := &WebhookReporter{url: url, runID: runID, httpClient: http.DefaultClient, classes: make(map[string]*errorClassCount)}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// webhookEvent is the JSON body posted for every webhook call.
+type webhookEvent struct {
+	RunID   string `json:"run_id"`
+	Event   string `json:"event"`
+	DID     string `json:"did,omitempty"`
+	Message string `json:"message,omitempty"`
+	Stack   string `json:"stack,omitempty"`
+}
+
+// OnError records err under its error class (see classifyErr) for the
+// end-of-run summary posted by Close, and posts immediately if err is a
+// recovered panic — a worker crashing mid-run is worth flagging right
+// away rather than waiting for the run to finish.
+func (r *WebhookReporter) OnError(did string, err error) {
+	class := classifyErr(err)
+
+	r.mu.Lock()
+	c, ok := r.classes[class]
+	if !ok {
+		c = &errorClassCount{}
+		r.classes[class] = c
+	}
+	c.count++
+	if c.example == "" {
+		c.example = err.Error()
+	}
+	r.mu.Unlock()
+
+	if errors.Is(err, ErrPanic) {
+		_ = r.post(webhookEvent{RunID: r.runID, Event: "panic", DID: did, Message: err.Error()})
+	}
+}
+
+// OnDIDStuck posts immediately: a DID exceeding Config.StuckDIDTimeout is
+// exactly the kind of thing an unattended run needs to surface right
+// away, not bundle into the end-of-run summary.
+func (r *WebhookReporter) OnDIDStuck(did string, elapsed time.Duration, stack []byte) {
+	_ = r.post(webhookEvent{RunID: r.runID, Event: "stuck", DID: did, Message: fmt.Sprintf("stuck for %s", elapsed), Stack: string(stack)})
+}
+
+// Close posts one summary event per distinct error class seen since the
+// reporter was created, so a run that ended with a pile of e.g.
+// "rate_limited" errors shows up as one aggregated alert instead of a
+// webhook call per error.
+func (r *WebhookReporter) Close() error {
+	r.mu.Lock()
+	classes := r.classes
+	r.classes = make(map[string]*errorClassCount)
+	r.mu.Unlock()
+
+	var errs []error
+	for class, c := range classes {
+		msg := fmt.Sprintf("%d occurrence(s), e.g. %q", c.count, c.example)
+		if err := r.post(webhookEvent{RunID: r.runID, Event: "error_class:" + class, Message: msg}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *WebhookReporter) post(event webhookEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("webhook report: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook report: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook report: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook report: %s returned %s", r.url, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package backfill
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingWebhookServer struct {
+	mu     sync.Mutex
+	events []webhookEvent
+}
+
+func newRecordingWebhookServer(t *testing.T) (*recordingWebhookServer, *httptest.Server) {
+	t.Helper()
+	srv := &recordingWebhookServer{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev webhookEvent
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+			t.Errorf("webhook server: decode body: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		srv.mu.Lock()
+		srv.events = append(srv.events, ev)
+		srv.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(ts.Close)
+	return srv, ts
+}
+
+func (s *recordingWebhookServer) snapshot() []webhookEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]webhookEvent, len(s.events))
+	copy(out, s.events)
+	return out
+}
+
+func TestWebhookReporterOnErrorPostsImmediatelyOnlyForPanics(t *testing.T) {
+	srv, ts := newRecordingWebhookServer(t)
+	r := NewWebhookReporter(ts.URL, "run-1")
+
+	r.OnError("did:plc:alice", ErrRateLimited)
+	r.OnError("did:plc:bob", fmt.Errorf("worker crashed: %w", ErrPanic))
+
+	events := srv.snapshot()
+	if len(events) != 1 {
+		t.Fatalf("got %d immediate post(s), want 1 (only the panic)", len(events))
+	}
+	if events[0].Event != "panic" || events[0].DID != "did:plc:bob" || events[0].RunID != "run-1" {
+		t.Errorf("got %+v, want panic event for did:plc:bob tagged with run-1", events[0])
+	}
+}
+
+func TestWebhookReporterOnDIDStuckPostsImmediately(t *testing.T) {
+	srv, ts := newRecordingWebhookServer(t)
+	r := NewWebhookReporter(ts.URL, "run-1")
+
+	r.OnDIDStuck("did:plc:alice", 90*time.Second, []byte("goroutine 1 [running]:"))
+
+	events := srv.snapshot()
+	if len(events) != 1 || events[0].Event != "stuck" || events[0].DID != "did:plc:alice" {
+		t.Fatalf("got %+v, want a single stuck event for did:plc:alice", events)
+	}
+	if events[0].Stack == "" {
+		t.Error("got empty Stack, want the goroutine dump preserved")
+	}
+}
+
+func TestWebhookReporterCloseAggregatesOneSummaryPerErrorClass(t *testing.T) {
+	srv, ts := newRecordingWebhookServer(t)
+	r := NewWebhookReporter(ts.URL, "run-1")
+
+	r.OnError("did:plc:alice", ErrRateLimited)
+	r.OnError("did:plc:bob", ErrRateLimited)
+	r.OnError("did:plc:carol", ErrDIDNotFound)
+
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	events := srv.snapshot()
+	if len(events) != 2 {
+		t.Fatalf("got %d summary event(s), want 2 (one per distinct error class)", len(events))
+	}
+	for _, ev := range events {
+		if ev.RunID != "run-1" {
+			t.Errorf("summary event %+v missing RunID", ev)
+		}
+	}
+}
+
+func TestWebhookReporterCloseWithNoErrorsPostsNothing(t *testing.T) {
+	srv, ts := newRecordingWebhookServer(t)
+	r := NewWebhookReporter(ts.URL, "run-1")
+
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if events := srv.snapshot(); len(events) != 0 {
+		t.Fatalf("got %d event(s), want 0", len(events))
+	}
+}